@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// when the process started, for the uptime in /about
+var startTime = time.Now()
+
+// commitHash is the VCS revision the binary was built from, if Go recorded one.
+func commitHash() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 7 {
+			return setting.Value[:7]
+		}
+	}
+	return "unknown"
+}
+
+// runtimeFields describes the running instance for /about.
+func runtimeFields(locale discordgo.Locale, s *discordgo.Session) []*discordgo.MessageEmbedField {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return []*discordgo.MessageEmbedField{
+		{Name: T(locale, "about.uptime"), Value: time.Since(startTime).Truncate(time.Second).String(), Inline: true},
+		{Name: T(locale, "about.guilds"), Value: fmt.Sprint(len(s.State.Guilds)), Inline: true},
+		{Name: T(locale, "about.shard"), Value: fmt.Sprintf("%d/%d", s.ShardID+1, max(s.ShardCount, 1)), Inline: true},
+		{Name: "🐹 Go", Value: runtime.Version(), Inline: true},
+		{Name: T(locale, "about.commit"), Value: commitHash(), Inline: true},
+		{Name: T(locale, "about.memory"), Value: fmt.Sprintf("%.1f MiB", float64(mem.Alloc)/(1<<20)), Inline: true},
+	}
+}
+
+// the fixers FixEmbed builds on, credited in /about
+var credits = []struct{ Name, URL, Author string }{
+	{"FxTwitter", "https://github.com/FixTweet/FxTwitter", "FixTweet"},
+	{"InstaFix", "https://github.com/Wikidepia/InstaFix", "Wikidepia"},
+	{"vxReddit", "https://github.com/dylanpdx/vxReddit", "dylanpdx"},
+	{"fixthreads", "https://github.com/milanmdev/fixthreads", "milanmdev"},
+	{"phixiv", "https://github.com/thelaao/phixiv", "thelaao"},
+	{"VixBluesky", "https://github.com/Rapougnac/VixBluesky", "Rapougnac"},
+}
+
+func aboutCredits(locale discordgo.Locale) string {
+	lines := make([]string, 0, len(credits))
+	for _, c := range credits {
+		lines = append(lines, "- "+T(locale, "about.credit", "["+c.Name+"]("+c.URL+")", c.Author))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Permissions FixEmbed asks for when invited
+const INVITE_PERMISSIONS = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionSendMessagesInThreads |
+	discordgo.PermissionEmbedLinks | discordgo.PermissionAttachFiles | discordgo.PermissionManageMessages | discordgo.PermissionReadMessageHistory
+
+// inviteURL is the OAuth2 link that adds this instance of the bot to a server.
+func inviteURL(s *discordgo.Session) string {
+	return fmt.Sprintf("https://discord.com/oauth2/authorize?client_id=%s&scope=bot+applications.commands&permissions=%d", s.State.User.ID, INVITE_PERMISSIONS)
+}
@@ -0,0 +1,68 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// How reposts credit the person who posted the link
+const (
+	ATTRIBUTION_MENTION = "mention" // "Sent by @user"
+	ATTRIBUTION_SILENT  = "silent"  // "Sent by @user", without a ping
+	ATTRIBUTION_NAME    = "name"    // "Sent by user", without a ping
+	ATTRIBUTION_NONE    = "none"    // no "Sent by" line at all
+)
+
+var attributionDescriptions = map[string]string{
+	ATTRIBUTION_MENTION: "🔔 Reposts end with \"Sent by\" and a mention of whoever posted the link.",
+	ATTRIBUTION_SILENT:  "🔕 Reposts end with \"Sent by\" and a mention of whoever posted the link, without pinging them.",
+	ATTRIBUTION_NAME:    "🏷️ Reposts end with \"Sent by\" and the poster's name, without pinging them.",
+	ATTRIBUTION_NONE:    "🚫 Reposts have no \"Sent by\" line.",
+}
+
+// attributionMode is a guild's attribution mode. Only "none" and "silent" are stored as such;
+// mention and name are the MentionUsers setting, which members can still override with /pingme.
+func attributionMode(settings *GuildSettings) string {
+	if settings.Attribution == ATTRIBUTION_NONE || settings.Attribution == ATTRIBUTION_SILENT {
+		return settings.Attribution
+	}
+	if settings.MentionUsers {
+		return ATTRIBUTION_MENTION
+	}
+	return ATTRIBUTION_NAME
+}
+
+func handleAttributionCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	mode := i.ApplicationCommandData().Options[0].StringValue()
+	if _, ok := attributionDescriptions[mode]; !ok {
+		mode = ATTRIBUTION_MENTION
+	}
+	attribution := ""
+	if mode == ATTRIBUTION_NONE || mode == ATTRIBUTION_SILENT {
+		attribution = mode
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title:       s.State.User.Username,
+		Description: attributionDescriptions[mode],
+		Color:       accentColor(i.GuildID, 0x78b159),
+	}
+	err := st.UpdateGuildColumn(gidInt, "attribution", attribution)
+	if err == nil && attribution == "" {
+		err = updateDelivery(st, gidInt, func(gs *GuildSettings) { gs.MentionUsers = mode == ATTRIBUTION_MENTION })
+	}
+	if err != nil {
+		logf(LOG_WARN, "Error updating attribution for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the attribution."
+		embed.Color = 0xff0000
+	} else {
+		updated := *getGuildSettings(gidInt)
+		updated.Attribution = attribution
+		cacheGuildSettings(gidInt, &updated)
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributionCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	handleAttributionCommand(db, s, slashCommand("attribution", option("mode", ATTRIBUTION_NAME)))
+	if gs := getGuildSettings(1); attributionMode(gs) != ATTRIBUTION_NAME || gs.MentionUsers {
+		t.Errorf("settings = %+v, want names without mentions", gs)
+	}
+
+	handleAttributionCommand(db, s, slashCommand("attribution", option("mode", ATTRIBUTION_SILENT)))
+	postMessage(t, db, s, getGuildSettings(1), "https://x.com/a/status/1")
+	body := fake.body("POST /channels/20/messages")
+	if !strings.Contains(body, "Sent by \\u003c@5\\u003e") || !strings.Contains(body, `"allowed_mentions":{"parse":null,"replied_user":false}`) {
+		t.Errorf("silent repost %s, want a mention that pings nobody", body)
+	}
+
+	handleAttributionCommand(db, s, slashCommand("attribution", option("mode", ATTRIBUTION_NONE)))
+	gs := getGuildSettings(1)
+	if attributionMode(gs) != ATTRIBUTION_NONE {
+		t.Fatalf("mode = %s, want none", attributionMode(gs))
+	}
+	postMessage(t, db, s, gs, "https://x.com/a/status/1")
+	if body := fake.body("POST /channels/20/messages"); !strings.Contains(body, "fixupx.com/a/status/1") || strings.Contains(body, "Sent by") {
+		t.Errorf("repost %s, want the link without attribution", body)
+	}
+}
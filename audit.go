@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Changes shown by /settings history
+const AUDIT_HISTORY_SIZE = 15
+
+// settingsChange is one entry of a guild's settings history.
+type settingsChange struct {
+	UserID    int64
+	Setting   string
+	OldValue  string
+	NewValue  string
+	CreatedAt int64
+}
+
+func (st *sqliteStore) RecordSettingsChange(guildID int64, change settingsChange) error {
+	_, err := st.db.Exec("INSERT INTO settings_audit (guild_id, user_id, setting, old_value, new_value, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		guildID, change.UserID, change.Setting, change.OldValue, change.NewValue, change.CreatedAt)
+	return err
+}
+
+func (st *sqliteStore) SettingsHistory(guildID int64, limit int) ([]settingsChange, error) {
+	rows, err := st.db.Query("SELECT user_id, setting, old_value, new_value, created_at FROM settings_audit WHERE guild_id = ? ORDER BY id DESC LIMIT ?", guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []settingsChange
+	for rows.Next() {
+		var c settingsChange
+		if err := rows.Scan(&c.UserID, &c.Setting, &c.OldValue, &c.NewValue, &c.CreatedAt); err != nil {
+			continue
+		}
+		history = append(history, c)
+	}
+	return history, nil
+}
+
+// the names GuildSettings fields go by in the history: the labels admins see in /settings
+var settingLabels = map[string]string{
+	"EnabledServices":   "Service Settings",
+	"MentionUsers":      "Mention Users",
+	"DeleteOriginal":    "Delete Original",
+	"LinkButtons":       "Link Buttons",
+	"DirectMedia":       "Direct Media",
+	"RichEmbeds":        "Rich Embeds",
+	"ReuploadMedia":     "Re-upload Media",
+	"PreserveText":      "Keep Message Text",
+	"ProcessWebhooks":   "Webhooks",
+	"Simulate":          "Simulate Mode",
+	"Leaderboard":       "Leaderboard",
+	"DMFallback":        "DM Fallback",
+	"TranslateLanguage": "Translation",
+	"LinkLimit":         "Link Limit",
+	"OptOutKeyword":     "Opt-out Keyword",
+	"NSFWMode":          "NSFW Channels",
+	"LogChannel":        "Log Channel",
+	"Locale":            "Language",
+	"RepostTemplate":    "Repost Template",
+	"EmbedColor":        "Embed Color",
+	"Attribution":       "Attribution",
+	"MastodonInstances": "Mastodon Instances",
+	"Frontends":         "Fixer Frontends",
+}
+
+// settingValue formats a GuildSettings field the way the history shows it.
+func settingValue(field string, value reflect.Value) string {
+	switch field {
+	case "LogChannel":
+		if value.String() != "" {
+			return "<#" + value.String() + ">"
+		}
+	case "EmbedColor":
+		if value.Int() != 0 {
+			return formatColor(int(value.Int()))
+		}
+		return ""
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		return map[bool]string{true: "on", false: "off"}[value.Bool()]
+	case reflect.Slice:
+		items := make([]string, value.Len())
+		for idx := range items {
+			items[idx] = fmt.Sprint(value.Index(idx).Interface())
+		}
+		return strings.Join(items, ", ")
+	case reflect.Map:
+		items := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			items = append(items, fmt.Sprintf("%v: %v", key.Interface(), value.MapIndex(key).Interface()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// settingsSnapshot flattens everything an admin can change in a guild into display name -> value.
+func settingsSnapshot(st Store, s *discordgo.Session, guildID string) map[string]string {
+	gidInt, _ := discordIDStringToInt64(guildID)
+	snapshot := make(map[string]string)
+
+	settings := reflect.ValueOf(*getGuildSettings(gidInt))
+	for idx := 0; idx < settings.NumField(); idx++ {
+		field := settings.Type().Field(idx).Name
+		label, ok := settingLabels[field]
+		if !ok {
+			label = field
+		}
+		snapshot[label] = settingValue(field, settings.Field(idx))
+	}
+
+	if digest, err := st.DigestChannel(gidInt); err == nil && digest != 0 {
+		snapshot["Weekly Digest"] = fmt.Sprintf("<#%d>", digest)
+	}
+	ignoredUsers.RLock()
+	ignored := make([]string, 0, len(ignoredUsers.m[gidInt]))
+	for uid := range ignoredUsers.m[gidInt] {
+		ignored = append(ignored, fmt.Sprintf("<@%d>", uid))
+	}
+	ignoredUsers.RUnlock()
+	sort.Strings(ignored)
+	snapshot["Ignored Accounts"] = strings.Join(ignored, ", ")
+
+	if g, err := s.State.Guild(guildID); err == nil {
+		retention, err := st.LoadChannelRetention()
+		if err != nil {
+			logf(LOG_WARN, "Error loading retention policies for the settings history: %v", err)
+		}
+		// categories keep a state of their own, which channels created in them pick up
+		for _, ch := range append(slices.Clone(g.Channels), g.Threads...) {
+			cidInt, _ := discordIDStringToInt64(ch.ID)
+			channelStates.RLock()
+			state, ok := channelStates.m[cidInt]
+			channelStates.RUnlock()
+			if ok {
+				snapshot[fmt.Sprintf("<#%s>", ch.ID)] = map[bool]string{true: "activated", false: "deactivated"}[state]
+			}
+			if days := retention[cidInt]; days > 0 {
+				snapshot[fmt.Sprintf("<#%s> retention", ch.ID)] = fmt.Sprintf("%d day(s)", days)
+			}
+		}
+	}
+	channelOverrides.RLock()
+	for cid, o := range channelOverrides.m {
+		if o.GuildID == gidInt {
+			snapshot[fmt.Sprintf("<#%d> overrides", cid)] = describeOverride(o)
+		}
+	}
+	channelOverrides.RUnlock()
+	return snapshot
+}
+
+// recordSettingsChanges stores the differences between two snapshots as changes made by userID.
+func recordSettingsChanges(st Store, guildID, userID string, before, after map[string]string) {
+	gidInt, _ := discordIDStringToInt64(guildID)
+	uidInt, _ := discordIDStringToInt64(userID)
+	keys := make([]string, 0, len(after))
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range after {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now().Unix()
+	for _, key := range keys {
+		if before[key] == after[key] {
+			continue
+		}
+		err := st.RecordSettingsChange(gidInt, settingsChange{UserID: uidInt, Setting: key, OldValue: before[key], NewValue: after[key], CreatedAt: now})
+		if err != nil {
+			logf(LOG_WARN, "Error recording settings change in guild %s: %v", guildID, err)
+		}
+	}
+}
+
+// auditInteraction runs handle and records the configuration it changed, if the interaction is a configuration one.
+func auditInteraction(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, handle func()) {
+	configuring := false
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		configuring = isConfigurationCommand(i.ApplicationCommandData().Name)
+	case discordgo.InteractionMessageComponent:
+		configuring = !publicComponents[i.MessageComponentData().CustomID]
+	case discordgo.InteractionModalSubmit:
+		// every modal edits a setting (the repost template, the embed color)
+		configuring = true
+	}
+	if !configuring || i.GuildID == "" || !canConfigure(i) {
+		handle()
+		return
+	}
+
+	before := settingsSnapshot(st, s, i.GuildID)
+	handle()
+	recordSettingsChanges(st, i.GuildID, interactionUserID(i), before, settingsSnapshot(st, s, i.GuildID))
+}
+
+func handleSettingsHistory(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := interactionLocale(i)
+	embed := &discordgo.MessageEmbed{
+		Title: T(locale, "audit.title"),
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	history, err := st.SettingsHistory(gidInt, AUDIT_HISTORY_SIZE)
+	if err != nil {
+		logf(LOG_WARN, "Error reading settings history for guild %s: %v", i.GuildID, err)
+		embed.Description = T(locale, "audit.failed")
+		embed.Color = 0xff0000
+	} else {
+		var lines []string
+		for _, c := range history {
+			lines = append(lines, fmt.Sprintf("<t:%d:R> <@%d> **%s**: `%s` → `%s`", c.CreatedAt, c.UserID, c.Setting, orNone(locale, c.OldValue), orNone(locale, c.NewValue)))
+		}
+		if len(lines) == 0 {
+			embed.Description = T(locale, "audit.empty")
+		} else {
+			embed.Description = strings.Join(lines, "\n")
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			Flags:           1 << 6, // ephemeral
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func orNone(locale discordgo.Locale, value string) string {
+	if value == "" {
+		return T(locale, "audit.none")
+	}
+	return value
+}
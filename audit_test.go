@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSettingsAudit(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	click := componentClick("toggle_link_buttons")
+	auditInteraction(db, s, click, func() { onInteractionCreate(db, s, click) })
+	// a click by someone who can't configure changes nothing and records nothing
+	click = componentClick("toggle_link_buttons")
+	click.Member.Permissions = 0
+	auditInteraction(db, s, click, func() { onInteractionCreate(db, s, click) })
+
+	// modals edit settings too
+	submit := colorSubmit("#123456")
+	auditInteraction(db, s, submit, func() { onInteractionCreate(db, s, submit) })
+
+	var n int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM settings_audit WHERE guild_id = 1 AND user_id = 5").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("recorded %d changes, %v; want 2", n, err)
+	}
+	handleSettingsHistory(db, s, slashCommand("settings", option("history", nil)))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "**Link Buttons**: `off` → `on`") {
+		t.Errorf("history = %s", body)
+	}
+}
+
+func TestSettingsAuditCommands(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	if err := s.State.GuildAdd(&discordgo.Guild{ID: "1", Channels: []*discordgo.Channel{{ID: "20", GuildID: "1", Type: discordgo.ChannelTypeGuildText}}}); err != nil {
+		t.Fatal(err)
+	}
+	manage := int64(discordgo.PermissionManageGuild)
+	registerConfigurationCommands([]*discordgo.ApplicationCommand{
+		{Name: "retention", DefaultMemberPermissions: &manage},
+		{Name: "digest", DefaultMemberPermissions: &manage},
+		{Name: "ignore", DefaultMemberPermissions: &manage},
+	})
+	t.Cleanup(func() {
+		configurationCommands.Lock()
+		delete(configurationCommands.m, "retention")
+		delete(configurationCommands.m, "digest")
+		delete(configurationCommands.m, "ignore")
+		configurationCommands.Unlock()
+		ignoredUsers.Lock()
+		delete(ignoredUsers.m, 1)
+		ignoredUsers.Unlock()
+	})
+
+	user := option("user", "6")
+	user.Type = discordgo.ApplicationCommandOptionUser
+	for _, i := range []*discordgo.InteractionCreate{
+		slashCommand("retention", option("days", float64(7))),
+		slashCommand("digest", option("enabled", true)),
+		slashCommand("ignore", option("add", nil, user)),
+	} {
+		i.Member.Permissions = manage
+		auditInteraction(db, s, i, func() { onInteractionCreate(db, s, i) })
+	}
+
+	history, err := db.SettingsHistory(1, AUDIT_HISTORY_SIZE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, c := range history {
+		got[c.Setting] = c.NewValue
+	}
+	want := map[string]string{"<#20> retention": "7 day(s)", "Weekly Digest": "<#20>", "Ignored Accounts": "<@6>"}
+	for setting, value := range want {
+		if got[setting] != value {
+			t.Errorf("%s recorded as %q, want %q (history %v)", setting, got[setting], value, got)
+		}
+	}
+}
+
+func TestSettingLabels(t *testing.T) {
+	fields := reflect.TypeOf(GuildSettings{})
+	for idx := 0; idx < fields.NumField(); idx++ {
+		if _, ok := settingLabels[fields.Field(idx).Name]; !ok {
+			t.Errorf("GuildSettings.%s has no label in the settings history", fields.Field(idx).Name)
+		}
+	}
+}
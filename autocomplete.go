@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// serviceOption is a command option naming a service, completed from the service registry.
+func serviceOption(description string, required bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "service",
+		Description:  description,
+		Required:     required,
+		Autocomplete: true,
+	}
+}
+
+// lookupService finds a service by name or label, ignoring case; autocomplete is only a
+// suggestion, so commands can still receive whatever the user typed.
+func lookupService(input string) *Service {
+	input = strings.TrimSpace(input)
+	for _, svc := range services {
+		if strings.EqualFold(svc.Name, input) || strings.EqualFold(svc.label(), input) {
+			return svc
+		}
+	}
+	return nil
+}
+
+// focusedOption returns the option the user is typing in, looking inside subcommands.
+func focusedOption(opts []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range opts {
+		if opt.Focused {
+			return opt
+		}
+		if found := focusedOption(opt.Options); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// handleAutocomplete suggests values for the option being typed in.
+func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if opt := focusedOption(i.ApplicationCommandData().Options); opt != nil && opt.Name == "service" {
+		typed := strings.ToLower(opt.StringValue())
+		for _, svc := range services {
+			if len(choices) == MAX_SELECT_OPTIONS {
+				break
+			}
+			if strings.Contains(strings.ToLower(svc.Name), typed) || strings.Contains(strings.ToLower(svc.label()), typed) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: svc.label(), Value: svc.Name})
+			}
+		}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
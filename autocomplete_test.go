@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLookupService(t *testing.T) {
+	for input, want := range map[string]string{"twitter": "Twitter", " Reddit ": "Reddit", "myspace": ""} {
+		got := ""
+		if svc := lookupService(input); svc != nil {
+			got = svc.Name
+		}
+		if got != want {
+			t.Errorf("lookupService(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestHandleAutocomplete(t *testing.T) {
+	s, fake := newTestSession(t, nil)
+	typed := option("service", "redd")
+	typed.Focused = true
+	i := slashCommand("stats", option("days", float64(7)), typed)
+	i.Type = discordgo.InteractionApplicationCommandAutocomplete
+	handleAutocomplete(s, i)
+
+	body := fake.body("POST /interactions/900/token/callback")
+	if !strings.Contains(body, `"value":"Reddit"`) || strings.Contains(body, `"value":"Twitter"`) {
+		t.Errorf("choices = %s", body)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// setChannelState activates or deactivates a channel. For a category the state is kept for the
+// category itself (so channels created in it later pick it up) and applied to every channel in it;
+// the number of channels changed under the category is returned.
+func setChannelState(st Store, s *discordgo.Session, guildID, channelID string, state bool) int {
+	cidInt, _ := discordIDStringToInt64(channelID)
+	cacheChannelStates(state, cidInt)
+	_ = st.UpdateChannelState(cidInt, state)
+
+	ch, err := s.State.Channel(channelID)
+	if err != nil || ch.Type != discordgo.ChannelTypeGuildCategory {
+		return 0
+	}
+	g, err := s.State.Guild(guildID)
+	if err != nil {
+		return 0
+	}
+	changed := 0
+	for _, child := range g.Channels {
+		if child.ParentID != channelID || !isTrackedChannel(child) {
+			continue
+		}
+		childInt, _ := discordIDStringToInt64(child.ID)
+		cacheChannelStates(state, childInt)
+		_ = st.UpdateChannelState(childInt, state)
+		changed++
+	}
+	return changed
+}
+
+// onChannelCreate gives a new channel the state of the category it was created in.
+func onChannelCreate(st Store, s *discordgo.Session, c *discordgo.ChannelCreate) {
+	if c.ParentID == "" || !isTrackedChannel(c.Channel) {
+		return
+	}
+	parentInt, _ := discordIDStringToInt64(c.ParentID)
+	channelStates.RLock()
+	state, ok := channelStates.m[parentInt]
+	channelStates.RUnlock()
+	if !ok {
+		return
+	}
+	cidInt, _ := discordIDStringToInt64(c.ID)
+	cacheChannelStates(state, cidInt)
+	if err := st.UpdateChannelState(cidInt, state); err != nil {
+		logf(LOG_WARN, "Error applying category state to channel %s: %v", c.ID, err)
+	}
+}
+
+// Channels that can be left out of /activate-all and /deactivate-all
+const MAX_EXCEPT_CHANNELS = 5
+
+func exceptChannelOptions(verb string) []*discordgo.ApplicationCommandOption {
+	opts := make([]*discordgo.ApplicationCommandOption, 0, MAX_EXCEPT_CHANNELS)
+	for n := 1; n <= MAX_EXCEPT_CHANNELS; n++ {
+		name := "except"
+		if n > 1 {
+			name = fmt.Sprintf("except%d", n)
+		}
+		opts = append(opts, &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         name,
+			Description:  fmt.Sprintf("A channel (or category) that should not be %s", verb),
+			ChannelTypes: activatableChannelTypes,
+		})
+	}
+	return opts
+}
+
+// setGuildState activates or deactivates every channel in a guild, apart from the excluded
+// channels and the channels in excluded categories. It returns how many channels changed.
+func setGuildState(st Store, g *discordgo.Guild, state bool, except map[string]bool) (int, error) {
+	var ids []int64
+	for _, ch := range g.Channels {
+		if !isTrackedChannel(ch) || except[ch.ID] || (ch.ParentID != "" && except[ch.ParentID]) {
+			continue
+		}
+		cidInt, _ := discordIDStringToInt64(ch.ID)
+		ids = append(ids, cidInt)
+	}
+	cacheChannelStates(state, ids...)
+	return len(ids), st.UpdateChannelStates(ids, state)
+}
+
+func handleActivateAllCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, state bool) {
+	except := make(map[string]bool)
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Type == discordgo.ApplicationCommandOptionChannel {
+			except[opt.Value.(string)] = true
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	g, err := s.State.Guild(i.GuildID)
+	if err == nil {
+		var n int
+		n, err = setGuildState(st, g, state, except)
+		if err == nil {
+			verb := "✅ Activated"
+			if !state {
+				verb = "❌ Deactivated"
+				embed.Color = 0xff0000
+			}
+			embed.Description = fmt.Sprintf("%s in %d channel(s)!", verb, n)
+			if len(except) > 0 {
+				skipped := make([]string, 0, len(except))
+				for id := range except {
+					skipped = append(skipped, "<#"+id+">")
+				}
+				sort.Strings(skipped)
+				embed.Description += "\nLeft alone: " + strings.Join(skipped, ", ")
+			}
+		}
+	}
+	if err != nil {
+		logf(LOG_WARN, "Error updating channel states for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the channels."
+		embed.Color = 0xff0000
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
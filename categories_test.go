@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCategoryState(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "40", GuildID: "1", Type: discordgo.ChannelTypeGuildCategory})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "41", GuildID: "1", Type: discordgo.ChannelTypeGuildText, ParentID: "40"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "42", GuildID: "1", Type: discordgo.ChannelTypeGuildVoice, ParentID: "40"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "43", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	stored := func(id int64) (state bool) {
+		if err := db.db.QueryRow("SELECT state FROM channel_states WHERE channel_id = ?", id).Scan(&state); err != nil {
+			t.Fatalf("channel %d: %v", id, err)
+		}
+		return state
+	}
+
+	if n := setChannelState(db, s, "1", "40", false); n != 1 {
+		t.Errorf("deactivating the category changed %d channels, want 1", n)
+	}
+	if stored(40) || stored(41) {
+		t.Error("the category or its text channel is still active")
+	}
+	if _, ok := channelState(s, "43"); ok {
+		t.Error("a channel outside the category was changed")
+	}
+
+	onChannelCreate(db, s, &discordgo.ChannelCreate{Channel: &discordgo.Channel{ID: "44", GuildID: "1", Type: discordgo.ChannelTypeGuildText, ParentID: "40"}})
+	if enabled, ok := channelState(s, "44"); !ok || enabled || stored(44) {
+		t.Errorf("new channel state = %t, %t; want it to pick up the deactivated category", enabled, ok)
+	}
+	if n := setChannelState(db, s, "1", "43", true); n != 0 || !stored(43) {
+		t.Errorf("activating a plain channel changed %d others", n)
+	}
+}
+
+func TestActivateAllCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "40", GuildID: "1", Type: discordgo.ChannelTypeGuildCategory})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "41", GuildID: "1", Type: discordgo.ChannelTypeGuildText, ParentID: "40"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "43", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "45", GuildID: "1", Type: discordgo.ChannelTypeGuildNews})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "46", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+
+	except := option("except", "40")
+	except.Type = discordgo.ApplicationCommandOptionChannel
+	except2 := option("except2", "46")
+	except2.Type = discordgo.ApplicationCommandOptionChannel
+	handleActivateAllCommand(db, s, slashCommand("deactivate-all", except, except2), false)
+
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Deactivated in 2 channel(s)") {
+		t.Errorf("response = %s", body)
+	}
+	for id, want := range map[string]bool{"41": false, "43": true, "45": true, "46": false} {
+		if _, ok := channelState(s, id); ok != want {
+			t.Errorf("channel %s changed = %t, want %t", id, ok, want)
+		}
+	}
+	var n int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM channel_states WHERE state = 0").Scan(&n); err != nil || n != 2 {
+		t.Errorf("stored %d deactivated channels, %v; want 2", n, err)
+	}
+}
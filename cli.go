@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CLI modes that manage application commands over REST and exit without opening the gateway
+var cliCommands = map[string]func(s *discordgo.Session, appID string) error{
+	"register-commands":   registerCommandsCLI,
+	"unregister-commands": unregisterCommandsCLI,
+	"list-commands":       listCommandsCLI,
+}
+
+// runCLI runs the CLI mode named by args[0], if any, and reports whether it handled it.
+func runCLI(token string, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	run, ok := cliCommands[args[0]]
+	if !ok {
+		return false
+	}
+	s, err := discordgo.New("Bot " + token)
+	if err != nil {
+		log.Fatalf("Error creating Discord session: %v", err)
+	}
+	// a bot's application ID is its user ID
+	me, err := s.User("@me")
+	if err != nil {
+		log.Fatalf("Error fetching the bot user: %v", err)
+	}
+	if err := run(s, me.ID); err != nil {
+		log.Fatalf("Error running %s: %v", args[0], err)
+	}
+	return true
+}
+
+// botGuilds lists every guild the bot is in, following the API's pagination.
+func botGuilds(s *discordgo.Session) ([]*discordgo.UserGuild, error) {
+	var guilds []*discordgo.UserGuild
+	after := ""
+	for {
+		page, err := s.UserGuilds(200, "", after, false)
+		if err != nil {
+			return nil, err
+		}
+		guilds = append(guilds, page...)
+		if len(page) < 200 {
+			return guilds, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+func registerCommandsCLI(s *discordgo.Session, appID string) error {
+	commands, globalCommands := applicationCommands()
+	if _, err := s.ApplicationCommandBulkOverwrite(appID, "", globalCommands); err != nil {
+		return fmt.Errorf("global commands: %w", err)
+	}
+	logf(LOG_INFO, "Registered %d global command(s)", len(globalCommands))
+	return overwriteGuildCommands(s, appID, commands)
+}
+
+// unregisterCommandsCLI removes every command FixEmbed has registered, including stale
+// ones left behind by older versions.
+func unregisterCommandsCLI(s *discordgo.Session, appID string) error {
+	if _, err := s.ApplicationCommandBulkOverwrite(appID, "", []*discordgo.ApplicationCommand{}); err != nil {
+		return fmt.Errorf("global commands: %w", err)
+	}
+	logf(LOG_INFO, "Removed the global commands")
+	return overwriteGuildCommands(s, appID, []*discordgo.ApplicationCommand{})
+}
+
+func overwriteGuildCommands(s *discordgo.Session, appID string, commands []*discordgo.ApplicationCommand) error {
+	guilds, err := botGuilds(s)
+	if err != nil {
+		return fmt.Errorf("listing guilds: %w", err)
+	}
+	synced := 0
+	for _, g := range guilds {
+		if _, err := s.ApplicationCommandBulkOverwrite(appID, g.ID, commands); err != nil {
+			logf(LOG_WARN, "Warning: failed to sync commands in guild %s: %v", g.ID, err)
+			continue
+		}
+		synced++
+	}
+	logf(LOG_INFO, "Synchronized %d command(s) across %d of %d guild(s)", len(commands), synced, len(guilds))
+	return nil
+}
+
+func listCommandsCLI(s *discordgo.Session, appID string) error {
+	global, err := s.ApplicationCommands(appID, "")
+	if err != nil {
+		return fmt.Errorf("global commands: %w", err)
+	}
+	printCommands("global", global)
+	guilds, err := botGuilds(s)
+	if err != nil {
+		return fmt.Errorf("listing guilds: %w", err)
+	}
+	for _, g := range guilds {
+		cmds, err := s.ApplicationCommands(appID, g.ID)
+		if err != nil {
+			logf(LOG_WARN, "Warning: failed to list commands in guild %s: %v", g.ID, err)
+			continue
+		}
+		printCommands(fmt.Sprintf("%s (%s)", g.Name, g.ID), cmds)
+	}
+	return nil
+}
+
+func printCommands(scope string, cmds []*discordgo.ApplicationCommand) {
+	fmt.Printf("%s: %d command(s)\n", scope, len(cmds))
+	for _, cmd := range cmds {
+		fmt.Printf("  %s\t%s\t%s\n", cmd.ID, cmd.Name, cmd.Description)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommandsCLI(t *testing.T) {
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) {
+		if strings.HasSuffix(r.URL.Path, "/users/@me/guilds") {
+			return http.StatusOK, `[{"id": "1", "name": "one"}, {"id": "2", "name": "two"}]`
+		}
+		return http.StatusOK, "[]"
+	})
+
+	if err := registerCommandsCLI(s, "1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range []string{"PUT /applications/1/commands", "PUT /applications/1/guilds/1/commands", "PUT /applications/1/guilds/2/commands"} {
+		if !slices.Contains(fake.calls(), call) {
+			t.Errorf("calls %v lack %s", fake.calls(), call)
+		}
+	}
+	if body := fake.body("PUT /applications/1/guilds/2/commands"); !strings.Contains(body, `"name":"activate"`) {
+		t.Errorf("registered %s, want the guild commands", body)
+	}
+
+	if err := unregisterCommandsCLI(s, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if body := fake.body("PUT /applications/1/guilds/2/commands"); strings.TrimSpace(body) != "[]" {
+		t.Errorf("unregistering sent %s, want no commands", body)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// accentColor is a guild's embed color, or fallback when it hasn't picked one. It reads the
+// settings cache only, so it's cheap enough to call for every embed.
+func accentColor(guildID string, fallback int) int {
+	if guildID == "" {
+		return fallback
+	}
+	gidInt, _ := discordIDStringToInt64(guildID)
+	botSettings.RLock()
+	settings := botSettings.m[gidInt]
+	botSettings.RUnlock()
+	if settings == nil || settings.EmbedColor == 0 {
+		return fallback
+	}
+	return settings.EmbedColor
+}
+
+// parseColor accepts "#5865F2", "5865f2" and "0x5865F2".
+func parseColor(input string) (int, bool) {
+	hex := strings.TrimSpace(input)
+	hex = strings.TrimPrefix(hex, "#")
+	hex = strings.TrimPrefix(strings.ToLower(hex), "0x")
+	if len(hex) != 6 {
+		return 0, false
+	}
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(color), true
+}
+
+func formatColor(color int) string {
+	return fmt.Sprintf("#%06X", color)
+}
+
+// embedColorModal asks for the guild's embed color; leaving it empty restores the defaults.
+func embedColorModal(settings *GuildSettings) *discordgo.InteractionResponseData {
+	current := ""
+	if settings.EmbedColor != 0 {
+		current = formatColor(settings.EmbedColor)
+	}
+	return &discordgo.InteractionResponseData{
+		CustomID: "embed_color",
+		Title:    "Embed Color",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "color",
+					Label:       "Hex color (empty for the default colors)",
+					Style:       discordgo.TextInputShort,
+					Placeholder: "#5865F2",
+					Value:       current,
+					MaxLength:   8,
+				},
+			}},
+		},
+	}
+}
+
+func handleEmbedColorModal(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	input := ""
+	for _, row := range i.ModalSubmitData().Components {
+		for _, c := range row.(*discordgo.ActionsRow).Components {
+			if text, ok := c.(*discordgo.TextInput); ok && text.CustomID == "color" {
+				input = strings.TrimSpace(text.Value)
+			}
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{Title: "Embed Color"}
+	color := 0
+	ok := true
+	if input != "" {
+		color, ok = parseColor(input)
+	}
+	if !ok {
+		embed.Description = fmt.Sprintf("❌ `%s` isn't a hex color like `#5865F2`.", input)
+		embed.Color = 0xff0000
+	} else {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		if err := st.UpdateGuildColumn(gidInt, "embed_color", color); err != nil {
+			logf(LOG_WARN, "Error updating embed color for guild %s: %v", i.GuildID, err)
+			embed.Description = "❌ Could not update the embed color."
+			embed.Color = 0xff0000
+		} else {
+			updated := *getGuildSettings(gidInt)
+			updated.EmbedColor = color
+			cacheGuildSettings(gidInt, &updated)
+			if color == 0 {
+				embed.Description = "🎨 FixEmbed's embeds are back to their default colors."
+			} else {
+				embed.Description = fmt.Sprintf("🎨 FixEmbed's embeds will use %s.", formatColor(color))
+			}
+			embed.Color = accentColor(i.GuildID, 0x78b159)
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  1 << 6, // ephemeral
+		},
+	})
+}
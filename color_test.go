@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseColor(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  int
+		ok    bool
+	}{
+		{"#5865F2", 0x5865F2, true},
+		{"5865f2", 0x5865F2, true},
+		{" 0x5865F2 ", 0x5865F2, true},
+		{"#fff", 0, false},
+		{"purple", 0, false},
+	} {
+		if got, ok := parseColor(tt.input); got != tt.want || ok != tt.ok {
+			t.Errorf("parseColor(%q) = %#x, %v, want %#x, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// colorSubmit is a submission of the embed color modal.
+func colorSubmit(color string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "900",
+		Token:   "token",
+		Type:    discordgo.InteractionModalSubmit,
+		GuildID: "1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "5"}, Permissions: discordgo.PermissionManageGuild},
+		Data: discordgo.ModalSubmitInteractionData{CustomID: "embed_color", Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: "color", Value: color}}},
+		}},
+	}}
+}
+
+func TestEmbedColorModal(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	onInteractionCreate(db, s, colorSubmit("#123456"))
+	if got := getGuildSettings(1).EmbedColor; got != 0x123456 {
+		t.Errorf("stored color = %#x", got)
+	}
+	if got := accentColor("1", 0x00ff00); got != 0x123456 {
+		t.Errorf("accentColor = %#x, want the guild's color", got)
+	}
+
+	onInteractionCreate(db, s, colorSubmit("nope"))
+	if got := getGuildSettings(1).EmbedColor; got != 0x123456 {
+		t.Errorf("stored color = %#x after an invalid one", got)
+	}
+
+	onInteractionCreate(db, s, colorSubmit(""))
+	if got := accentColor("1", 0x00ff00); got != 0x00ff00 {
+		t.Errorf("accentColor = %#x, want the default after clearing", got)
+	}
+}
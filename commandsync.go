@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the per-guild command set built in Ready, for guilds joined later on
+var guildCommands struct {
+	sync.Mutex
+	commands []*discordgo.ApplicationCommand
+	synced   map[string]bool
+}
+
+// setGuildCommands stores the command set that syncGuildCommands registers.
+func setGuildCommands(commands []*discordgo.ApplicationCommand) {
+	guildCommands.Lock()
+	guildCommands.commands = commands
+	if guildCommands.synced == nil {
+		guildCommands.synced = make(map[string]bool)
+	}
+	guildCommands.Unlock()
+}
+
+// syncGuildCommands registers the command set in a guild unless that's already been done.
+// GuildCreate fires for every guild on startup too, so this claims the guild first to keep
+// Ready and onGuildCreate from both overwriting it.
+func syncGuildCommands(s *discordgo.Session, guildID string) bool {
+	guildCommands.Lock()
+	commands := guildCommands.commands
+	if commands == nil || guildCommands.synced[guildID] {
+		guildCommands.Unlock()
+		return false
+	}
+	guildCommands.synced[guildID] = true
+	guildCommands.Unlock()
+
+	// ApplicationCommandBulkOverwrite replaces the guild's commands with exactly `commands`.
+	if _, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, commands); err != nil {
+		logf(LOG_WARN, "Warning: failed to sync commands in guild %s: %v", guildID, err)
+		guildCommands.Lock()
+		delete(guildCommands.synced, guildID)
+		guildCommands.Unlock()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSyncGuildCommands(t *testing.T) {
+	failing := true
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) {
+		if r.Method == http.MethodPut && failing {
+			return http.StatusForbidden, "{}"
+		}
+		return http.StatusOK, "[]"
+	})
+	t.Cleanup(func() { setGuildCommands(nil) })
+
+	if syncGuildCommands(s, "1") {
+		t.Fatal("synced before Ready built the commands")
+	}
+	setGuildCommands([]*discordgo.ApplicationCommand{{Name: "fix", Description: "Fix a link"}})
+
+	// a failed sync is retried on the next GuildCreate
+	if syncGuildCommands(s, "1") {
+		t.Fatal("a failed sync reported success")
+	}
+	failing = false
+	if !syncGuildCommands(s, "1") {
+		t.Fatal("did not sync a new guild")
+	}
+	if syncGuildCommands(s, "1") {
+		t.Error("synced the same guild twice")
+	}
+	if !slices.Contains(fake.calls(), "PUT /applications/1/guilds/1/commands") {
+		t.Errorf("calls %v do not overwrite the guild's commands", fake.calls())
+	}
+}
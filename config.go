@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Core settings, set by flags that default to their environment variables
+type config struct {
+	ConfigFile       string
+	DBPath           string
+	LogLevel         string
+	TokenFile        string
+	StatusInterval   time.Duration
+	RegisterCommands bool
+	LiveStatuses     bool
+
+	// several instances can share one database file, each running some of the bot's shards:
+	// each reloads its caches when another one changes something, and only the primary runs
+	// scheduled jobs. The file must be reachable by all of them (one host or a shared volume).
+	ShardID           int
+	ShardCount        int
+	StoreSyncInterval time.Duration
+	Primary           bool
+}
+
+// How often instances pick up each other's changes unless told otherwise
+const DEFAULT_STORE_SYNC_INTERVAL = 10 * time.Second
+
+var cfg = config{
+	DBPath:            "fixembed_data.db",
+	LogLevel:          LOG_INFO,
+	StatusInterval:    60 * time.Second,
+	RegisterCommands:  true,
+	LiveStatuses:      true,
+	ShardCount:        1,
+	StoreSyncInterval: DEFAULT_STORE_SYNC_INTERVAL,
+	Primary:           true,
+}
+
+// flags given on the command line, which a config reload leaves alone
+var explicitFlags = make(map[string]bool)
+
+// parseFlags reads the environment and then the command line into cfg, and returns what's
+// left of the command line (a CLI mode, if any).
+func parseFlags(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("fixembed", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: fixembed [flags] [register-commands | unregister-commands | list-commands]")
+		fs.PrintDefaults()
+	}
+	// read by configPath before the flags are parsed; registered so the flag is accepted
+	fs.String("config", "", "TOML or YAML config file (CONFIG_FILE, default config.toml or config.yaml)")
+	fs.StringVar(&cfg.DBPath, "db", envString("DB_PATH", cfg.DBPath), "SQLite database path; instances sharing it must all be able to open the file (DB_PATH)")
+	fs.StringVar(&cfg.LogLevel, "log-level", envString("LOG_LEVEL", cfg.LogLevel), "debug, info or warn (LOG_LEVEL)")
+	fs.StringVar(&cfg.TokenFile, "token-file", envString("BOT_TOKEN_FILE", cfg.TokenFile), "read the bot token from this file instead of BOT_TOKEN (BOT_TOKEN_FILE)")
+	fs.DurationVar(&cfg.StatusInterval, "status-interval", envDuration("STATUS_INTERVAL", cfg.StatusInterval), "how often the status rotates (STATUS_INTERVAL)")
+	fs.BoolVar(&cfg.LiveStatuses, "live-statuses", envBool("LIVE_STATUSES", cfg.LiveStatuses), "show server and link counts between the statuses (LIVE_STATUSES)")
+	fs.BoolVar(&cfg.RegisterCommands, "register-commands", envBool("REGISTER_COMMANDS", cfg.RegisterCommands), "sync slash commands on startup (REGISTER_COMMANDS)")
+	fs.IntVar(&cfg.ShardID, "shard-id", envInt("SHARD_ID", cfg.ShardID), "the shard this instance runs, from 0 (SHARD_ID)")
+	fs.IntVar(&cfg.ShardCount, "shard-count", envInt("SHARD_COUNT", cfg.ShardCount), "how many shards the bot is split into across instances (SHARD_COUNT)")
+	fs.DurationVar(&cfg.StoreSyncInterval, "store-sync-interval", envDuration("STORE_SYNC_INTERVAL", cfg.StoreSyncInterval), "how often to pick up changes from other instances sharing the database, 0 for never (STORE_SYNC_INTERVAL)")
+	fs.BoolVar(&cfg.Primary, "primary", envBool("PRIMARY_INSTANCE", cfg.Primary), "run the scheduled jobs (digests, retention, top.gg) in this instance (PRIMARY_INSTANCE)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) { explicitFlags[f.Name] = true })
+	if !isLogLevel(cfg.LogLevel) {
+		return nil, fmt.Errorf("unknown log level %q", cfg.LogLevel)
+	}
+	if cfg.StatusInterval <= 0 {
+		return nil, fmt.Errorf("status interval must be positive, got %s", cfg.StatusInterval)
+	}
+	if cfg.ShardCount < 1 || cfg.ShardID < 0 || cfg.ShardID >= cfg.ShardCount {
+		return nil, fmt.Errorf("shard %d of %d doesn't exist", cfg.ShardID, cfg.ShardCount)
+	}
+	if cfg.ShardCount > 1 && !isSet("primary", "PRIMARY_INSTANCE") {
+		// leave the scheduled jobs to the one instance explicitly made primary, so they don't
+		// run once per shard
+		cfg.Primary = false
+	}
+	return fs.Args(), nil
+}
+
+// isSet reports whether a setting was given on the command line or in the environment.
+func isSet(flagName, envKey string) bool {
+	return explicitFlags[flagName] || os.Getenv(envKey) != ""
+}
+
+func envString(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			return n
+		}
+		logf(LOG_WARN, "%s: %v", key, err)
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d
+		}
+		logf(LOG_WARN, "%s: %v", key, err)
+	}
+	return fallback
+}
+
+func envBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			return b
+		}
+		logf(LOG_WARN, "%s: %v", key, err)
+	}
+	return fallback
+}
+
+// botToken is BOT_TOKEN, or the contents of the token file when one is given (for secrets
+// mounted as files).
+func botToken() (string, error) {
+	if cfg.TokenFile == "" {
+		return os.Getenv("BOT_TOKEN"), nil
+	}
+	data, err := os.ReadFile(cfg.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Log levels, from most to least verbose
+const (
+	LOG_DEBUG = "debug"
+	LOG_INFO  = "info"
+	LOG_WARN  = "warn"
+)
+
+var logLevels = map[string]int{LOG_DEBUG: 0, LOG_INFO: 1, LOG_WARN: 2}
+
+// the configured level, as a logLevels value; lines below it are dropped
+var logThreshold atomic.Int32
+
+// logf logs a line at the given level, if the configured level lets it through. Debug lines
+// are tagged "[DEBUG]" so they stand out.
+func logf(level string, format string, v ...interface{}) {
+	if int32(logLevels[level]) < logThreshold.Load() {
+		return
+	}
+	if level == LOG_DEBUG {
+		format = "[DEBUG] " + format
+	}
+	_ = log.Output(2, fmt.Sprintf(format, v...))
+}
+
+func isLogLevel(level string) bool {
+	_, ok := logLevels[level]
+	return ok
+}
+
+func setLogLevel(level string) {
+	logThreshold.Store(int32(logLevels[level]))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlags(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+	t.Setenv("DB_PATH", "/data/env.db")
+	t.Setenv("STATUS_INTERVAL", "2m")
+
+	// flags win over the environment, which wins over the defaults
+	args, err := parseFlags([]string{"-db", "/data/flag.db", "list-commands"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPath != "/data/flag.db" || cfg.StatusInterval != 2*time.Minute || cfg.LogLevel != LOG_INFO {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if len(args) != 1 || args[0] != "list-commands" {
+		t.Errorf("args = %v, want the CLI mode", args)
+	}
+
+	if _, err := parseFlags([]string{"-log-level", "loud"}); err == nil {
+		t.Error("accepted an unknown log level")
+	}
+	if _, err := parseFlags([]string{"-status-interval", "0s"}); err == nil {
+		t.Error("accepted a zero status interval")
+	}
+}
+
+func TestParseFlagsSharded(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+
+	// instances may share the database whether sharded or not, so they always sync by default
+	if _, err := parseFlags(nil); err != nil || cfg.StoreSyncInterval != DEFAULT_STORE_SYNC_INTERVAL || !cfg.Primary {
+		t.Errorf("sync interval = %s, primary = %t, %v", cfg.StoreSyncInterval, cfg.Primary, err)
+	}
+
+	// sharded instances leave the jobs alone unless made primary
+	if _, err := parseFlags([]string{"-shard-id", "1", "-shard-count", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.StoreSyncInterval != DEFAULT_STORE_SYNC_INTERVAL || cfg.Primary {
+		t.Errorf("sync interval = %s, primary = %t", cfg.StoreSyncInterval, cfg.Primary)
+	}
+
+	cfg = saved
+	t.Setenv("PRIMARY_INSTANCE", "true")
+	if _, err := parseFlags([]string{"-shard-count", "2"}); err != nil || !cfg.Primary {
+		t.Errorf("primary = %t, %v; want the explicit setting kept", cfg.Primary, err)
+	}
+
+	if _, err := parseFlags([]string{"-shard-id", "2", "-shard-count", "2"}); err == nil {
+		t.Error("accepted a shard past the count")
+	}
+}
+
+func TestBotTokenFile(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+	path := t.TempDir() + "/token"
+	if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.TokenFile = path
+	if token, err := botToken(); err != nil || token != "secret" {
+		t.Errorf("botToken() = %q, %v", token, err)
+	}
+}
+
+func TestLogf(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		setLogLevel(LOG_DEBUG)
+	})
+
+	setLogLevel(LOG_INFO)
+	logf(LOG_DEBUG, "hidden")
+	logf(LOG_INFO, "shown %d", 1)
+	setLogLevel(LOG_WARN)
+	logf(LOG_INFO, "hidden")
+	logf(LOG_WARN, "shown %d", 2)
+	if got := out.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "shown 1") || !strings.Contains(got, "shown 2") {
+		t.Errorf("logged %q", got)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config files looked for when neither -config nor CONFIG_FILE names one
+var defaultConfigFiles = []string{"config.toml", "config.yaml", "config.yml"}
+
+// environment variables set from the config file rather than the real environment
+var configFileEnv = make(map[string]bool)
+
+// fileConfig is the optional config.toml / config.yaml, e.g.
+//
+//	log_level = "info"
+//	statuses = ["for Twitter links", "in {guilds} servers"]
+//	status_interval = "2m"
+//
+//	[database]
+//	path = "/data/fixembed.db"
+//
+//	[fixers]
+//	mastodon = "fxmastodon.example"
+//	fallbacks = { Twitter = ["vxtwitter.com", "fixvx.com"] }
+//
+// Every value is the default for an environment variable, so the environment (and then
+// the command line) still wins.
+type fileConfig struct {
+	Token            string   `toml:"token" yaml:"token"`
+	TokenFile        string   `toml:"token_file" yaml:"token_file"`
+	OwnerID          string   `toml:"owner_id" yaml:"owner_id"`
+	LogLevel         string   `toml:"log_level" yaml:"log_level"`
+	StatusInterval   string   `toml:"status_interval" yaml:"status_interval"`
+	Statuses         []string `toml:"statuses" yaml:"statuses"`
+	LiveStatuses     *bool    `toml:"live_statuses" yaml:"live_statuses"`
+	Primary          *bool    `toml:"primary" yaml:"primary"`
+	RegisterCommands *bool    `toml:"register_commands" yaml:"register_commands"`
+
+	Database struct {
+		Backend      string `toml:"backend" yaml:"backend"`
+		Path         string `toml:"path" yaml:"path"`
+		SyncInterval string `toml:"sync_interval" yaml:"sync_interval"`
+	} `toml:"database" yaml:"database"`
+
+	Shards struct {
+		ID    *int `toml:"id" yaml:"id"`
+		Count int  `toml:"count" yaml:"count"`
+	} `toml:"shards" yaml:"shards"`
+
+	Fixers struct {
+		ServicesFile string              `toml:"services_file" yaml:"services_file"`
+		Mastodon     string              `toml:"mastodon" yaml:"mastodon"`
+		Nitter       []string            `toml:"nitter" yaml:"nitter"`
+		Fallbacks    map[string][]string `toml:"fallbacks" yaml:"fallbacks"`
+	} `toml:"fixers" yaml:"fixers"`
+
+	Redis struct {
+		URL string `toml:"url" yaml:"url"`
+	} `toml:"redis" yaml:"redis"`
+
+	RateLimit struct {
+		Messages int    `toml:"messages" yaml:"messages"`
+		Window   string `toml:"window" yaml:"window"`
+	} `toml:"rate_limit" yaml:"rate_limit"`
+
+	Features struct {
+		TopggToken        string `toml:"topgg_token" yaml:"topgg_token"`
+		PremiumSKU        string `toml:"premium_sku_id" yaml:"premium_sku_id"`
+		MetadataCacheTTL  string `toml:"metadata_cache_ttl" yaml:"metadata_cache_ttl"`
+		MetadataCacheSize int    `toml:"metadata_cache_size" yaml:"metadata_cache_size"`
+	} `toml:"features" yaml:"features"`
+}
+
+// configPath finds the config file: -config on the command line, then CONFIG_FILE, then
+// the first default file that exists. It scans args itself because the file has to be read
+// before the flags, whose defaults come from the environment it fills in.
+func configPath(args []string) string {
+	for idx, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if idx+1 < len(args) {
+			return args[idx+1]
+		}
+	}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	for _, path := range defaultConfigFiles {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadConfigFile reads a TOML or YAML config file (by extension) and sets the environment
+// variables it covers that aren't already set.
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fc fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &fc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	default:
+		return fmt.Errorf("%s: unknown config format, use .toml or .yaml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if fc.Database.Backend != "" && fc.Database.Backend != "sqlite" {
+		return fmt.Errorf("%s: unsupported database backend %q", path, fc.Database.Backend)
+	}
+
+	fallbacks := make([]string, 0, len(fc.Fixers.Fallbacks))
+	for name, hosts := range fc.Fixers.Fallbacks {
+		fallbacks = append(fallbacks, name+"="+strings.Join(hosts, ","))
+	}
+	sort.Strings(fallbacks)
+	registerCommands, liveStatuses, primary := "", "", ""
+	if fc.RegisterCommands != nil {
+		registerCommands = strconv.FormatBool(*fc.RegisterCommands)
+	}
+	if fc.LiveStatuses != nil {
+		liveStatuses = strconv.FormatBool(*fc.LiveStatuses)
+	}
+	if fc.Primary != nil {
+		primary = strconv.FormatBool(*fc.Primary)
+	}
+
+	defaults := map[string]string{
+		"BOT_TOKEN":             fc.Token,
+		"BOT_TOKEN_FILE":        fc.TokenFile,
+		"OWNER_ID":              fc.OwnerID,
+		"LOG_LEVEL":             fc.LogLevel,
+		"STATUS_INTERVAL":       fc.StatusInterval,
+		"STATUSES":              strings.Join(fc.Statuses, STATUS_SEPARATOR),
+		"LIVE_STATUSES":         liveStatuses,
+		"REGISTER_COMMANDS":     registerCommands,
+		"DB_PATH":               fc.Database.Path,
+		"STORE_SYNC_INTERVAL":   fc.Database.SyncInterval,
+		"PRIMARY_INSTANCE":      primary,
+		"SERVICES_FILE":         fc.Fixers.ServicesFile,
+		"MASTODON_FIXER_DOMAIN": fc.Fixers.Mastodon,
+		"FIXER_FALLBACKS":       strings.Join(fallbacks, ";"),
+		"RATE_LIMIT_WINDOW":     fc.RateLimit.Window,
+		"REDIS_URL":             fc.Redis.URL,
+		"TOPGG_TOKEN":           fc.Features.TopggToken,
+		"PREMIUM_SKU_ID":        fc.Features.PremiumSKU,
+		"METADATA_CACHE_TTL":    fc.Features.MetadataCacheTTL,
+	}
+	if fc.Fixers.Nitter != nil {
+		// an empty list is meaningful: it turns the Nitter mirrors off
+		defaults["NITTER_DOMAINS"] = strings.Join(fc.Fixers.Nitter, ",")
+	}
+	if fc.RateLimit.Messages > 0 {
+		defaults["RATE_LIMIT_MESSAGES"] = strconv.Itoa(fc.RateLimit.Messages)
+	}
+	if fc.Shards.ID != nil {
+		defaults["SHARD_ID"] = strconv.Itoa(*fc.Shards.ID)
+	}
+	if fc.Shards.Count > 0 {
+		defaults["SHARD_COUNT"] = strconv.Itoa(fc.Shards.Count)
+	}
+	if fc.Features.MetadataCacheSize > 0 {
+		defaults["METADATA_CACHE_SIZE"] = strconv.Itoa(fc.Features.MetadataCacheSize)
+	}
+	for key, value := range defaults {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if value != "" || key == "NITTER_DOMAINS" {
+			os.Setenv(key, value)
+			configFileEnv[key] = true
+		}
+	}
+	return nil
+}
+
+// reloadConfigFile loads the config file again, replacing whatever the previous load set
+// in the environment.
+func reloadConfigFile(path string) error {
+	previous := make(map[string]string, len(configFileEnv))
+	for key := range configFileEnv {
+		previous[key] = os.Getenv(key)
+		os.Unsetenv(key)
+	}
+	configFileEnv = make(map[string]bool)
+	if err := loadConfigFile(path); err != nil {
+		// keep running with the previous file's values
+		for key, value := range previous {
+			os.Setenv(key, value)
+			configFileEnv[key] = true
+		}
+		return err
+	}
+	return nil
+}
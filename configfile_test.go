@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfigPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		defaults []string // files created in the working directory
+		want     string
+	}{
+		{name: "nothing", want: ""},
+		{name: "flag", args: []string{"-config", "a.toml"}, want: "a.toml"},
+		{name: "flag with =", args: []string{"-debug", "--config=b.yaml"}, want: "b.yaml"},
+		{name: "flag over environment", args: []string{"-config", "a.toml"}, env: "env.toml", want: "a.toml"},
+		{name: "flag without a value", args: []string{"-config"}, env: "env.toml", want: "env.toml"},
+		{name: "after --", args: []string{"--", "-config", "a.toml"}, want: ""},
+		{name: "environment", env: "env.toml", want: "env.toml"},
+		{name: "environment over defaults", env: "env.toml", defaults: []string{"config.toml"}, want: "env.toml"},
+		{name: "default", defaults: []string{"config.yml"}, want: "config.yml"},
+		{name: "first default", defaults: []string{"config.yaml", "config.toml"}, want: "config.toml"},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(wd)
+			for _, name := range tt.defaults {
+				if err := os.WriteFile(name, nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("CONFIG_FILE", tt.env)
+
+			if got := configPath(tt.args); got != tt.want {
+				t.Errorf("configPath(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
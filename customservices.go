@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CustomServiceConfig is one entry of the custom services file, e.g.
+//
+//	[{"name": "Tumblr", "domains": ["tumblr.com"], "pattern": "tumblr\\.com/([A-Za-z0-9_-]+)/[0-9]+",
+//	  "replacement": "tpmblr.com", "display": "Tumblr • {user}"}]
+//
+// The first capture group in pattern (if any) is used as {user}.
+type CustomServiceConfig struct {
+	Name        string   `json:"name"`
+	Fixer       string   `json:"fixer"`
+	Domains     []string `json:"domains"`
+	Pattern     string   `json:"pattern"`
+	Replacement string   `json:"replacement"`
+	Fallbacks   []string `json:"fallbacks"`
+	Display     string   `json:"display"`
+}
+
+// loadCustomServices reads service definitions from a JSON file and merges them into the
+// registry. A definition with the same name as a built-in service replaces it.
+func loadCustomServices(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var configs []CustomServiceConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	for _, cfg := range configs {
+		svc, err := cfg.service()
+		if err != nil {
+			return 0, fmt.Errorf("%s: service %q: %w", path, cfg.Name, err)
+		}
+		replaced := false
+		for idx, existing := range services {
+			if existing.Name == svc.Name {
+				services[idx] = svc
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			services = append(services, svc)
+		}
+	}
+	return len(configs), nil
+}
+
+func (cfg CustomServiceConfig) service() (*Service, error) {
+	if cfg.Name == "" || len(cfg.Domains) == 0 || cfg.Replacement == "" {
+		return nil, fmt.Errorf("name, domains and replacement are required")
+	}
+	domains := make([]string, 0, len(cfg.Domains))
+	hosts := make(map[string]string, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+		domains = append(domains, d)
+		hosts[d] = cfg.Replacement
+	}
+
+	pattern := cfg.Pattern
+	if pattern == "" {
+		pattern = `{domains}/[^\s>]+`
+	}
+	// compile with the domains filled in so mistakes surface at startup, not per message
+	quoted := make([]string, 0, len(domains))
+	for _, d := range domains {
+		quoted = append(quoted, regexp.QuoteMeta(d))
+	}
+	re, err := regexp.Compile(strings.ReplaceAll(pattern, "{domains}", "(?:"+strings.Join(quoted, "|")+")"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	svc := &Service{
+		Name:      cfg.Name,
+		Fixer:     cfg.Fixer,
+		Display:   cfg.Display,
+		Domains:   domains,
+		Patterns:  []string{pattern},
+		Rewrite:   rewriteHost(hosts),
+		Fallbacks: cfg.Fallbacks,
+	}
+	if re.NumSubexp() > 0 {
+		svc.Canonicalize = captureUser(re)
+	}
+	return svc, nil
+}
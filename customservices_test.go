@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCustomServices(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "services.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCustomServices(t *testing.T) {
+	builtin := services
+	t.Cleanup(func() { services = builtin })
+	services = append([]*Service{}, builtin...)
+
+	path := writeCustomServices(t, `[
+		{"name": "Tumblr", "domains": ["www.Tumblr.com"], "pattern": "{domains}/([A-Za-z0-9_-]+)/[0-9]+",
+		 "replacement": "tpmblr.com", "display": "{service} post by {user}"},
+		{"name": "Pixiv", "domains": ["pixiv.net"], "replacement": "ppxiv.net"}
+	]`)
+	n, err := loadCustomServices(path)
+	if err != nil || n != 2 {
+		t.Fatalf("loadCustomServices = %d, %v", n, err)
+	}
+	if len(services) != len(builtin)+1 {
+		t.Errorf("got %d services, want Pixiv replaced and Tumblr added to %d", len(services), len(builtin))
+	}
+
+	fixed, ok := serviceForDomain("tumblr.com", defaultGuildSettings()).fix("tumblr.com/someone/123", "tumblr.com", defaultGuildSettings())
+	if !ok || fixed.Fixed != "tpmblr.com/someone/123" || fixed.DisplayText != "Tumblr post by someone" {
+		t.Errorf("Tumblr fix = %+v", fixed)
+	}
+	fixed, ok = findService("Pixiv").fix("pixiv.net/en/artworks/123", "pixiv.net", defaultGuildSettings())
+	if !ok || fixed.Fixed != "ppxiv.net/en/artworks/123" {
+		t.Errorf("Pixiv fix = %+v", fixed)
+	}
+}
+
+func TestLoadCustomServicesErrors(t *testing.T) {
+	builtin := services
+	t.Cleanup(func() { services = builtin })
+
+	for content, want := range map[string]string{
+		`[{"name": "Tumblr", "domains": ["tumblr.com"]}]`:                                     "required",
+		`[{"name": "Tumblr", "domains": ["tumblr.com"], "replacement": "t", "pattern": "("}]`: "invalid pattern",
+		`{"name": "Tumblr"}`: "parsing",
+	} {
+		if _, err := loadCustomServices(writeCustomServices(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loading %s: error %v, want one mentioning %q", content, err, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const DIGEST_INTERVAL = 7 * 24 * time.Hour
+
+// How often the scheduler checks whether a digest is due
+const DIGEST_CHECK_INTERVAL = 1 * time.Hour
+
+func (st *sqliteStore) UpdateDigestChannel(guildID int64, channelID int64) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		// the first digest goes out a full week after it's enabled
+		_, err := st.db.Exec(`INSERT INTO guild_settings (guild_id, digest_channel_id, last_digest_at) VALUES (?, ?, ?)
+			ON CONFLICT(guild_id) DO UPDATE SET digest_channel_id = excluded.digest_channel_id, last_digest_at = excluded.last_digest_at`,
+			guildID, channelID, time.Now().Unix())
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func (st *sqliteStore) DigestChannel(guildID int64) (int64, error) {
+	var channelID sql.NullInt64
+	err := st.db.QueryRow("SELECT digest_channel_id FROM guild_settings WHERE guild_id = ?", guildID).Scan(&channelID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return channelID.Int64, err
+}
+
+// digestChannel is a guild that gets the weekly digest.
+type digestChannel struct {
+	GuildID      int64
+	ChannelID    int64
+	LastDigestAt time.Time
+}
+
+func (st *sqliteStore) DigestChannels() ([]digestChannel, error) {
+	rows, err := st.db.Query("SELECT guild_id, digest_channel_id, last_digest_at FROM guild_settings WHERE digest_channel_id IS NOT NULL AND digest_channel_id != 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var channels []digestChannel
+	for rows.Next() {
+		var d digestChannel
+		var last sql.NullInt64
+		if err := rows.Scan(&d.GuildID, &d.ChannelID, &last); err != nil {
+			continue
+		}
+		d.LastDigestAt = time.Unix(last.Int64, 0)
+		channels = append(channels, d)
+	}
+	return channels, nil
+}
+
+func (st *sqliteStore) MarkDigestPosted(guildID int64, at time.Time) error {
+	_, err := st.db.Exec("UPDATE guild_settings SET last_digest_at = ? WHERE guild_id = ?", at.Unix(), guildID)
+	return err
+}
+
+func handleDigestCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channelID := i.ChannelID
+	enabled := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "channel":
+			channelID = opt.Value.(string)
+		}
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	var cidInt int64
+	if enabled {
+		cidInt, _ = discordIDStringToInt64(channelID)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := st.UpdateDigestChannel(gidInt, cidInt); err != nil {
+		logf(LOG_WARN, "Error updating digest channel for guild %s: %v", i.GuildID, err)
+		embed.Description = T(interactionLocale(i), "digest.failed")
+		embed.Color = 0xff0000
+	} else if enabled {
+		embed.Description = T(interactionLocale(i), "digest.enabled", "<#"+channelID+">")
+	} else {
+		embed.Description = T(interactionLocale(i), "digest.disabled")
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// topCounts formats counts as a ranked list, logging the error that produced them, if any.
+func topCounts(counts []keyCount, err error, format func(key string, count int) string) string {
+	if err != nil {
+		logf(LOG_WARN, "Error counting fixed links: %v", err)
+		return ""
+	}
+	lines := make([]string, 0, len(counts))
+	for _, c := range counts {
+		lines = append(lines, format(c.Key, c.Count))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (st *sqliteStore) BotEventCounts(guildID int64, since time.Time, limit int) ([]keyCount, error) {
+	return st.keyCounts(`SELECT CASE kind WHEN 'permission' THEN '🔒 ' ELSE '⚠️ ' END || detail || ' in <#' || channel_id || '>', COUNT(*) AS n
+		FROM bot_events WHERE guild_id = ? AND created_at >= ? GROUP BY kind, detail, channel_id ORDER BY n DESC LIMIT ?`, guildID, since.Unix(), limit)
+}
+
+func buildDigestEmbed(st Store, s *discordgo.Session, guildID int64, since time.Time) *discordgo.MessageEmbed {
+	from := since.Unix()
+	filter := linkFilter{GuildID: guildID, Since: since}
+	services, err := st.LinkCounts(filter, "service", 0)
+	byService := topCounts(services, err, func(k string, n int) string { return fmt.Sprintf("%s: %d", k, n) })
+	channels, err := st.LinkCounts(filter, "channel", 5)
+	byChannel := topCounts(channels, err, func(k string, n int) string { return fmt.Sprintf("<#%s>: %d", k, n) })
+	users, err := st.LinkCounts(filter, "user", 5)
+	byUser := topCounts(users, err, func(k string, n int) string { return fmt.Sprintf("<@%s>: %d", k, n) })
+	events, err := st.BotEventCounts(guildID, since, 5)
+	problems := topCounts(events, err, func(k string, n int) string { return fmt.Sprintf("%s (×%d)", k, n) })
+
+	// posted to a channel rather than answering anyone, so in the server's language
+	locale := guildLocale(s, strconv.FormatInt(guildID, 10), getGuildSettings(guildID))
+	embed := &discordgo.MessageEmbed{
+		Title:       T(locale, "digest.title"),
+		Description: T(locale, "digest.since", fmt.Sprintf("<t:%d:D>", from)),
+		Color:       accentColor(strconv.FormatInt(guildID, 10), 0x5865F2),
+	}
+	if byService == "" {
+		embed.Description += "\n" + T(locale, "digest.nothing")
+	} else {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: T(locale, "digest.links"), Value: byService, Inline: true},
+			&discordgo.MessageEmbedField{Name: T(locale, "digest.channels"), Value: byChannel, Inline: true},
+			&discordgo.MessageEmbedField{Name: T(locale, "digest.posters"), Value: byUser, Inline: true},
+		)
+	}
+	if problems != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: T(locale, "digest.problems"), Value: problems})
+	}
+	createFooter(embed, s)
+	return embed
+}
+
+// postDueDigests sends the weekly digest to every guild whose last one is at least a week old.
+func postDueDigests(st Store, s *discordgo.Session) error {
+	channels, err := st.DigestChannels()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, d := range channels {
+		since := d.LastDigestAt
+		if now.Sub(since) < DIGEST_INTERVAL {
+			continue
+		}
+		if now.Sub(since) > 2*DIGEST_INTERVAL {
+			// don't report on a backlog of weeks if the bot was offline
+			since = now.Add(-DIGEST_INTERVAL)
+		}
+		embed := buildDigestEmbed(st, s, d.GuildID, since)
+		if _, err := s.ChannelMessageSendEmbed(fmt.Sprint(d.ChannelID), embed); err != nil {
+			logf(LOG_WARN, "Warning: failed to post digest for guild %d: %v", d.GuildID, err)
+		}
+		_ = st.MarkDigestPosted(d.GuildID, now)
+	}
+	return nil
+}
+
+func startDigestScheduler(st Store, s *discordgo.Session, stop <-chan struct{}) {
+	ticker := time.NewTicker(DIGEST_CHECK_INTERVAL)
+	for {
+		select {
+		case <-ticker.C:
+			if err := postDueDigests(st, s); err != nil {
+				logf(LOG_WARN, "Error posting weekly digests: %v", err)
+			}
+		case <-stop:
+			ticker.Stop()
+			return
+		}
+	}
+}
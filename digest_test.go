@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPostDueDigests(t *testing.T) {
+	db := newTestDB(t)
+	for guild, channel := range map[int64]int64{1: 50, 2: 60, 3: 0} {
+		if err := db.UpdateDigestChannel(guild, channel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// only guild 1 has gone a week without a digest
+	weekAgo := time.Now().Add(-DIGEST_INTERVAL - time.Hour).Unix()
+	if _, err := db.db.Exec("UPDATE guild_settings SET last_digest_at = ? WHERE guild_id IN (1, 3)", weekAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	posted := &discordgo.Message{GuildID: "1", ChannelID: "20", Author: &discordgo.User{ID: "5"}}
+	for _, service := range []string{"Twitter", "Twitter", "Pixiv"} {
+		if err := recordLinkFix(db, posted, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forbidden := &discordgo.RESTError{
+		Response: &http.Response{StatusCode: http.StatusForbidden},
+		Message:  &discordgo.APIErrorMessage{Code: 50013, Message: "Missing Permissions"},
+	}
+	recordDeliveryError(db, posted, "delete original", forbidden)
+
+	s, fake := newTestSession(t, nil)
+	if err := postDueDigests(db, s); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls, want := fake.calls(), []string{"POST /channels/50/messages"}; !slices.Equal(calls, want) {
+		t.Fatalf("posted %v, want %v", calls, want)
+	}
+	body := fake.body("POST /channels/50/messages")
+	for _, want := range []string{"Weekly Digest", "Twitter: 2", "Pixiv: 1", "delete original: Missing Permissions", "🔒"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest %s does not mention %q", body, want)
+		}
+	}
+
+	var last int64
+	if err := db.db.QueryRow("SELECT last_digest_at FROM guild_settings WHERE guild_id = 1").Scan(&last); err != nil {
+		t.Fatal(err)
+	}
+	if last <= weekAgo {
+		t.Errorf("last_digest_at = %d after posting, want it moved on from %d", last, weekAgo)
+	}
+	// posting again right away finds nothing due
+	if err := postDueDigests(db, s); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.calls(); len(calls) != 1 {
+		t.Errorf("second run posted again: %v", calls)
+	}
+
+	// the digest is written in the server's language
+	if err := db.UpdateGuildColumn(1, "locale", string(discordgo.SpanishES)); err != nil {
+		t.Fatal(err)
+	}
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	if embed := buildDigestEmbed(db, s, 1, time.Now().Add(-DIGEST_INTERVAL)); embed.Title != "Resumen semanal" || embed.Fields[0].Name != "Enlaces arreglados" {
+		t.Errorf("Spanish digest = %q / %q", embed.Title, embed.Fields[0].Name)
+	}
+
+	// and in the server's accent color
+	gs := *getGuildSettings(1)
+	gs.EmbedColor = 0x123456
+	botSettings.Lock()
+	botSettings.m[1] = &gs
+	botSettings.Unlock()
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+	if embed := buildDigestEmbed(db, s, 1, time.Now().Add(-DIGEST_INTERVAL)); embed.Color != 0x123456 {
+		t.Errorf("digest color = %#x, want the server's 0x123456", embed.Color)
+	}
+}
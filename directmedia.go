@@ -0,0 +1,23 @@
+package main
+
+import "strings"
+
+// Fixer hosts that serve only the raw media when prefixed with "d." (d.fxtwitter.com etc.)
+var directMediaHosts = map[string]bool{
+	"fxtwitter.com":   true,
+	"fixupx.com":      true,
+	"vxtwitter.com":   true,
+	"fixvx.com":       true,
+	"fxbsky.app":      true,
+	"ddinstagram.com": true,
+}
+
+// directMediaLink moves a fixed link onto its fixer's direct-media host. Links on fixers
+// without one are returned unchanged.
+func directMediaLink(fixed string) string {
+	host, rest, _ := strings.Cut(fixed, "/")
+	if !directMediaHosts[strings.ToLower(host)] {
+		return fixed
+	}
+	return "d." + host + "/" + rest
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestDirectMediaLink(t *testing.T) {
+	tests := map[string]string{
+		"fxtwitter.com/someone/status/123": "d.fxtwitter.com/someone/status/123",
+		"FixupX.com/someone/status/123":    "d.FixupX.com/someone/status/123",
+		"phixiv.net/en/artworks/123":       "phixiv.net/en/artworks/123",
+	}
+	for fixed, want := range tests {
+		if got := directMediaLink(fixed); got != want {
+			t.Errorf("directMediaLink(%q) = %q, want %q", fixed, got, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// deliverByDM sends a fix to the message's author when FixEmbed can't post in the channel.
+// The original is left alone, since nobody else in the channel will see the fix.
+func deliverByDM(st Store, s *discordgo.Session, m *discordgo.Message, sends []*discordgo.MessageSend, locale discordgo.Locale) bool {
+	ch, err := s.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not open a DM with %s: %v", m.Author.ID, err)
+		return false
+	}
+	if _, err := rateLimitedSend(s, ch.ID, T(locale, "dm.fallback", "<#"+m.ChannelID+">")); err != nil {
+		// DMs closed; there's nowhere left to deliver the fix
+		logf(LOG_WARN, "Warning: could not DM %s: %v", m.Author.ID, err)
+		return false
+	}
+	for _, send := range sends {
+		sent, err := rateLimitedSendComplex(s, ch.ID, send)
+		if err != nil {
+			logf(LOG_WARN, "Warning: could not DM %s: %v", m.Author.ID, err)
+			return false
+		}
+		_ = recordFixMessage(st, sent, m, "")
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDMFallback(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/users/@me/channels") {
+			return http.StatusOK, `{"id": "60", "type": 1}`
+		}
+		return postedReposts(r)
+	})
+	settings := defaultGuildSettings()
+	postMessage(t, db, s, settings, "") // sets up guild 1
+
+	// channel 21 can be read but not posted in
+	s.State.GuildAdd(&discordgo.Guild{ID: "1", OwnerID: "9", Roles: []*discordgo.Role{{ID: "1", Permissions: discordgo.PermissionViewChannel}}})
+	s.State.ChannelAdd(&discordgo.Channel{ID: "21", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	s.State.MemberAdd(&discordgo.Member{GuildID: "1", User: s.State.User})
+	post := func() {
+		onMessageCreate(db, s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID: "71", ChannelID: "21", GuildID: "1", Content: "https://x.com/a/status/1",
+			Author: &discordgo.User{ID: "5", Username: "someone"},
+		}})
+	}
+
+	post()
+	if calls := fake.calls(); !slices.Contains(calls, "POST /channels/60/messages") || slices.Contains(calls, "POST /channels/21/messages") {
+		t.Errorf("calls %v, want the fix in a DM only", calls)
+	}
+	if slices.Contains(fake.calls(), "DELETE /channels/21/messages/71") {
+		t.Error("deleted the original of a fix only its author can see")
+	}
+
+	settings.DMFallback = false
+	before := len(fake.calls())
+	post()
+	if calls := fake.calls()[before:]; slices.Contains(calls, "POST /users/@me/channels") {
+		t.Errorf("calls %v DM the author with the fallback off", calls)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a fixer host's health check result is trusted
+const FIXER_HEALTH_TTL = 5 * time.Minute
+
+// fixers may redirect browsers to the original site, so checks present as Discord's crawler
+const fixerCheckUserAgent = "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)"
+
+var fixerCheckClient = &http.Client{
+	Timeout: REDIRECT_TIMEOUT,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+type fixerHealth struct {
+	up      bool
+	checked time.Time
+}
+
+var fixerHealthCache = struct {
+	sync.Mutex
+	m map[string]fixerHealth
+}{m: make(map[string]fixerHealth)}
+
+// fixerUp reports whether the fixer serving link answers without a server error.
+// Results are cached per host so only the first link in a while pays for the check.
+func fixerUp(host, link string) bool {
+	fixerHealthCache.Lock()
+	h, ok := fixerHealthCache.m[host]
+	fixerHealthCache.Unlock()
+	if ok && time.Since(h.checked) < FIXER_HEALTH_TTL {
+		return h.up
+	}
+
+	up := false
+	req, err := http.NewRequest(http.MethodHead, "https://"+link, nil)
+	if err == nil {
+		req.Header.Set("User-Agent", fixerCheckUserAgent)
+		if resp, err := fixerCheckClient.Do(req); err == nil {
+			resp.Body.Close()
+			up = resp.StatusCode < 500
+		} else {
+			logf(LOG_DEBUG, "fixerUp: %s: %v", host, err)
+		}
+	}
+
+	fixerHealthCache.Lock()
+	fixerHealthCache.m[host] = fixerHealth{up: up, checked: time.Now()}
+	fixerHealthCache.Unlock()
+	return up
+}
+
+// withFallback moves a rewritten link onto the first of the service's fallback hosts that is up
+// when its fixer is down. If every fixer is down the link is kept as-is.
+func (svc *Service) withFallback(fixed, original string) (string, string, bool) {
+	runtimeConfig.RLock()
+	fallbacks := svc.Fallbacks
+	runtimeConfig.RUnlock()
+	if len(fallbacks) == 0 || fixed == original {
+		return fixed, "", false
+	}
+	host, rest, _ := strings.Cut(fixed, "/")
+	if fixerUp(host, fixed) {
+		return fixed, "", false
+	}
+	for _, fallback := range fallbacks {
+		if strings.EqualFold(fallback, host) {
+			continue
+		}
+		candidate := fallback + "/" + rest
+		if fixerUp(fallback, candidate) {
+			logf(LOG_WARN, "Fixer %s is down, falling back to %s", host, fallback)
+			return candidate, fallback, true
+		}
+	}
+	logf(LOG_WARN, "Fixer %s and all %s fallbacks are down", host, svc.Name)
+	return fixed, "", false
+}
+
+// parseFallbacks reads per-service fallback hosts, e.g. "Twitter=vxtwitter.com,fixvx.com;Instagram=ddinstagram.com".
+func parseFallbacks(value string) map[string][]string {
+	fallbacks := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		name, hosts, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		fallbacks[strings.TrimSpace(name)] = parseDomainList(hosts)
+	}
+	return fallbacks
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFallbacks(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string][]string
+	}{
+		{value: "", want: map[string][]string{}},
+		{value: "Twitter=vxtwitter.com", want: map[string][]string{"Twitter": {"vxtwitter.com"}}},
+		{
+			value: " Twitter = vxtwitter.com, FIXVX.com ;Instagram=ddinstagram.com",
+			want:  map[string][]string{"Twitter": {"vxtwitter.com", "fixvx.com"}, "Instagram": {"ddinstagram.com"}},
+		},
+		{value: "garbage;Reddit=", want: map[string][]string{"Reddit": nil}},
+	}
+	for _, tt := range tests {
+		if got := parseFallbacks(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFallbacks(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWithFallback(t *testing.T) {
+	svc := &Service{Name: "Twitter", Fallbacks: []string{"fxtwitter.com", "down.example", "vxtwitter.com"}}
+	setHealth := func(host string, up bool) {
+		fixerHealthCache.Lock()
+		fixerHealthCache.m[host] = fixerHealth{up: up, checked: time.Now()}
+		fixerHealthCache.Unlock()
+	}
+
+	setHealth("fxtwitter.com", true)
+	if got, fallback, ok := svc.withFallback("fxtwitter.com/someone/status/1", "twitter.com/someone/status/1"); ok || got != "fxtwitter.com/someone/status/1" || fallback != "" {
+		t.Errorf("with the fixer up: %q, %q, %t", got, fallback, ok)
+	}
+
+	setHealth("fxtwitter.com", false)
+	setHealth("down.example", false)
+	setHealth("vxtwitter.com", true)
+	if got, fallback, ok := svc.withFallback("fxtwitter.com/someone/status/1", "twitter.com/someone/status/1"); !ok || got != "vxtwitter.com/someone/status/1" || fallback != "vxtwitter.com" {
+		t.Errorf("with the fixer down: %q, %q, %t", got, fallback, ok)
+	}
+
+	setHealth("vxtwitter.com", false)
+	if got, _, ok := svc.withFallback("fxtwitter.com/someone/status/1", "twitter.com/someone/status/1"); ok || got != "fxtwitter.com/someone/status/1" {
+		t.Errorf("with every fixer down: %q, %t", got, ok)
+	}
+}
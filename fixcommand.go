@@ -0,0 +1,154 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// handleFixCommand fixes a link on demand. It ignores channel activation, so it works anywhere, DMs included.
+func handleFixCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	url := ""
+	private := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "url":
+			url = opt.StringValue()
+		case "private":
+			private = opt.BoolValue()
+		}
+	}
+
+	settings := defaultGuildSettings()
+	if i.GuildID != "" {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		settings = getGuildSettings(gidInt)
+	}
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	// the fix stands in for a message the user would have posted; the interaction ID keeps it apart from real ones
+	msg := &discordgo.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    user,
+		Content:   url,
+	}
+	sends, _ := buildFix(s, msg, settings)
+
+	respondWithFix(st, s, i, msg, sends, private)
+}
+
+// respondWithFix posts a fix as the interaction's response (and follow-ups, for long ones).
+func respondWithFix(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, msg *discordgo.Message, sends []*discordgo.MessageSend, private bool) {
+	var flags discordgo.MessageFlags
+	if private {
+		flags = 1 << 6 // ephemeral
+	}
+	if len(sends) == 0 {
+		respondNoFix(s, i)
+		return
+	}
+
+	first := sends[0]
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         first.Content,
+			Embeds:          first.Embeds,
+			Components:      first.Components,
+			Files:           first.Files,
+			AllowedMentions: first.AllowedMentions,
+			Flags:           flags,
+		},
+	})
+	if err != nil {
+		logf(LOG_WARN, "Error responding to /%s: %v", i.ApplicationCommandData().Name, err)
+		return
+	}
+	if !private && i.GuildID != "" {
+		if sent, err := s.InteractionResponse(i.Interaction); err == nil {
+			_ = recordFixMessage(st, sent, msg, "")
+		}
+	}
+	for _, send := range sends[1:] {
+		sent, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         send.Content,
+			Embeds:          send.Embeds,
+			Components:      send.Components,
+			Files:           send.Files,
+			AllowedMentions: send.AllowedMentions,
+			Flags:           flags,
+		})
+		if err != nil {
+			logf(LOG_WARN, "Error sending /%s follow-up: %v", i.ApplicationCommandData().Name, err)
+			continue
+		}
+		if !private && i.GuildID != "" {
+			_ = recordFixMessage(st, sent, msg, "")
+		}
+	}
+}
+
+func respondNoFix(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: T(interactionLocale(i), "error.no_fix"),
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+}
+
+// handleFixLinksCommand serves the "Fix Links" message command: the targeted message's links are fixed
+// in a reply. Where FixEmbed can't post (DMs, servers it was not added to) the fix is the command's response.
+func handleFixLinksCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	target := data.Resolved.Messages[data.TargetID]
+	if target == nil {
+		respondNoFix(s, i)
+		return
+	}
+	target.GuildID = i.GuildID
+	if target.ChannelID == "" {
+		target.ChannelID = i.ChannelID
+	}
+
+	settings := defaultGuildSettings()
+	if i.GuildID != "" {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		settings = getGuildSettings(gidInt)
+	}
+	sends, links := buildFix(s, target, settings)
+	if len(sends) == 0 {
+		respondNoFix(s, i)
+		return
+	}
+	if _, err := s.State.Guild(i.GuildID); i.GuildID == "" || err != nil {
+		respondWithFix(st, s, i, target, sends, false)
+		return
+	}
+
+	for _, send := range sends {
+		send.Reference = target.Reference()
+		sent, err := rateLimitedSendComplex(s, target.ChannelID, send)
+		if err != nil {
+			recordDeliveryError(st, target, "send", err)
+			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: T(interactionLocale(i), "error.post_failed"),
+					Flags:   1 << 6, // ephemeral
+				},
+			})
+			return
+		}
+		_ = recordFixMessage(st, sent, target, repostSignature(links))
+		rememberRepost(target, sent, repostSignature(links))
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: T(interactionLocale(i), "fix.done"),
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+}
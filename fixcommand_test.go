@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFixCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	fixerHealthCache.Lock()
+	fixerHealthCache.m["fixupx.com"] = fixerHealth{up: true, checked: time.Now()}
+	fixerHealthCache.Unlock()
+
+	// in a DM there is no guild or member, only the user
+	fix := func(url string) string {
+		i := slashCommand("fix", option("url", url), option("private", true))
+		i.GuildID = ""
+		i.User, i.Member = i.Member.User, nil
+		handleFixCommand(db, s, i)
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	body := fix("https://x.com/a/status/1")
+	if !strings.Contains(body, "fixupx.com/a/status/1") || !strings.Contains(body, `"flags":64`) {
+		t.Errorf("private fix = %s", body)
+	}
+	if body := fix("https://example.com/"); !strings.Contains(body, "no link FixEmbed can fix") {
+		t.Errorf("unfixable link = %s", body)
+	}
+}
+
+func TestFixLinksCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, postedReposts)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	fixerHealthCache.Lock()
+	fixerHealthCache.m["fixupx.com"] = fixerHealth{up: true, checked: time.Now()}
+	fixerHealthCache.Unlock()
+
+	i := slashCommand("Fix Links")
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name:     "Fix Links",
+		TargetID: "70",
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{Messages: map[string]*discordgo.Message{
+			"70": {ID: "70", ChannelID: "20", Content: "look https://x.com/a/status/1", Author: &discordgo.User{ID: "6", Username: "poster"}},
+		}},
+	}
+	handleFixLinksCommand(db, s, i)
+
+	reply := fake.body("POST /channels/20/messages")
+	if !strings.Contains(reply, "fixupx.com/a/status/1") || !strings.Contains(reply, `"message_id":"70"`) {
+		t.Errorf("reply = %s", reply)
+	}
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Fixed.") {
+		t.Errorf("response = %s", body)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func handleLogChannelCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channelID := i.ChannelID
+	enabled := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "channel":
+			channelID = opt.Value.(string)
+		}
+	}
+	if !enabled {
+		channelID = ""
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	cidInt, _ := discordIDStringToInt64(channelID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := st.UpdateGuildColumn(gidInt, "log_channel_id", cidInt); err != nil {
+		logf(LOG_WARN, "Error updating log channel for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the log channel."
+		embed.Color = 0xff0000
+	} else {
+		updated := *getGuildSettings(gidInt)
+		updated.LogChannel = channelID
+		cacheGuildSettings(gidInt, &updated)
+		if enabled {
+			embed.Description = fmt.Sprintf("📝 Fixed links will be logged in <#%s>.", channelID)
+		} else {
+			embed.Description = "📝 Fixed links are no longer logged."
+			embed.Color = 0xff0000
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// postFixLog posts a compact record of a fix to the guild's log channel, if it has one.
+func postFixLog(s *discordgo.Session, m *discordgo.Message, settings *GuildSettings, links []*repostLink, action string) {
+	if settings.LogChannel == "" {
+		return
+	}
+	lines := make([]string, 0, len(links))
+	for _, link := range links {
+		lines = append(lines, fmt.Sprintf("<%s> → <https://%s>", link.Match, link.Fixed))
+	}
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    m.Author.Username,
+			IconURL: m.Author.AvatarURL(""),
+		},
+		Description: strings.Join(lines, "\n"),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Author", Value: fmt.Sprintf("<@%s>", m.Author.ID), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+			{Name: "Action", Value: action, Inline: true},
+		},
+		Color: accentColor(m.GuildID, 0x78b159),
+	}
+	if _, err := rateLimitedSendComplex(s, settings.LogChannel, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}); err != nil {
+		logf(LOG_WARN, "Warning: failed to log fix in channel %s: %v", settings.LogChannel, err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLogChannelCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	channel := option("channel", "60")
+	channel.Type = discordgo.ApplicationCommandOptionChannel
+	handleLogChannelCommand(db, s, slashCommand("logchannel", option("enabled", true), channel))
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	if got := getGuildSettings(1).LogChannel; got != "60" {
+		t.Errorf("LogChannel = %q, want 60", got)
+	}
+
+	handleLogChannelCommand(db, s, slashCommand("logchannel", option("enabled", false), channel))
+	if got := getGuildSettings(1).LogChannel; got != "" {
+		t.Errorf("LogChannel = %q after turning logging off", got)
+	}
+}
+
+func TestPostFixLog(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	settings.LogChannel = "60"
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+
+	if !slices.Contains(fake.calls(), "POST /channels/60/messages") {
+		t.Fatalf("nothing was logged: %v", fake.calls())
+	}
+	body := fake.body("POST /channels/60/messages")
+	for _, want := range []string{"https://x.com/a/status/1", "https://fixupx.com/a/status/1", "Reposted, original deleted"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("log entry %s is missing %q", body, want)
+		}
+	}
+}
+
+func TestSimulateMode(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	settings.Simulate = true
+	settings.LogChannel = "60"
+	settings.RichEmbeds = true
+	// a trial run has nothing to fetch the post for
+	transport := redirectClient.Transport
+	t.Cleanup(func() { redirectClient.Transport = transport })
+	redirectClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("simulate mode fetched %s", r.URL)
+		return nil, errors.New("unreachable")
+	})
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+
+	for _, call := range fake.calls() {
+		if strings.Contains(call, "/channels/20/") {
+			t.Errorf("simulate mode touched the channel: %s", call)
+		}
+	}
+	if body := fake.body("POST /channels/60/messages"); !strings.Contains(body, "Would repost and delete the original") {
+		t.Errorf("log entry = %s", body)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// parseFrontends reads the stored frontend choices, a list of "Service=Frontend" entries.
+func parseFrontends(stored string) map[string]string {
+	choices := make(map[string]string)
+	for _, entry := range parseStoredList(stored) {
+		if service, frontend, ok := strings.Cut(entry, "="); ok {
+			choices[service] = frontend
+		}
+	}
+	return choices
+}
+
+// formatFrontends is the inverse of parseFrontends, in registry order so the stored value is stable.
+func formatFrontends(choices map[string]string) string {
+	entries := make([]string, 0, len(choices))
+	for _, svc := range services {
+		if name, ok := choices[svc.Name]; ok {
+			entries = append(entries, svc.Name+"="+name)
+		}
+	}
+	return formatStoredList(entries)
+}
+
+// describeFrontends summarizes the fixer used for each service that offers a choice.
+func describeFrontends(settings *GuildSettings) string {
+	var lines []string
+	for _, svc := range services {
+		if len(svc.Frontends) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", svc.Name, svc.frontend(settings).Name))
+		}
+	}
+	if len(lines) == 0 {
+		return "Default"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// frontendSelectMenu lists every selectable frontend as "Service: Frontend". Nothing is marked as
+// the default: it's a single choice across services, and describeFrontends shows the current ones.
+func frontendSelectMenu() *discordgo.SelectMenu {
+	var opts []discordgo.SelectMenuOption
+	for _, svc := range services {
+		if len(svc.Frontends) == 0 {
+			continue
+		}
+		names := []string{svc.Fixer}
+		for _, fe := range svc.Frontends {
+			names = append(names, fe.Name)
+		}
+		for _, name := range names {
+			opts = append(opts, discordgo.SelectMenuOption{
+				Label: fmt.Sprintf("%s: %s", svc.Name, name),
+				Value: svc.Name + "=" + name,
+			})
+		}
+	}
+	minVal := new(int)
+	*minVal = 1
+	return &discordgo.SelectMenu{
+		CustomID:    "frontend_select",
+		Placeholder: "Select a fixer frontend...",
+		MinValues:   minVal,
+		MaxValues:   1,
+		Options:     opts,
+	}
+}
+
+// handleFrontendSelect stores the picked frontend for its service and redraws the menu.
+func handleFrontendSelect(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, guildID int64, values []string) {
+	updated := *defaultGuildSettings()
+	if guildID != 0 {
+		updated = *getGuildSettings(guildID)
+	}
+	if len(values) > 0 {
+		service, name, _ := strings.Cut(values[0], "=")
+		// copy so readers of the cached settings never see a half-updated map
+		choices := make(map[string]string, len(updated.Frontends)+1)
+		for k, v := range updated.Frontends {
+			choices[k] = v
+		}
+		if svc := findService(service); svc != nil && name == svc.Fixer {
+			delete(choices, service)
+		} else {
+			choices[service] = name
+		}
+		updated.Frontends = choices
+
+		if guildID != 0 {
+			if err := st.UpdateGuildColumn(guildID, "frontends", formatFrontends(choices)); err != nil {
+				logf(LOG_WARN, "Error saving frontends for guild %d: %v", guildID, err)
+			}
+			cacheGuildSettings(guildID, &updated)
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{Title: "Fixer Frontends", Description: "Saved fixer frontends.\n\n" + describeFrontends(&updated), Color: accentColor(i.GuildID, 0x5865F2)}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{&discordgo.ActionsRow{Components: []discordgo.MessageComponent{frontendSelectMenu()}}},
+		},
+	})
+}
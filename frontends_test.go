@@ -0,0 +1,50 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestStoredFrontends(t *testing.T) {
+	choices := map[string]string{"Reddit": "rxddit", "Twitter": "vxTwitter"}
+	stored := formatFrontends(choices)
+	if stored != formatStoredList([]string{"Twitter=vxTwitter", "Reddit=rxddit"}) {
+		t.Errorf("formatFrontends = %q, want registry order", stored)
+	}
+	if got := parseFrontends(stored); !maps.Equal(got, choices) {
+		t.Errorf("parseFrontends(%q) = %v", stored, got)
+	}
+	if got := parseFrontends(""); len(got) != 0 {
+		t.Errorf("parseFrontends(\"\") = %v", got)
+	}
+}
+
+func TestHandleFrontendSelect(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	pick := func(value string) {
+		handleFrontendSelect(db, s, componentClick("frontend_select"), 1, []string{value})
+	}
+
+	pick("Twitter=vxTwitter")
+	pick("Instagram=kkinstagram")
+	gs, _ := db.GuildSettings(1)
+	if !maps.Equal(gs.Frontends, map[string]string{"Twitter": "vxTwitter", "Instagram": "kkinstagram"}) {
+		t.Fatalf("stored frontends = %v", gs.Frontends)
+	}
+	// picking the default fixer drops the choice
+	pick("Twitter=FxTwitter")
+	gs, _ = db.GuildSettings(1)
+	if !maps.Equal(gs.Frontends, map[string]string{"Instagram": "kkinstagram"}) {
+		t.Errorf("stored frontends = %v, want only Instagram", gs.Frontends)
+	}
+	if got := getGuildSettings(1).Frontends; !maps.Equal(got, gs.Frontends) {
+		t.Errorf("cached frontends = %v", got)
+	}
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Instagram: kkinstagram") || !strings.Contains(body, "Twitter: FxTwitter") {
+		t.Errorf("panel = %s", body)
+	} else if strings.Contains(body, `"default":true`) {
+		t.Errorf("panel %s marks a default in a single-choice menu", body)
+	}
+}
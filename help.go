@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the application commands registered on Ready, for the help index
+var commandList []*discordgo.ApplicationCommand
+
+type helpTopic struct {
+	Value       string
+	Label       string
+	Description string
+	Emoji       string
+	Body        func() string
+}
+
+var helpTopics = []helpTopic{
+	{"commands", "Commands", "Every slash command", "📖", helpCommands},
+	{"services", "Services", "The sites FixEmbed fixes", "🌐", helpServices},
+	{"delivery", "Delivery", "How fixed links are posted", "📬", helpDelivery},
+	{"permissions", "Permissions", "What FixEmbed needs, and who can configure it", "🔐", helpPermissions},
+}
+
+func helpCommands() string {
+	lines := make([]string, 0, len(commandList))
+	for _, cmd := range commandList {
+		if cmd.Type != 0 && cmd.Type != discordgo.ChatApplicationCommand {
+			continue
+		}
+		line := fmt.Sprintf("`/%s` %s", cmd.Name, cmd.Description)
+		if cmd.DefaultMemberPermissions != nil {
+			line += " *(admin)*"
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func helpServices() string {
+	lines := make([]string, 0, len(services))
+	for _, svc := range services {
+		line := fmt.Sprintf("**%s**", svc.label())
+		if len(svc.Domains) > 0 {
+			line += ": " + strings.Join(svc.Domains, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n\nTurn services on or off with `/settings panel` → Service Settings."
+}
+
+func helpDelivery() string {
+	return strings.Join([]string{
+		"**Delete Original**: the message is deleted and reposted with fixed links. When off, the original stays and only its broken preview is hidden.",
+		"**Mention Users**: the repost mentions the poster; `/pingme` lets each user choose for themselves. `/attribution` can instead show the mention without a ping, or drop the \"Sent by\" line entirely.",
+		"**Link Buttons**: links go in buttons instead of the message text.",
+		"**Keep Message Text**: the whole message is reposted with the links swapped in place.",
+		"**Repost Template**: the repost's layout, using `{link}`, `{service}`, `{user}`, `{author_mention}` and `{sent_by}`.",
+		"**Direct Media**, **Rich Embeds** and **Re-upload Media** change what the repost shows.",
+		"**DM Fallback**: where FixEmbed can't post, the fixed link is sent to its poster by DM instead.",
+		"Start a message with the opt-out keyword (`/nofix`) to leave it alone.",
+	}, "\n")
+}
+
+func helpPermissions() string {
+	return strings.Join([]string{
+		"FixEmbed needs **Send Messages** and **Embed Links** in the channels it works in, plus **Manage Messages** to delete or un-embed the original.",
+		"Configuration commands and the settings panels need **Manage Server** or **Manage Channels**.",
+	}, "\n")
+}
+
+// helpPage builds the index (empty topic) or one topic's page, with the topic picker underneath.
+func helpPage(s *discordgo.Session, locale discordgo.Locale, topic string) *discordgo.InteractionResponseData {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Help",
+		Description: T(locale, "help.intro"),
+		Color:       0x7289DA,
+	}
+	options := make([]discordgo.SelectMenuOption, 0, len(helpTopics))
+	for _, t := range helpTopics {
+		if t.Value == topic {
+			embed.Title = "Help: " + t.Label
+			embed.Description = t.Body()
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       t.Label,
+			Value:       t.Value,
+			Description: t.Description,
+			Emoji:       &discordgo.ComponentEmoji{Name: t.Emoji},
+			Default:     t.Value == topic,
+		})
+	}
+	createFooter(embed, s)
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "help_topic", Placeholder: "Pick a topic", Options: options},
+			}},
+		},
+		Flags: 1 << 6, // ephemeral
+	}
+}
+
+func handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: helpPage(s, interactionLocale(i), ""),
+	})
+}
+
+func handleHelpTopic(s *discordgo.Session, i *discordgo.InteractionCreate, values []string) {
+	topic := ""
+	if len(values) > 0 {
+		topic = values[0]
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: helpPage(s, interactionLocale(i), topic),
+	})
+}
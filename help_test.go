@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestHelpTopic(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	manage := int64(discordgo.PermissionManageGuild)
+	commandList = []*discordgo.ApplicationCommand{
+		{Name: "status", Description: "List channels", DefaultMemberPermissions: &manage},
+		{Name: "about", Description: "About the bot"},
+		{Name: "Repost", Type: discordgo.MessageApplicationCommand},
+	}
+	t.Cleanup(func() { commandList = nil })
+
+	// anyone may browse help, not just members who can configure
+	click := componentClick("help_topic")
+	click.Member.Permissions = 0
+	click.Data = discordgo.MessageComponentInteractionData{CustomID: "help_topic", Values: []string{"commands"}}
+	onInteractionCreate(db, s, click)
+
+	body := fake.body("POST /interactions/900/token/callback")
+	if !strings.Contains(body, "Help: Commands") || !strings.Contains(body, "`/about` About the bot\\n`/status` List channels *(admin)*") {
+		t.Errorf("commands page = %s", body)
+	}
+	if strings.Contains(body, "Repost") {
+		t.Error("a context menu command was listed")
+	}
+}
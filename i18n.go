@@ -0,0 +1,693 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Language used when a message has no translation for the reader's locale
+const FALLBACK_LOCALE = discordgo.EnglishUS
+
+// catalog maps message keys to fmt format strings in one language. Keys starting with "cmd." translate
+// command and option descriptions, and "menu." keys the /settings menu's descriptions; their English
+// text lives in the registration payload and settingsEntries themselves.
+type catalog map[string]string
+
+var translations = map[discordgo.Locale]catalog{
+	discordgo.EnglishUS: {
+		"repost.sent_by":            "Sent by %s",
+		"repost.open_on":            "Open on %s",
+		"repost.delete":             "Delete",
+		"repost.delete_not_author":  "Only the person who posted the link (or a moderator) can delete this.",
+		"notice.permission_title":   "FixEmbed is missing a permission",
+		"notice.permission":         "FixEmbed keeps failing in %[2]s because it doesn't have the **%[1]s** permission there. Grant it, or use /deactivate in that channel.",
+		"dm.fallback":               "FixEmbed isn't allowed to post in %s, so here's your fixed link:",
+		"error.not_allowed":         "You need the Manage Server or Manage Channels permission to do that.",
+		"error.no_fix":              "❌ There's no link FixEmbed can fix here.",
+		"error.post_failed":         "❌ I couldn't post in this channel.",
+		"error.preference":          "❌ Could not update your preference, please try again.",
+		"fix.done":                  "✅ Fixed.",
+		"premium.required":          "💎 %s is a premium feature. Your other settings keep working as they are.",
+		"premium.webhooks":          "Processing webhook messages",
+		"premium.link_limit":        "Fixing more than %d links per message",
+		"premium.template":          "A custom repost template",
+		"optout.out":                "✅ FixEmbed will no longer fix your links, in any server.",
+		"optout.in":                 "✅ FixEmbed will fix your links again.",
+		"pingme.default":            "✅ Your reposts will follow each server's mention setting.",
+		"pingme.always":             "✅ Your reposts will always ping you.",
+		"pingme.never":              "✅ Your reposts will show your name without pinging you.",
+		"ping.pinging":              "Pinging…",
+		"help.intro":                "FixEmbed reposts social media links through embed fixers so they get proper previews. Pick a topic below.",
+		"activate.done":             "✅ Activated for %s!",
+		"activate.done_children":    "✅ Activated for %s and its %d channel(s)!",
+		"deactivate.done":           "❌ Deactivated for %s!",
+		"deactivate.done_children":  "❌ Deactivated for %s and its %d channel(s)!",
+		"about.title":               "About",
+		"about.description":         "This bot fixes the lack of embed support in Discord.",
+		"about.links":               "🎉 Quick Links",
+		"about.invite":              "Invite FixEmbed",
+		"about.source":              "Star our Source Code on GitHub",
+		"about.credits":             "📜 Credits",
+		"about.credit":              "%s, created by %s",
+		"about.uptime":              "⏱️ Uptime",
+		"about.guilds":              "🏠 Guilds",
+		"about.shard":               "🧩 Shard",
+		"about.commit":              "🔖 Commit",
+		"about.memory":              "💾 Memory",
+		"settings.title":            "Settings",
+		"settings.description":      "Configure FixEmbed's settings",
+		"settings.placeholder":      "Choose an option...",
+		"settings.services":         "Service Settings",
+		"settings.mention_users":    "Mention Users",
+		"settings.attribution":      "Attribution",
+		"settings.delete_original":  "Delete Original",
+		"settings.link_buttons":     "Link Buttons",
+		"settings.direct_media":     "Direct Media",
+		"settings.rich_embeds":      "Rich Embeds",
+		"settings.reupload_media":   "Re-upload Media",
+		"settings.preserve_text":    "Keep Message Text",
+		"settings.link_limit":       "Link Limit",
+		"settings.link_limit_value": "%d per message",
+		"settings.log_channel":      "Log Channel",
+		"settings.opt_out_keyword":  "Opt-out Keyword",
+		"settings.nsfw":             "NSFW Channels",
+		"settings.template":         "Repost Template",
+		"settings.embed_color":      "Embed Color",
+		"settings.language":         "Language",
+		"settings.webhooks":         "Webhooks",
+		"settings.simulate":         "Simulate Mode",
+		"settings.leaderboard":      "Leaderboard",
+		"settings.dm_fallback":      "DM Fallback",
+		"settings.frontends":        "Fixer Frontends",
+		"settings.off":              "Off",
+		"settings.default":          "Default",
+		"settings.automatic":        "Automatic",
+		"digest.failed":             "❌ Could not update the weekly digest.",
+		"digest.enabled":            "📰 The weekly digest will be posted in %s.",
+		"digest.disabled":           "📰 The weekly digest is now disabled.",
+		"digest.title":              "Weekly Digest",
+		"digest.since":              "FixEmbed activity since %s",
+		"digest.nothing":            "No links were fixed this week.",
+		"digest.links":              "Links Fixed",
+		"digest.channels":           "Most Active Channels",
+		"digest.posters":            "Top Posters",
+		"digest.problems":           "Problems",
+		"retention.failed":          "❌ Could not update the retention policy for %s.",
+		"retention.forever":         "🗂️ Fixed links in %s will be kept forever.",
+		"retention.days":            "🗑️ Fixed links in %s will be deleted after %d day(s).",
+		"audit.title":               "Settings History",
+		"audit.failed":              "❌ Could not read the settings history.",
+		"audit.empty":               "No settings have been changed yet.",
+		"audit.none":                "none",
+	},
+	discordgo.SpanishES: {
+		"repost.sent_by":            "Enviado por %s",
+		"repost.open_on":            "Abrir en %s",
+		"repost.delete":             "Eliminar",
+		"repost.delete_not_author":  "Solo quien publicó el enlace (o un moderador) puede eliminar esto.",
+		"notice.permission_title":   "A FixEmbed le falta un permiso",
+		"notice.permission":         "FixEmbed sigue fallando en %[2]s porque no tiene el permiso **%[1]s** allí. Concédeselo o usa /deactivate en ese canal.",
+		"dm.fallback":               "FixEmbed no puede publicar en %s, así que aquí tienes tu enlace arreglado:",
+		"error.not_allowed":         "Necesitas el permiso Gestionar servidor o Gestionar canales para hacer eso.",
+		"error.no_fix":              "❌ Aquí no hay ningún enlace que FixEmbed pueda arreglar.",
+		"error.post_failed":         "❌ No puedo publicar en este canal.",
+		"error.preference":          "❌ No se pudo actualizar tu preferencia, inténtalo de nuevo.",
+		"fix.done":                  "✅ Arreglado.",
+		"premium.required":          "💎 %s es una función premium. El resto de tus ajustes siguen funcionando igual.",
+		"premium.webhooks":          "Procesar mensajes de webhooks",
+		"premium.link_limit":        "Arreglar más de %d enlaces por mensaje",
+		"premium.template":          "Una plantilla de publicación personalizada",
+		"optout.out":                "✅ FixEmbed ya no arreglará tus enlaces en ningún servidor.",
+		"optout.in":                 "✅ FixEmbed volverá a arreglar tus enlaces.",
+		"pingme.default":            "✅ Tus publicaciones seguirán el ajuste de menciones de cada servidor.",
+		"pingme.always":             "✅ Tus publicaciones siempre te mencionarán.",
+		"pingme.never":              "✅ Tus publicaciones mostrarán tu nombre sin mencionarte.",
+		"ping.pinging":              "Midiendo…",
+		"help.intro":                "FixEmbed vuelve a publicar los enlaces de redes sociales a través de servicios que arreglan las vistas previas. Elige un tema abajo.",
+		"cmd.Fix Links":             "Arreglar enlaces",
+		"cmd.about":                 "Muestra información sobre el bot",
+		"cmd.help":                  "Aprende a usar FixEmbed",
+		"cmd.ping":                  "Comprueba la conexión de FixEmbed con Discord",
+		"cmd.stats":                 "Muestra cuántos enlaces ha arreglado FixEmbed en este servidor",
+		"cmd.leaderboard":           "Muestra a quién se le arreglan más enlaces",
+		"cmd.test":                  "Comprueba qué haría FixEmbed con un enlace en este canal",
+		"cmd.optout":                "Impide que FixEmbed arregle tus enlaces en todos los servidores",
+		"cmd.optin":                 "Deja que FixEmbed vuelva a arreglar tus enlaces",
+		"cmd.pingme":                "Elige si tus publicaciones te mencionan",
+		"cmd.vote":                  "Vota por FixEmbed en top.gg",
+		"cmd.fix":                   "Arregla un enlace ahora, incluso donde FixEmbed está desactivado",
+		"cmd.fix.url":               "El enlace que quieres arreglar",
+		"cmd.fix.private":           "Mostrar el enlace arreglado solo a ti",
+		"cmd.test.url":              "El enlace que quieres probar",
+		"cmd.stats.days":            "Contar solo los últimos días (por defecto: desde siempre)",
+		"cmd.stats.service":         "Contar solo los enlaces de un servicio",
+		"cmd.pingme.mode":           "Cuándo deben mencionarte tus publicaciones",
+		"activate.done":             "✅ ¡Activado en %s!",
+		"activate.done_children":    "✅ ¡Activado en %s y sus %d canal(es)!",
+		"deactivate.done":           "❌ ¡Desactivado en %s!",
+		"deactivate.done_children":  "❌ ¡Desactivado en %s y sus %d canal(es)!",
+		"about.title":               "Acerca de",
+		"about.description":         "Este bot soluciona la falta de vistas previas en Discord.",
+		"about.links":               "🎉 Enlaces rápidos",
+		"about.invite":              "Invitar a FixEmbed",
+		"about.source":              "Danos una estrella en GitHub",
+		"about.credits":             "📜 Créditos",
+		"about.credit":              "%s, creado por %s",
+		"about.uptime":              "⏱️ Tiempo activo",
+		"about.guilds":              "🏠 Servidores",
+		"about.shard":               "🧩 Shard",
+		"about.commit":              "🔖 Commit",
+		"about.memory":              "💾 Memoria",
+		"settings.title":            "Ajustes",
+		"settings.description":      "Configura los ajustes de FixEmbed",
+		"settings.placeholder":      "Elige una opción...",
+		"settings.services":         "Servicios",
+		"settings.mention_users":    "Mencionar usuarios",
+		"settings.attribution":      "Atribución",
+		"settings.delete_original":  "Eliminar original",
+		"settings.link_buttons":     "Botones de enlace",
+		"settings.direct_media":     "Multimedia directa",
+		"settings.rich_embeds":      "Vistas enriquecidas",
+		"settings.reupload_media":   "Volver a subir multimedia",
+		"settings.preserve_text":    "Conservar el texto",
+		"settings.link_limit":       "Límite de enlaces",
+		"settings.link_limit_value": "%d por mensaje",
+		"settings.log_channel":      "Canal de registro",
+		"settings.opt_out_keyword":  "Palabra para omitir",
+		"settings.nsfw":             "Canales NSFW",
+		"settings.template":         "Plantilla de publicación",
+		"settings.embed_color":      "Color de los embeds",
+		"settings.language":         "Idioma",
+		"settings.webhooks":         "Webhooks",
+		"settings.simulate":         "Modo simulación",
+		"settings.leaderboard":      "Clasificación",
+		"settings.dm_fallback":      "Respaldo por MD",
+		"settings.frontends":        "Servicios de arreglo",
+		"settings.off":              "Desactivado",
+		"settings.default":          "Predeterminado",
+		"settings.automatic":        "Automático",
+		"digest.failed":             "❌ No se pudo actualizar el resumen semanal.",
+		"digest.enabled":            "📰 El resumen semanal se publicará en %s.",
+		"digest.disabled":           "📰 El resumen semanal está desactivado.",
+		"digest.title":              "Resumen semanal",
+		"digest.since":              "Actividad de FixEmbed desde %s",
+		"digest.nothing":            "Esta semana no se arregló ningún enlace.",
+		"digest.links":              "Enlaces arreglados",
+		"digest.channels":           "Canales más activos",
+		"digest.posters":            "Quienes más publican",
+		"digest.problems":           "Problemas",
+		"retention.failed":          "❌ No se pudo actualizar la política de retención de %s.",
+		"retention.forever":         "🗂️ Los enlaces arreglados en %s se conservarán para siempre.",
+		"retention.days":            "🗑️ Los enlaces arreglados en %s se eliminarán después de %d día(s).",
+		"audit.title":               "Historial de ajustes",
+		"audit.failed":              "❌ No se pudo leer el historial de ajustes.",
+		"audit.empty":               "Todavía no se ha cambiado ningún ajuste.",
+		"audit.none":                "ninguno",
+		"menu.FixEmbed":             "Activa o desactiva el bot en todos los canales",
+		"menu.Mention Users":        "Activa o desactiva las menciones en los mensajes",
+		"menu.Delivery Method":      "Activa o desactiva la eliminación del mensaje original",
+		"menu.Link Style":           "Usa botones en lugar de enlaces enmascarados",
+		"menu.Direct Media":         "Publica solo la multimedia, sin la tarjeta de texto",
+		"menu.Rich Embeds":          "Crea los embeds con las API de los servicios de arreglo",
+		"menu.Re-upload Media":      "Adjunta la multimedia directamente a la publicación",
+		"menu.Keep Message Text":    "Conserva el resto del mensaje en la publicación",
+		"menu.Webhooks":             "Arregla enlaces en mensajes de webhooks (puentes, feeds)",
+		"menu.Channels":             "Activa o desactiva canales concretos",
+		"menu.Simulate Mode":        "Solo informa de lo que se arreglaría, sin publicar ni eliminar",
+		"menu.Leaderboard":          "Activa o desactiva la clasificación de /leaderboard",
+		"menu.Repost Template":      "Cambia el formato de las publicaciones",
+		"menu.Embed Color":          "Ajusta los embeds de FixEmbed a los colores de tu servidor",
+		"menu.DM Fallback":          "Envía el enlace por MD cuando FixEmbed no puede publicar",
+		"menu.Service Settings":     "Configura qué servicios están activados",
+		"menu.Fixer Frontends":      "Elige qué servicio de arreglo usa cada red",
+		"menu.Debug":                "Muestra la información de depuración actual",
+	},
+	discordgo.German: {
+		"repost.sent_by":            "Gesendet von %s",
+		"repost.open_on":            "Auf %s öffnen",
+		"repost.delete":             "Löschen",
+		"repost.delete_not_author":  "Nur die Person, die den Link gepostet hat (oder ein Moderator), kann das löschen.",
+		"notice.permission_title":   "FixEmbed fehlt eine Berechtigung",
+		"notice.permission":         "FixEmbed scheitert in %[2]s immer wieder, weil es dort die Berechtigung **%[1]s** nicht hat. Erteile sie oder nutze /deactivate in diesem Kanal.",
+		"dm.fallback":               "FixEmbed darf in %s nicht schreiben, deshalb bekommst du deinen reparierten Link hier:",
+		"error.not_allowed":         "Dafür brauchst du die Berechtigung „Server verwalten“ oder „Kanäle verwalten“.",
+		"error.no_fix":              "❌ Hier gibt es keinen Link, den FixEmbed reparieren kann.",
+		"error.post_failed":         "❌ Ich kann in diesem Kanal nicht schreiben.",
+		"error.preference":          "❌ Deine Einstellung konnte nicht gespeichert werden, bitte versuche es erneut.",
+		"fix.done":                  "✅ Repariert.",
+		"premium.required":          "💎 %s ist eine Premium-Funktion. Deine übrigen Einstellungen funktionieren weiterhin.",
+		"premium.webhooks":          "Webhook-Nachrichten verarbeiten",
+		"premium.link_limit":        "Mehr als %d Links pro Nachricht reparieren",
+		"premium.template":          "Eine eigene Repost-Vorlage",
+		"optout.out":                "✅ FixEmbed repariert deine Links ab jetzt auf keinem Server mehr.",
+		"optout.in":                 "✅ FixEmbed repariert deine Links wieder.",
+		"pingme.default":            "✅ Deine Reposts folgen der Erwähnungs-Einstellung des jeweiligen Servers.",
+		"pingme.always":             "✅ Deine Reposts erwähnen dich immer.",
+		"pingme.never":              "✅ Deine Reposts zeigen deinen Namen, ohne dich zu erwähnen.",
+		"ping.pinging":              "Messe…",
+		"help.intro":                "FixEmbed postet Social-Media-Links über Embed-Fixer neu, damit sie richtige Vorschauen bekommen. Wähle unten ein Thema.",
+		"cmd.Fix Links":             "Links reparieren",
+		"cmd.about":                 "Zeigt Informationen über den Bot",
+		"cmd.help":                  "Erfahre, wie man FixEmbed benutzt",
+		"cmd.ping":                  "Prüft die Verbindung von FixEmbed zu Discord",
+		"cmd.stats":                 "Zeigt, wie viele Links FixEmbed auf diesem Server repariert hat",
+		"cmd.leaderboard":           "Zeigt, wessen Links am häufigsten repariert werden",
+		"cmd.test":                  "Prüft, was FixEmbed in diesem Kanal mit einem Link machen würde",
+		"cmd.optout":                "Verhindert, dass FixEmbed deine Links auf allen Servern repariert",
+		"cmd.optin":                 "Lässt FixEmbed deine Links wieder reparieren",
+		"cmd.pingme":                "Lege fest, ob deine Reposts dich erwähnen",
+		"cmd.vote":                  "Stimme auf top.gg für FixEmbed ab",
+		"cmd.fix":                   "Repariert einen Link sofort, auch wo FixEmbed deaktiviert ist",
+		"cmd.fix.url":               "Der Link, der repariert werden soll",
+		"cmd.fix.private":           "Den reparierten Link nur dir zeigen",
+		"cmd.test.url":              "Der Link, der getestet werden soll",
+		"cmd.stats.days":            "Nur die letzten Tage zählen (Standard: gesamte Zeit)",
+		"cmd.stats.service":         "Nur Links zu einem Dienst zählen",
+		"cmd.pingme.mode":           "Wann deine Reposts dich erwähnen sollen",
+		"activate.done":             "✅ Aktiviert für %s!",
+		"activate.done_children":    "✅ Aktiviert für %s und %d Kanäle darin!",
+		"deactivate.done":           "❌ Deaktiviert für %s!",
+		"deactivate.done_children":  "❌ Deaktiviert für %s und %d Kanäle darin!",
+		"about.title":               "Über",
+		"about.description":         "Dieser Bot behebt fehlende Link-Vorschauen in Discord.",
+		"about.links":               "🎉 Schnellzugriff",
+		"about.invite":              "FixEmbed einladen",
+		"about.source":              "Gib unserem Quellcode einen Stern auf GitHub",
+		"about.credits":             "📜 Danksagungen",
+		"about.credit":              "%s, erstellt von %s",
+		"about.uptime":              "⏱️ Laufzeit",
+		"about.guilds":              "🏠 Server",
+		"about.shard":               "🧩 Shard",
+		"about.commit":              "🔖 Commit",
+		"about.memory":              "💾 Speicher",
+		"settings.title":            "Einstellungen",
+		"settings.description":      "Konfiguriere die Einstellungen von FixEmbed",
+		"settings.placeholder":      "Wähle eine Option...",
+		"settings.services":         "Dienste",
+		"settings.mention_users":    "Nutzer erwähnen",
+		"settings.attribution":      "Urheberangabe",
+		"settings.delete_original":  "Original löschen",
+		"settings.link_buttons":     "Link-Schaltflächen",
+		"settings.direct_media":     "Nur Medien",
+		"settings.rich_embeds":      "Erweiterte Embeds",
+		"settings.reupload_media":   "Medien neu hochladen",
+		"settings.preserve_text":    "Nachrichtentext behalten",
+		"settings.link_limit":       "Link-Limit",
+		"settings.link_limit_value": "%d pro Nachricht",
+		"settings.log_channel":      "Log-Kanal",
+		"settings.opt_out_keyword":  "Opt-out-Schlüsselwort",
+		"settings.nsfw":             "NSFW-Kanäle",
+		"settings.template":         "Repost-Vorlage",
+		"settings.embed_color":      "Embed-Farbe",
+		"settings.language":         "Sprache",
+		"settings.webhooks":         "Webhooks",
+		"settings.simulate":         "Simulationsmodus",
+		"settings.leaderboard":      "Rangliste",
+		"settings.dm_fallback":      "DM als Ausweg",
+		"settings.frontends":        "Fixer-Frontends",
+		"settings.off":              "Aus",
+		"settings.default":          "Standard",
+		"settings.automatic":        "Automatisch",
+		"digest.failed":             "❌ Die Wochenübersicht konnte nicht aktualisiert werden.",
+		"digest.enabled":            "📰 Die Wochenübersicht wird in %s gepostet.",
+		"digest.disabled":           "📰 Die Wochenübersicht ist jetzt deaktiviert.",
+		"digest.title":              "Wochenübersicht",
+		"digest.since":              "FixEmbed-Aktivität seit %s",
+		"digest.nothing":            "Diese Woche wurden keine Links repariert.",
+		"digest.links":              "Reparierte Links",
+		"digest.channels":           "Aktivste Kanäle",
+		"digest.posters":            "Aktivste Mitglieder",
+		"digest.problems":           "Probleme",
+		"retention.failed":          "❌ Die Aufbewahrungsregel für %s konnte nicht aktualisiert werden.",
+		"retention.forever":         "🗂️ Reparierte Links in %s werden für immer behalten.",
+		"retention.days":            "🗑️ Reparierte Links in %s werden nach %d Tag(en) gelöscht.",
+		"audit.title":               "Einstellungsverlauf",
+		"audit.failed":              "❌ Der Einstellungsverlauf konnte nicht gelesen werden.",
+		"audit.empty":               "Es wurden noch keine Einstellungen geändert.",
+		"audit.none":                "keine",
+		"menu.FixEmbed":             "Den Bot in allen Kanälen aktivieren oder deaktivieren",
+		"menu.Mention Users":        "Erwähnungen in Nachrichten ein- oder ausschalten",
+		"menu.Delivery Method":      "Löschen der Originalnachricht ein- oder ausschalten",
+		"menu.Link Style":           "Link-Schaltflächen statt maskierter Links verwenden",
+		"menu.Direct Media":         "Nur die Medien ohne Textkarte posten",
+		"menu.Rich Embeds":          "Embeds aus den APIs der Fixer erstellen",
+		"menu.Re-upload Media":      "Medien direkt an den Repost anhängen",
+		"menu.Keep Message Text":    "Den restlichen Nachrichtentext im Repost behalten",
+		"menu.Webhooks":             "Links in Webhook-Nachrichten reparieren (Bridges, Feeds)",
+		"menu.Channels":             "Einzelne Kanäle aktivieren oder deaktivieren",
+		"menu.Simulate Mode":        "Nur melden, was repariert würde, ohne zu posten oder zu löschen",
+		"menu.Leaderboard":          "Die /leaderboard-Rangliste ein- oder ausschalten",
+		"menu.Repost Template":      "Das Layout der Reposts ändern",
+		"menu.Embed Color":          "Die Embeds von FixEmbed an die Farben deines Servers anpassen",
+		"menu.DM Fallback":          "Den Link per DM senden, wenn FixEmbed nicht posten darf",
+		"menu.Service Settings":     "Festlegen, welche Dienste aktiviert sind",
+		"menu.Fixer Frontends":      "Wählen, welchen Fixer jeder Dienst nutzt",
+		"menu.Debug":                "Aktuelle Debug-Informationen anzeigen",
+	},
+	discordgo.French: {
+		"repost.sent_by":            "Envoyé par %s",
+		"repost.open_on":            "Ouvrir sur %s",
+		"repost.delete":             "Supprimer",
+		"repost.delete_not_author":  "Seule la personne qui a publié le lien (ou un modérateur) peut supprimer ceci.",
+		"notice.permission_title":   "Il manque une permission à FixEmbed",
+		"notice.permission":         "FixEmbed échoue sans cesse dans %[2]s car il n'y a pas la permission **%[1]s**. Accorde-la, ou utilise /deactivate dans ce salon.",
+		"dm.fallback":               "FixEmbed ne peut pas publier dans %s, alors voici ton lien corrigé :",
+		"error.not_allowed":         "Il te faut la permission Gérer le serveur ou Gérer les salons pour faire ça.",
+		"error.no_fix":              "❌ Il n'y a aucun lien que FixEmbed peut corriger ici.",
+		"error.post_failed":         "❌ Je ne peux pas publier dans ce salon.",
+		"error.preference":          "❌ Impossible de mettre à jour ta préférence, réessaie.",
+		"fix.done":                  "✅ Corrigé.",
+		"premium.required":          "💎 %s est une fonctionnalité premium. Tes autres réglages continuent de fonctionner.",
+		"premium.webhooks":          "Le traitement des messages de webhooks",
+		"premium.link_limit":        "La correction de plus de %d liens par message",
+		"premium.template":          "Un modèle de republication personnalisé",
+		"optout.out":                "✅ FixEmbed ne corrigera plus tes liens, sur aucun serveur.",
+		"optout.in":                 "✅ FixEmbed corrigera à nouveau tes liens.",
+		"pingme.default":            "✅ Tes republications suivront le réglage de mention de chaque serveur.",
+		"pingme.always":             "✅ Tes republications te mentionneront toujours.",
+		"pingme.never":              "✅ Tes republications afficheront ton nom sans te mentionner.",
+		"ping.pinging":              "Mesure…",
+		"help.intro":                "FixEmbed republie les liens de réseaux sociaux via des services de correction pour qu'ils aient de vrais aperçus. Choisis un sujet ci-dessous.",
+		"cmd.Fix Links":             "Corriger les liens",
+		"cmd.about":                 "Affiche des informations sur le bot",
+		"cmd.help":                  "Apprends à utiliser FixEmbed",
+		"cmd.ping":                  "Vérifie la connexion de FixEmbed à Discord",
+		"cmd.stats":                 "Affiche combien de liens FixEmbed a corrigés sur ce serveur",
+		"cmd.leaderboard":           "Affiche qui a le plus de liens corrigés",
+		"cmd.test":                  "Vérifie ce que FixEmbed ferait d'un lien dans ce salon",
+		"cmd.optout":                "Empêche FixEmbed de corriger tes liens sur tous les serveurs",
+		"cmd.optin":                 "Laisse FixEmbed corriger à nouveau tes liens",
+		"cmd.pingme":                "Choisis si tes republications te mentionnent",
+		"cmd.vote":                  "Vote pour FixEmbed sur top.gg",
+		"cmd.fix":                   "Corrige un lien tout de suite, même là où FixEmbed est désactivé",
+		"cmd.fix.url":               "Le lien à corriger",
+		"cmd.fix.private":           "N'afficher le lien corrigé qu'à toi",
+		"cmd.test.url":              "Le lien à tester",
+		"cmd.stats.days":            "Ne compter que les derniers jours (par défaut : depuis toujours)",
+		"cmd.stats.service":         "Ne compter que les liens d'un service",
+		"cmd.pingme.mode":           "Quand tes republications doivent te mentionner",
+		"activate.done":             "✅ Activé pour %s !",
+		"activate.done_children":    "✅ Activé pour %s et ses %d salon(s) !",
+		"deactivate.done":           "❌ Désactivé pour %s !",
+		"deactivate.done_children":  "❌ Désactivé pour %s et ses %d salon(s) !",
+		"about.title":               "À propos",
+		"about.description":         "Ce bot corrige le manque d'aperçus dans Discord.",
+		"about.links":               "🎉 Liens rapides",
+		"about.invite":              "Inviter FixEmbed",
+		"about.source":              "Mets une étoile à notre code source sur GitHub",
+		"about.credits":             "📜 Crédits",
+		"about.credit":              "%s, créé par %s",
+		"about.uptime":              "⏱️ Disponibilité",
+		"about.guilds":              "🏠 Serveurs",
+		"about.shard":               "🧩 Shard",
+		"about.commit":              "🔖 Commit",
+		"about.memory":              "💾 Mémoire",
+		"settings.title":            "Réglages",
+		"settings.description":      "Configure les réglages de FixEmbed",
+		"settings.placeholder":      "Choisis une option...",
+		"settings.services":         "Services",
+		"settings.mention_users":    "Mentionner les membres",
+		"settings.attribution":      "Attribution",
+		"settings.delete_original":  "Supprimer l'original",
+		"settings.link_buttons":     "Boutons de lien",
+		"settings.direct_media":     "Médias directs",
+		"settings.rich_embeds":      "Embeds enrichis",
+		"settings.reupload_media":   "Republier les médias",
+		"settings.preserve_text":    "Garder le texte du message",
+		"settings.link_limit":       "Limite de liens",
+		"settings.link_limit_value": "%d par message",
+		"settings.log_channel":      "Salon de journal",
+		"settings.opt_out_keyword":  "Mot-clé d'exclusion",
+		"settings.nsfw":             "Salons NSFW",
+		"settings.template":         "Modèle de republication",
+		"settings.embed_color":      "Couleur des embeds",
+		"settings.language":         "Langue",
+		"settings.webhooks":         "Webhooks",
+		"settings.simulate":         "Mode simulation",
+		"settings.leaderboard":      "Classement",
+		"settings.dm_fallback":      "Repli en MP",
+		"settings.frontends":        "Services de correction",
+		"settings.off":              "Désactivé",
+		"settings.default":          "Par défaut",
+		"settings.automatic":        "Automatique",
+		"digest.failed":             "❌ Impossible de mettre à jour le résumé hebdomadaire.",
+		"digest.enabled":            "📰 Le résumé hebdomadaire sera publié dans %s.",
+		"digest.disabled":           "📰 Le résumé hebdomadaire est désormais désactivé.",
+		"digest.title":              "Résumé hebdomadaire",
+		"digest.since":              "Activité de FixEmbed depuis %s",
+		"digest.nothing":            "Aucun lien n'a été corrigé cette semaine.",
+		"digest.links":              "Liens corrigés",
+		"digest.channels":           "Salons les plus actifs",
+		"digest.posters":            "Membres les plus actifs",
+		"digest.problems":           "Problèmes",
+		"retention.failed":          "❌ Impossible de mettre à jour la durée de conservation pour %s.",
+		"retention.forever":         "🗂️ Les liens corrigés dans %s seront conservés pour toujours.",
+		"retention.days":            "🗑️ Les liens corrigés dans %s seront supprimés après %d jour(s).",
+		"audit.title":               "Historique des réglages",
+		"audit.failed":              "❌ Impossible de lire l'historique des réglages.",
+		"audit.empty":               "Aucun réglage n'a encore été modifié.",
+		"audit.none":                "aucun",
+		"menu.FixEmbed":             "Activer ou désactiver le bot dans tous les salons",
+		"menu.Mention Users":        "Activer ou désactiver les mentions dans les messages",
+		"menu.Delivery Method":      "Activer ou désactiver la suppression du message d'origine",
+		"menu.Link Style":           "Utiliser des boutons au lieu de liens masqués",
+		"menu.Direct Media":         "Publier uniquement le média, sans la carte de texte",
+		"menu.Rich Embeds":          "Construire les embeds à partir des API des services",
+		"menu.Re-upload Media":      "Joindre les médias directement à la republication",
+		"menu.Keep Message Text":    "Garder le reste du message dans la republication",
+		"menu.Webhooks":             "Corriger les liens des messages de webhooks (ponts, flux)",
+		"menu.Channels":             "Activer ou désactiver des salons un par un",
+		"menu.Simulate Mode":        "Signaler seulement ce qui serait corrigé, sans publier ni supprimer",
+		"menu.Leaderboard":          "Activer ou désactiver le classement /leaderboard",
+		"menu.Repost Template":      "Changer la mise en page des republications",
+		"menu.Embed Color":          "Accorder les embeds de FixEmbed aux couleurs de ton serveur",
+		"menu.DM Fallback":          "Envoyer le lien en MP quand FixEmbed ne peut pas publier",
+		"menu.Service Settings":     "Choisir quels services sont activés",
+		"menu.Fixer Frontends":      "Choisir le service de correction de chaque réseau",
+		"menu.Debug":                "Afficher les informations de débogage actuelles",
+	},
+	discordgo.PortugueseBR: {
+		"repost.sent_by":            "Enviado por %s",
+		"repost.open_on":            "Abrir no %s",
+		"repost.delete":             "Excluir",
+		"repost.delete_not_author":  "Só quem postou o link (ou um moderador) pode excluir isto.",
+		"notice.permission_title":   "Falta uma permissão ao FixEmbed",
+		"notice.permission":         "O FixEmbed continua falhando em %[2]s porque não tem a permissão **%[1]s** lá. Conceda-a ou use /deactivate nesse canal.",
+		"dm.fallback":               "O FixEmbed não pode postar em %s, então aqui está seu link corrigido:",
+		"error.not_allowed":         "Você precisa da permissão Gerenciar servidor ou Gerenciar canais para fazer isso.",
+		"error.no_fix":              "❌ Não há nenhum link que o FixEmbed possa corrigir aqui.",
+		"error.post_failed":         "❌ Não consigo postar neste canal.",
+		"error.preference":          "❌ Não foi possível atualizar sua preferência, tente novamente.",
+		"fix.done":                  "✅ Corrigido.",
+		"premium.required":          "💎 %s é um recurso premium. Suas outras configurações continuam funcionando.",
+		"premium.webhooks":          "Processar mensagens de webhooks",
+		"premium.link_limit":        "Corrigir mais de %d links por mensagem",
+		"premium.template":          "Um modelo de repost personalizado",
+		"optout.out":                "✅ O FixEmbed não vai mais corrigir seus links em nenhum servidor.",
+		"optout.in":                 "✅ O FixEmbed vai voltar a corrigir seus links.",
+		"pingme.default":            "✅ Seus reposts vão seguir a configuração de menções de cada servidor.",
+		"pingme.always":             "✅ Seus reposts sempre vão mencionar você.",
+		"pingme.never":              "✅ Seus reposts vão mostrar seu nome sem mencionar você.",
+		"ping.pinging":              "Medindo…",
+		"help.intro":                "O FixEmbed reposta links de redes sociais por meio de serviços que corrigem as prévias. Escolha um tópico abaixo.",
+		"cmd.Fix Links":             "Corrigir links",
+		"cmd.about":                 "Mostra informações sobre o bot",
+		"cmd.help":                  "Aprenda a usar o FixEmbed",
+		"cmd.ping":                  "Verifica a conexão do FixEmbed com o Discord",
+		"cmd.stats":                 "Mostra quantos links o FixEmbed corrigiu neste servidor",
+		"cmd.leaderboard":           "Mostra quem tem mais links corrigidos",
+		"cmd.test":                  "Verifica o que o FixEmbed faria com um link neste canal",
+		"cmd.optout":                "Impede o FixEmbed de corrigir seus links em todos os servidores",
+		"cmd.optin":                 "Deixa o FixEmbed corrigir seus links novamente",
+		"cmd.pingme":                "Escolha se seus reposts mencionam você",
+		"cmd.vote":                  "Vote no FixEmbed no top.gg",
+		"cmd.fix":                   "Corrige um link agora, mesmo onde o FixEmbed está desativado",
+		"cmd.fix.url":               "O link a corrigir",
+		"cmd.fix.private":           "Mostrar o link corrigido só para você",
+		"cmd.test.url":              "O link a testar",
+		"cmd.stats.days":            "Contar só os últimos dias (padrão: desde sempre)",
+		"cmd.stats.service":         "Contar só os links de um serviço",
+		"cmd.pingme.mode":           "Quando seus reposts devem mencionar você",
+		"activate.done":             "✅ Ativado em %s!",
+		"activate.done_children":    "✅ Ativado em %s e nos seus %d canal(is)!",
+		"deactivate.done":           "❌ Desativado em %s!",
+		"deactivate.done_children":  "❌ Desativado em %s e nos seus %d canal(is)!",
+		"about.title":               "Sobre",
+		"about.description":         "Este bot corrige a falta de prévias no Discord.",
+		"about.links":               "🎉 Links rápidos",
+		"about.invite":              "Convidar o FixEmbed",
+		"about.source":              "Dê uma estrela no nosso código no GitHub",
+		"about.credits":             "📜 Créditos",
+		"about.credit":              "%s, criado por %s",
+		"about.uptime":              "⏱️ Tempo ativo",
+		"about.guilds":              "🏠 Servidores",
+		"about.shard":               "🧩 Shard",
+		"about.commit":              "🔖 Commit",
+		"about.memory":              "💾 Memória",
+		"settings.title":            "Configurações",
+		"settings.description":      "Configure o FixEmbed",
+		"settings.placeholder":      "Escolha uma opção...",
+		"settings.services":         "Serviços",
+		"settings.mention_users":    "Mencionar usuários",
+		"settings.attribution":      "Atribuição",
+		"settings.delete_original":  "Excluir original",
+		"settings.link_buttons":     "Botões de link",
+		"settings.direct_media":     "Mídia direta",
+		"settings.rich_embeds":      "Embeds completos",
+		"settings.reupload_media":   "Reenviar mídia",
+		"settings.preserve_text":    "Manter o texto da mensagem",
+		"settings.link_limit":       "Limite de links",
+		"settings.link_limit_value": "%d por mensagem",
+		"settings.log_channel":      "Canal de registro",
+		"settings.opt_out_keyword":  "Palavra para ignorar",
+		"settings.nsfw":             "Canais NSFW",
+		"settings.template":         "Modelo de repost",
+		"settings.embed_color":      "Cor dos embeds",
+		"settings.language":         "Idioma",
+		"settings.webhooks":         "Webhooks",
+		"settings.simulate":         "Modo simulação",
+		"settings.leaderboard":      "Ranking",
+		"settings.dm_fallback":      "Alternativa por DM",
+		"settings.frontends":        "Serviços de correção",
+		"settings.off":              "Desligado",
+		"settings.default":          "Padrão",
+		"settings.automatic":        "Automático",
+		"digest.failed":             "❌ Não foi possível atualizar o resumo semanal.",
+		"digest.enabled":            "📰 O resumo semanal será postado em %s.",
+		"digest.disabled":           "📰 O resumo semanal agora está desativado.",
+		"digest.title":              "Resumo semanal",
+		"digest.since":              "Atividade do FixEmbed desde %s",
+		"digest.nothing":            "Nenhum link foi corrigido nesta semana.",
+		"digest.links":              "Links corrigidos",
+		"digest.channels":           "Canais mais ativos",
+		"digest.posters":            "Quem mais postou",
+		"digest.problems":           "Problemas",
+		"retention.failed":          "❌ Não foi possível atualizar a política de retenção de %s.",
+		"retention.forever":         "🗂️ Os links corrigidos em %s serão mantidos para sempre.",
+		"retention.days":            "🗑️ Os links corrigidos em %s serão excluídos após %d dia(s).",
+		"audit.title":               "Histórico de configurações",
+		"audit.failed":              "❌ Não foi possível ler o histórico de configurações.",
+		"audit.empty":               "Nenhuma configuração foi alterada ainda.",
+		"audit.none":                "nenhum",
+		"menu.FixEmbed":             "Ativa ou desativa o bot em todos os canais",
+		"menu.Mention Users":        "Ativa ou desativa menções nas mensagens",
+		"menu.Delivery Method":      "Ativa ou desativa a exclusão da mensagem original",
+		"menu.Link Style":           "Usa botões em vez de links mascarados",
+		"menu.Direct Media":         "Posta só a mídia, sem o cartão de texto",
+		"menu.Rich Embeds":          "Monta os embeds com as APIs dos serviços de correção",
+		"menu.Re-upload Media":      "Anexa a mídia diretamente ao repost",
+		"menu.Keep Message Text":    "Mantém o resto da mensagem no repost",
+		"menu.Webhooks":             "Corrige links em mensagens de webhooks (pontes, feeds)",
+		"menu.Channels":             "Ativa ou desativa canais individualmente",
+		"menu.Simulate Mode":        "Só informa o que seria corrigido, sem postar nem excluir",
+		"menu.Leaderboard":          "Ativa ou desativa o ranking do /leaderboard",
+		"menu.Repost Template":      "Muda o layout dos reposts",
+		"menu.Embed Color":          "Combina os embeds do FixEmbed com as cores do seu servidor",
+		"menu.DM Fallback":          "Envia o link por DM quando o FixEmbed não pode postar",
+		"menu.Service Settings":     "Configura quais serviços estão ativados",
+		"menu.Fixer Frontends":      "Escolhe qual serviço de correção cada rede usa",
+		"menu.Debug":                "Mostra as informações de depuração atuais",
+	},
+}
+
+// catalogFor picks the catalog for a locale, falling back to another variant of the same
+// language (es-419 reads es-ES) and then to nothing.
+func catalogFor(locale discordgo.Locale) catalog {
+	if c, ok := translations[locale]; ok {
+		return c
+	}
+	lang, _, _ := strings.Cut(string(locale), "-")
+	for l, c := range translations {
+		if other, _, _ := strings.Cut(string(l), "-"); other == lang {
+			return c
+		}
+	}
+	return nil
+}
+
+// T formats the message for key in locale, falling back to English and then to the key itself.
+func T(locale discordgo.Locale, key string, args ...interface{}) string {
+	format, ok := catalogFor(locale)[key]
+	if !ok {
+		if format, ok = translations[FALLBACK_LOCALE][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// native names of the languages FixEmbed has been translated to, for /language
+var localeNames = map[discordgo.Locale]string{
+	discordgo.EnglishUS:    "English",
+	discordgo.SpanishES:    "Español",
+	discordgo.German:       "Deutsch",
+	discordgo.French:       "Français",
+	discordgo.PortugueseBR: "Português (Brasil)",
+}
+
+// guildLocale is the language FixEmbed writes in where nobody in particular is being answered,
+// e.g. reposts: the server's chosen language, else its preferred locale.
+func guildLocale(s *discordgo.Session, guildID string, settings *GuildSettings) discordgo.Locale {
+	if settings.Locale != "" {
+		return discordgo.Locale(settings.Locale)
+	}
+	if g, err := s.State.Guild(guildID); err == nil && g.PreferredLocale != "" {
+		return discordgo.Locale(g.PreferredLocale)
+	}
+	return FALLBACK_LOCALE
+}
+
+// interactionLocale is the language to answer an interaction in: the server's chosen language,
+// else the language of whoever is being answered.
+func interactionLocale(i *discordgo.InteractionCreate) discordgo.Locale {
+	if i.GuildID != "" {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		botSettings.RLock()
+		settings := botSettings.m[gidInt]
+		botSettings.RUnlock()
+		if settings != nil && settings.Locale != "" {
+			return discordgo.Locale(settings.Locale)
+		}
+	}
+	return i.Locale
+}
+
+// localizeCommands fills in the translated descriptions (and, for context menu commands, names)
+// of commands and their options from the "cmd." catalog keys.
+func localizeCommands(commands []*discordgo.ApplicationCommand) {
+	for _, cmd := range commands {
+		for locale, c := range translations {
+			if locale == FALLBACK_LOCALE {
+				continue
+			}
+			text, ok := c["cmd."+cmd.Name]
+			if !ok {
+				continue
+			}
+			if cmd.Type == discordgo.MessageApplicationCommand || cmd.Type == discordgo.UserApplicationCommand {
+				if cmd.NameLocalizations == nil {
+					cmd.NameLocalizations = &map[discordgo.Locale]string{}
+				}
+				(*cmd.NameLocalizations)[locale] = text
+				continue
+			}
+			if cmd.DescriptionLocalizations == nil {
+				cmd.DescriptionLocalizations = &map[discordgo.Locale]string{}
+			}
+			(*cmd.DescriptionLocalizations)[locale] = text
+			for _, opt := range cmd.Options {
+				if text, ok := c["cmd."+cmd.Name+"."+opt.Name]; ok {
+					if opt.DescriptionLocalizations == nil {
+						opt.DescriptionLocalizations = map[discordgo.Locale]string{}
+					}
+					opt.DescriptionLocalizations[locale] = text
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestT(t *testing.T) {
+	for _, tt := range []struct {
+		locale discordgo.Locale
+		key    string
+		want   string
+	}{
+		{discordgo.SpanishES, "repost.sent_by", "Enviado por <@5>"},
+		{discordgo.Locale("es-419"), "repost.sent_by", "Enviado por <@5>"}, // same language, other region
+		{discordgo.Japanese, "repost.sent_by", "Sent by <@5>"},
+	} {
+		if got := T(tt.locale, tt.key, "<@5>"); got != tt.want {
+			t.Errorf("T(%s, %s) = %q, want %q", tt.locale, tt.key, got, tt.want)
+		}
+	}
+	if got := T(discordgo.SpanishES, "no.such.key"); got != "no.such.key" {
+		t.Errorf("a missing key = %q, want the key", got)
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for locale, c := range translations {
+		for key := range translations[FALLBACK_LOCALE] {
+			if _, ok := c[key]; !ok {
+				t.Errorf("%s has no translation for %s", locale, key)
+			}
+		}
+	}
+}
+
+func TestSettingsMenuTranslated(t *testing.T) {
+	for locale, c := range translations {
+		if locale == FALLBACK_LOCALE {
+			continue
+		}
+		for _, e := range settingsEntries {
+			if _, ok := c["menu."+e.Label]; !ok {
+				t.Errorf("%s has no translation for the %s menu entry", locale, e.Label)
+			}
+		}
+	}
+	menu := settingsSelectMenu(discordgo.German, defaultGuildSettings(), true)
+	if menu.Placeholder != "Wähle eine Option..." || menu.Options[0].Description != translations[discordgo.German]["menu.FixEmbed"] {
+		t.Errorf("German menu = %q, %q", menu.Placeholder, menu.Options[0].Description)
+	}
+	if menu.Options[0].Value != "FixEmbed" {
+		t.Errorf("menu value = %q, want the untranslated label", menu.Options[0].Value)
+	}
+}
+
+func TestLocalizeCommands(t *testing.T) {
+	commands := []*discordgo.ApplicationCommand{
+		{Name: "fix", Description: "Fix a link", Options: []*discordgo.ApplicationCommandOption{{Name: "url", Description: "The link"}}},
+		{Name: "Fix Links", Type: discordgo.MessageApplicationCommand},
+	}
+	localizeCommands(commands)
+	if got := (*commands[0].DescriptionLocalizations)[discordgo.SpanishES]; got != translations[discordgo.SpanishES]["cmd.fix"] {
+		t.Errorf("/fix in Spanish = %q", got)
+	}
+	if got := commands[0].Options[0].DescriptionLocalizations[discordgo.SpanishES]; got != "El enlace que quieres arreglar" {
+		t.Errorf("/fix url in Spanish = %q", got)
+	}
+	if got := (*commands[1].NameLocalizations)[discordgo.SpanishES]; got != "Arreglar enlaces" {
+		t.Errorf("Fix Links in Spanish = %q", got)
+	}
+}
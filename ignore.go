@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// accounts (users or bots) whose messages a guild never wants rewritten
+var ignoredUsers = struct {
+	sync.RWMutex
+	m map[int64]map[int64]bool // guild ID -> user IDs
+}{m: make(map[int64]map[int64]bool)}
+
+func (st *sqliteStore) LoadIgnoredUsers() (map[int64]map[int64]bool, error) {
+	rows, err := st.db.Query("SELECT guild_id, user_id FROM ignored_users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ignored := make(map[int64]map[int64]bool)
+	for rows.Next() {
+		var guildID, userID int64
+		if err := rows.Scan(&guildID, &userID); err != nil {
+			continue
+		}
+		if ignored[guildID] == nil {
+			ignored[guildID] = make(map[int64]bool)
+		}
+		ignored[guildID][userID] = true
+	}
+	return ignored, nil
+}
+
+func loadIgnoredUsers(st Store) error {
+	ignored, err := st.LoadIgnoredUsers()
+	if err != nil {
+		return err
+	}
+	ignoredUsers.Lock()
+	ignoredUsers.m = ignored
+	ignoredUsers.Unlock()
+	return nil
+}
+
+func isIgnored(guildID, userID string) bool {
+	gid, _ := discordIDStringToInt64(guildID)
+	uid, _ := discordIDStringToInt64(userID)
+	ignoredUsers.RLock()
+	defer ignoredUsers.RUnlock()
+	return ignoredUsers.m[gid][uid]
+}
+
+func (st *sqliteStore) UpdateIgnoredUser(guildID, userID int64, ignore bool) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		var err error
+		if ignore {
+			_, err = st.db.Exec("INSERT OR REPLACE INTO ignored_users (guild_id, user_id, created_at) VALUES (?, ?, ?)", guildID, userID, time.Now().Unix())
+		} else {
+			_, err = st.db.Exec("DELETE FROM ignored_users WHERE guild_id = ? AND user_id = ?", guildID, userID)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func updateIgnoredUser(st Store, guildID, userID int64, ignore bool) error {
+	if err := st.UpdateIgnoredUser(guildID, userID, ignore); err != nil {
+		return err
+	}
+	cacheIgnoredUser(guildID, userID, ignore)
+	return nil
+}
+
+func handleIgnoreCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Ignored Accounts",
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	respond := func() {
+		createFooter(embed, s)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+				Flags:  1 << 6, // ephemeral
+			},
+		})
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name == "list" {
+		ignoredUsers.RLock()
+		var lines []string
+		for uid := range ignoredUsers.m[gidInt] {
+			lines = append(lines, fmt.Sprintf("- <@%d>", uid))
+		}
+		ignoredUsers.RUnlock()
+		if len(lines) == 0 {
+			embed.Description = "No accounts are ignored. Add one with `/ignore add`."
+		} else {
+			embed.Description = strings.Join(lines, "\n")
+		}
+		respond()
+		return
+	}
+
+	user := sub.Options[0].UserValue(nil)
+	uid, _ := discordIDStringToInt64(user.ID)
+	ignore := sub.Name == "add"
+	if err := updateIgnoredUser(st, gidInt, uid, ignore); err != nil {
+		logf(LOG_WARN, "Error updating ignore list for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the ignore list."
+		embed.Color = 0xff0000
+	} else if ignore {
+		embed.Description = fmt.Sprintf("🙈 Messages from <@%s> won't be fixed.", user.ID)
+	} else {
+		embed.Description = fmt.Sprintf("👀 Messages from <@%s> will be fixed again.", user.ID)
+	}
+	respond()
+}
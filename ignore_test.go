@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestIgnoreCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	forget := func() {
+		ignoredUsers.Lock()
+		ignoredUsers.m = make(map[int64]map[int64]bool)
+		ignoredUsers.Unlock()
+	}
+	t.Cleanup(forget)
+	run := func(sub string, userID string) string {
+		var options []*discordgo.ApplicationCommandInteractionDataOption
+		if userID != "" {
+			user := option("user", userID)
+			user.Type = discordgo.ApplicationCommandOptionUser
+			options = append(options, user)
+		}
+		handleIgnoreCommand(db, s, slashCommand("ignore", option(sub, nil, options...)))
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	run("add", "5")
+	run("add", "6")
+	if !isIgnored("1", "5") || isIgnored("2", "5") {
+		t.Fatalf("ignored in guild 1: %t, in guild 2: %t; want only guild 1", isIgnored("1", "5"), isIgnored("2", "5"))
+	}
+	postMessage(t, db, s, defaultGuildSettings(), "https://x.com/a/status/1")
+	if strings.Contains(strings.Join(fake.calls(), " "), "/channels/20/messages") {
+		t.Errorf("an ignored member's link was fixed: %v", fake.calls())
+	}
+
+	run("remove", "6")
+	if list := run("list", ""); !strings.Contains(list, `\u003c@5\u003e`) || strings.Contains(list, "@6") {
+		t.Errorf("list = %s, want only member 5", list)
+	}
+	forget()
+	if err := loadIgnoredUsers(db); err != nil || !isIgnored("1", "5") || isIgnored("1", "6") {
+		t.Errorf("after reloading: 5 ignored %t, 6 ignored %t, %v", isIgnored("1", "5"), isIgnored("1", "6"), err)
+	}
+}
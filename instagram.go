@@ -0,0 +1,25 @@
+package main
+
+import "regexp"
+
+var (
+	instagramStoryRe = regexp.MustCompile(`instagram\.com/stories/([A-Za-z0-9_.]+)/([0-9]+)`)
+	instagramPostRe  = regexp.MustCompile(`instagram\.com/(?:[A-Za-z0-9_.]+/)?(p|reels?|tv)/([A-Za-z0-9_-]+)`)
+)
+
+// canonicalInstagramLink normalizes post, reel and story links to the forms InstaFix understands,
+// dropping the username prefix and share tokens like ?igsh=. It also returns the text shown
+// after "Instagram •": the story's owner, or the post's shortcode.
+func canonicalInstagramLink(link string) (string, string, bool) {
+	if mm := instagramStoryRe.FindStringSubmatch(link); len(mm) > 2 {
+		return "instagram.com/stories/" + mm[1] + "/" + mm[2], mm[1], true
+	}
+	if mm := instagramPostRe.FindStringSubmatch(link); len(mm) > 2 {
+		kind := "p"
+		if mm[1] == "reel" || mm[1] == "reels" {
+			kind = "reel"
+		}
+		return "instagram.com/" + kind + "/" + mm[2], mm[2], true
+	}
+	return "", "", false
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestCanonicalInstagramLink(t *testing.T) {
+	tests := []struct {
+		link, want, id string
+		ok             bool
+	}{
+		{"instagram.com/p/AbC-1/?igsh=xyz", "instagram.com/p/AbC-1", "AbC-1", true},
+		{"instagram.com/someone/reels/AbC-1", "instagram.com/reel/AbC-1", "AbC-1", true},
+		{"instagram.com/tv/AbC-1", "instagram.com/p/AbC-1", "AbC-1", true},
+		{"instagram.com/stories/some.one/123", "instagram.com/stories/some.one/123", "some.one", true},
+		{"instagram.com/someone", "", "", false},
+	}
+	for _, tt := range tests {
+		got, id, ok := canonicalInstagramLink(tt.link)
+		if got != tt.want || id != tt.id || ok != tt.ok {
+			t.Errorf("canonicalInstagramLink(%q) = %q, %q, %t; want %q, %q, %t", tt.link, got, id, ok, tt.want, tt.id, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Value of the /language choice that follows the server's and members' own languages
+const LANGUAGE_AUTO = "auto"
+
+func languageChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{{Name: "Automatic", Value: LANGUAGE_AUTO}}
+	for locale, name := range localeNames {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: string(locale)})
+	}
+	sort.Slice(choices[1:], func(a, b int) bool { return choices[1+a].Name < choices[1+b].Name })
+	return choices
+}
+
+func handleLanguageCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	locale := i.ApplicationCommandData().Options[0].StringValue()
+	if locale == LANGUAGE_AUTO {
+		locale = ""
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := st.UpdateGuildColumn(gidInt, "locale", locale); err != nil {
+		logf(LOG_WARN, "Error updating language for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the language."
+		embed.Color = 0xff0000
+	} else {
+		updated := *getGuildSettings(gidInt)
+		updated.Locale = locale
+		cacheGuildSettings(gidInt, &updated)
+		if locale == "" {
+			embed.Description = "🌐 Reposts follow the server's language and replies follow each member's."
+		} else {
+			embed.Description = fmt.Sprintf("🌐 FixEmbed now writes in %s on this server.", localeNames[discordgo.Locale(locale)])
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
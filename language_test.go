@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLanguageCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+	s.State.GuildAdd(&discordgo.Guild{ID: "1", PreferredLocale: string(discordgo.German)})
+
+	i := slashCommand("language", option("language", string(discordgo.French)))
+	i.Locale = discordgo.SpanishES
+	handleLanguageCommand(db, s, i)
+	if got := getGuildSettings(1).Locale; got != string(discordgo.French) {
+		t.Errorf("stored locale = %q, want fr", got)
+	}
+	if got := guildLocale(s, "1", getGuildSettings(1)); got != discordgo.French {
+		t.Errorf("guildLocale = %s, want the chosen language", got)
+	}
+	if got := interactionLocale(i); got != discordgo.French {
+		t.Errorf("interactionLocale = %s, want the chosen language over the member's", got)
+	}
+
+	handleLanguageCommand(db, s, slashCommand("language", option("language", LANGUAGE_AUTO)))
+	if got := guildLocale(s, "1", getGuildSettings(1)); got != discordgo.German {
+		t.Errorf("guildLocale = %s, want the server's preferred locale", got)
+	}
+	if got := interactionLocale(i); got != discordgo.SpanishES {
+		t.Errorf("interactionLocale = %s, want the member's locale", got)
+	}
+}
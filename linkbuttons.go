@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord allows 5 action rows of 5 buttons each
+const (
+	MAX_BUTTON_ROWS     = 5
+	MAX_BUTTONS_PER_ROW = 5
+)
+
+// linkButtonRows exposes the links of a repost as buttons instead of masked markdown links.
+// The fixed URLs are still posted bare so Discord embeds them, but their text can't be
+// disguised the way a masked link's display text can.
+func linkButtonRows(links []*repostLink) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	for idx, l := range links {
+		fixer := l.Fixer
+		if fixer == "" {
+			fixer = "FixEmbed"
+		}
+		openFixed, openOriginal := "Open on "+fixer, "Open original"
+		if len(links) > 1 {
+			openFixed = fmt.Sprintf("%d: %s", idx+1, openFixed)
+			openOriginal = fmt.Sprintf("%d: %s", idx+1, openOriginal)
+		}
+		buttons = append(buttons,
+			discordgo.Button{Label: openFixed, Style: discordgo.LinkButton, URL: "https://" + l.Fixed},
+			discordgo.Button{Label: openOriginal, Style: discordgo.LinkButton, URL: "https://" + l.Original},
+		)
+	}
+
+	// the last row is kept free for the repost's own buttons
+	var rows []discordgo.MessageComponent
+	for len(buttons) > 0 && len(rows) < MAX_BUTTON_ROWS-1 {
+		n := min(len(buttons), MAX_BUTTONS_PER_ROW)
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[:n]})
+		buttons = buttons[n:]
+	}
+	return rows
+}
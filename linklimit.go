@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Links fixed per message unless a guild configures otherwise
+const DEFAULT_LINK_LIMIT = 5
+
+var (
+	linkLimitMin float64 = 1
+	linkLimitMax float64 = 25
+)
+
+func handleLinkLimitCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	limit := DEFAULT_LINK_LIMIT
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "max" {
+			limit = int(opt.IntValue())
+		}
+	}
+
+	if limit > DEFAULT_LINK_LIMIT && !isPremium(i.GuildID) {
+		respondPremiumRequired(s, i, T(interactionLocale(i), "premium.link_limit", DEFAULT_LINK_LIMIT))
+		return
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := st.UpdateGuildColumn(gidInt, "link_limit", limit); err != nil {
+		logf(LOG_WARN, "Error updating link limit for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the link limit."
+		embed.Color = 0xff0000
+	} else {
+		updated := *getGuildSettings(gidInt)
+		updated.LinkLimit = limit
+		cacheGuildSettings(gidInt, &updated)
+		embed.Description = fmt.Sprintf("🔢 Up to %d link(s) per message will be fixed.", limit)
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
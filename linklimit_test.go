@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkLimit(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+
+	handleLinkLimitCommand(db, s, slashCommand("linklimit", option("max", float64(1))))
+	stored, _ := db.GuildSettings(1)
+	if stored.LinkLimit != 1 {
+		t.Fatalf("stored link limit = %d, want 1", stored.LinkLimit)
+	}
+
+	postMessage(t, db, s, stored, "https://x.com/a/status/1 https://x.com/b/status/2")
+	body := fake.body("POST /channels/20/messages")
+	if !strings.Contains(body, "fixupx.com/a/status/1") || strings.Contains(body, "fixupx.com/b/status/2") {
+		t.Errorf("repost %s should only fix the first link", body)
+	}
+}
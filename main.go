@@ -2,11 +2,14 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -20,9 +23,9 @@ import (
 // Version number
 const VERSION = "1.1.8"
 
-// Rate-limiting configuration
-const MESSAGE_LIMIT = 5
-const TIME_WINDOW = 1 * time.Second // Time window
+// Rate-limiting configuration (override with RATE_LIMIT_MESSAGES and RATE_LIMIT_WINDOW)
+var MESSAGE_LIMIT = 5
+var TIME_WINDOW = 1 * time.Second // Time window
 
 var (
 	// in-memory storage
@@ -43,9 +46,10 @@ var (
 	// Discord owner (single user allowed to run owner command)
 	ownerID string
 
-	statuses = []string{
-		"for Twitter links", "for Reddit links", "for Instagram links", "for Threads links", "for Pixiv links", "for Bluesky links",
-	}
+	// permission required (by default) to see the configuration commands
+	manageGuildPermission int64 = discordgo.PermissionManageGuild
+
+	statuses = defaultStatuses()
 )
 
 // GuildSettings mirrors the Python structure
@@ -53,21 +57,91 @@ type GuildSettings struct {
 	EnabledServices []string
 	MentionUsers    bool
 	DeleteOriginal  bool
+	LinkButtons     bool // post link buttons instead of a masked markdown link
+	DirectMedia     bool // rewrite to the fixers' direct-media hosts (raw media, no text card)
+	RichEmbeds      bool // build embeds from the fixers' APIs instead of relying on their OG tags
+	ReuploadMedia   bool // attach the post's media instead of relying on the fixer staying up
+	PreserveText    bool // repost the whole message with the link swapped in place
+	ProcessWebhooks bool // fix links in webhook messages (PluralKit proxies are always fixed)
+	Simulate        bool // report what would be fixed without posting or deleting anything
+	Leaderboard     bool // members can see who gets the most links fixed with /leaderboard
+	DMFallback      bool // DM the fix to its poster when FixEmbed can't post in the channel
+
+	TranslateLanguage string // language tweets are translated to; empty means off
+	LinkLimit         int    // links fixed per message; the rest are ignored
+	OptOutKeyword     string // messages starting with this word are skipped
+	NSFWMode          string // how links in NSFW channels are handled, one of the NSFW_MODE_* values
+	LogChannel        string // channel each fix is logged to; empty means off
+	Locale            string // language FixEmbed writes in; empty follows the server's and members' own
+	RepostTemplate    string // layout of reposts, see renderRepost; empty means DEFAULT_REPOST_TEMPLATE
+	EmbedColor        int    // accent color of FixEmbed's own embeds; 0 keeps the defaults
+	Attribution       string // ATTRIBUTION_NONE drops the "Sent by" line; see attributionMode
+
+	MastodonInstances []string // instance domains treated as Mastodon links
+
+	Frontends map[string]string // service name -> chosen fixer frontend, when not the default
 }
 
+// defaultServices lists the registry's services that every guild can use; they're all enabled by default.
 func defaultServices() []string {
-	return []string{"Twitter", "Instagram", "Reddit", "Threads", "Pixiv", "Bluesky"}
+	names := make([]string, 0, len(services))
+	for _, svc := range services {
+		if svc.Available == nil {
+			names = append(names, svc.Name)
+		}
+	}
+	return names
+}
+
+// availableServices lists the services a guild can toggle, e.g. Mastodon only shows up once
+// the guild has registered at least one instance.
+func availableServices(settings *GuildSettings) []string {
+	names := make([]string, 0, len(services))
+	for _, svc := range services {
+		if svc.Available == nil || svc.Available(settings) {
+			names = append(names, svc.Name)
+		}
+	}
+	return names
+}
+
+// Separates the status texts in STATUSES, e.g. "for broken embeds|in {guilds} servers"
+const STATUS_SEPARATOR = "|"
+
+func defaultStatuses() []string {
+	texts := make([]string, 0, len(services))
+	for _, svc := range services {
+		if svc.Available == nil {
+			texts = append(texts, fmt.Sprintf("for %s links", svc.label()))
+		}
+	}
+	return texts
+}
+
+func defaultGuildSettings() *GuildSettings {
+	return &GuildSettings{EnabledServices: defaultServices(), MentionUsers: true, DeleteOriginal: true, DMFallback: true, LinkLimit: DEFAULT_LINK_LIMIT, OptOutKeyword: DEFAULT_OPT_OUT_KEYWORD, NSFWMode: NSFW_MODE_FIX}
+}
+
+func rateLimitedSend(s *discordgo.Session, channelID string, content string) (*discordgo.Message, error) {
+	return rateLimitedSendComplex(s, channelID, &discordgo.MessageSend{Content: content})
 }
 
-func rateLimitedSend(s *discordgo.Session, channelID string, content string) error {
+func rateLimitedSendComplex(s *discordgo.Session, channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	// nothing pings unless the caller explicitly allows it
+	if data.AllowedMentions == nil {
+		data.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	}
 	// Simple sliding-window rate limiter matching Python behaviour
 	for {
+		runtimeConfig.RLock()
+		limit, window := MESSAGE_LIMIT, TIME_WINDOW
+		runtimeConfig.RUnlock()
 		tsMutex.Lock()
 		now := time.Now()
 		// remove old timestamps
 		clean := 0
 		for i, t := range times {
-			if now.Sub(t) >= TIME_WINDOW {
+			if now.Sub(t) >= window {
 				clean = i + 1
 			} else {
 				break
@@ -76,11 +150,10 @@ func rateLimitedSend(s *discordgo.Session, channelID string, content string) err
 		if clean > 0 {
 			times = times[clean:]
 		}
-		if len(times) < MESSAGE_LIMIT {
+		if len(times) < limit {
 			times = append(times, now)
 			tsMutex.Unlock()
-			_, err := s.ChannelMessageSend(channelID, content)
-			return err
+			return s.ChannelMessageSendComplex(channelID, data)
 		}
 		tsMutex.Unlock()
 		time.Sleep(100 * time.Millisecond)
@@ -104,49 +177,92 @@ func initDB(path string) (*sql.DB, error) {
 
 	// Make sure the database is responsive
 	db.SetMaxOpenConns(1)
+	// other instances may share the file; wait out their writes instead of failing
+	_, _ = db.Exec(`PRAGMA busy_timeout = 5000`)
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS channel_states (channel_id INTEGER PRIMARY KEY, state BOOLEAN)`)
-	if err != nil {
+	if err := migrate(db); err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS guild_settings (guild_id INTEGER PRIMARY KEY, enabled_services TEXT, mention_users BOOLEAN, delete_original BOOLEAN DEFAULT 1)`)
-	if err != nil {
-		return nil, err
-	}
+	return db, nil
+}
 
-	// Add columns if missing (sqlite will error - ignore duplicate column)
-	_, _ = db.Exec(`ALTER TABLE guild_settings ADD COLUMN mention_users BOOLEAN DEFAULT 1`)
-	_, _ = db.Exec(`ALTER TABLE guild_settings ADD COLUMN delete_original BOOLEAN DEFAULT 1`)
+// channel types FixEmbed can be activated in; forum posts are threads and inherit their forum's state
+var trackedChannelTypes = []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum}
 
-	return db, nil
+// isTrackedChannel reports whether ch is a text, announcement or forum channel.
+func isTrackedChannel(ch *discordgo.Channel) bool {
+	return slices.Contains(trackedChannelTypes, ch.Type)
 }
 
-func loadChannelStates(db *sql.DB, dg *discordgo.Session) error {
-	rows, err := db.Query("SELECT channel_id, state FROM channel_states")
+// channel types /activate and /deactivate accept; threads get an explicit override of their parent,
+// categories apply to every channel in them
+var activatableChannelTypes = append(slices.Clone(trackedChannelTypes), discordgo.ChannelTypeGuildCategory,
+	discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread, discordgo.ChannelTypeGuildNewsThread)
+
+// channelState returns whether FixEmbed is active in a channel. Threads without a state of
+// their own inherit their parent's; ok is false when neither has been set.
+func channelState(s *discordgo.Session, channelID string) (enabled, ok bool) {
+	cidInt, _ := discordIDStringToInt64(channelID)
+	channelStates.RLock()
+	enabled, ok = channelStates.m[cidInt]
+	channelStates.RUnlock()
+	if ok {
+		return enabled, true
+	}
+	ch, err := s.State.Channel(channelID)
 	if err != nil {
-		return err
+		if ch, err = s.Channel(channelID); err != nil {
+			return false, false
+		}
+	}
+	if ch.IsThread() && ch.ParentID != "" {
+		return channelState(s, ch.ParentID)
+	}
+	return false, false
+}
+
+func (st *sqliteStore) LoadChannelStates() (map[int64]bool, error) {
+	rows, err := st.db.Query("SELECT channel_id, state FROM channel_states")
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
-	channelStates.Lock()
+	states := make(map[int64]bool)
 	for rows.Next() {
 		var channelID int64
 		var state int
 		if err := rows.Scan(&channelID, &state); err != nil {
 			continue
 		}
-		channelStates.m[channelID] = state != 0
+		states[channelID] = state != 0
+	}
+	return states, nil
+}
+
+func loadChannelStates(st Store, dg *discordgo.Session) error {
+	states, err := st.LoadChannelStates()
+	if err != nil {
+		return err
 	}
+	// start over, so rows deleted since the last load (e.g. by another instance) go away
+	channelStates.Lock()
+	channelStates.m = states
 	channelStates.Unlock()
 
+	defaultChannelStates(dg)
+	return nil
+}
+
+// defaultChannelStates activates the channels that don't have a state yet.
+func defaultChannelStates(dg *discordgo.Session) {
 	// Ensure any channels we haven't seen default to true.
 	// Use current guilds in session state (populated after ready)
 	if dg != nil && dg.State != nil {
 		channelStates.Lock()
 		for _, g := range dg.State.Guilds {
 			for _, ch := range g.Channels {
-				// only text channels (type 0)
-				if ch.Type == discordgo.ChannelTypeGuildText {
+				if isTrackedChannel(ch) {
 					cidInt, _ := discordIDStringToInt64(ch.ID)
 					if _, ok := channelStates.m[cidInt]; !ok {
 						channelStates.m[cidInt] = true
@@ -156,117 +272,214 @@ func loadChannelStates(db *sql.DB, dg *discordgo.Session) error {
 		}
 		channelStates.Unlock()
 	}
+}
+
+// columns read by scanGuildSettings, in scan order
+const guildSettingsColumns = "enabled_services, mention_users, delete_original, link_buttons, mastodon_instances, frontends, direct_media, translate_language, rich_embeds, reupload_media, preserve_text, link_limit, optout_keyword, process_webhooks, nsfw_mode, log_channel_id, simulate, leaderboard, locale, repost_template, embed_color, attribution, dm_fallback"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGuildSettings parses a guild_settings row selected with guildSettingsColumns.
+// Any extra destinations are scanned before the settings columns.
+func scanGuildSettings(row rowScanner, extra ...interface{}) (*GuildSettings, error) {
+	var enabledServices sql.NullString
+	var mentionUsers sql.NullBool
+	var deleteOriginal sql.NullBool
+	var linkButtons sql.NullBool
+	var mastodonInstances sql.NullString
+	var frontends sql.NullString
+	var directMedia sql.NullBool
+	var translateLanguage sql.NullString
+	var richEmbeds sql.NullBool
+	var reuploadMedia sql.NullBool
+	var preserveText sql.NullBool
+	var linkLimit sql.NullInt64
+	var optOutKeyword sql.NullString
+	var processWebhooks sql.NullBool
+	var nsfwMode sql.NullString
+	var logChannelID sql.NullInt64
+	var simulate sql.NullBool
+	var leaderboard sql.NullBool
+	var locale sql.NullString
+	var repostTemplate sql.NullString
+	var embedColor sql.NullInt64
+	var attribution sql.NullString
+	var dmFallback sql.NullBool
+	dest := append(extra, &enabledServices, &mentionUsers, &deleteOriginal, &linkButtons, &mastodonInstances, &frontends, &directMedia, &translateLanguage, &richEmbeds, &reuploadMedia, &preserveText, &linkLimit, &optOutKeyword, &processWebhooks, &nsfwMode, &logChannelID, &simulate, &leaderboard, &locale, &repostTemplate, &embedColor, &attribution, &dmFallback)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
 
-	return nil
+	settings := defaultGuildSettings()
+	if svcList := parseStoredList(enabledServices.String); len(svcList) > 0 {
+		settings.EnabledServices = svcList
+	}
+	if mentionUsers.Valid {
+		settings.MentionUsers = mentionUsers.Bool
+	}
+	if deleteOriginal.Valid {
+		settings.DeleteOriginal = deleteOriginal.Bool
+	}
+	if linkButtons.Valid {
+		settings.LinkButtons = linkButtons.Bool
+	}
+	settings.MastodonInstances = parseStoredList(mastodonInstances.String)
+	settings.Frontends = parseFrontends(frontends.String)
+	settings.DirectMedia = directMedia.Valid && directMedia.Bool
+	settings.TranslateLanguage = translateLanguage.String
+	settings.RichEmbeds = richEmbeds.Valid && richEmbeds.Bool
+	settings.ReuploadMedia = reuploadMedia.Valid && reuploadMedia.Bool
+	settings.PreserveText = preserveText.Valid && preserveText.Bool
+	if linkLimit.Valid && linkLimit.Int64 > 0 {
+		settings.LinkLimit = int(linkLimit.Int64)
+	}
+	if optOutKeyword.String != "" {
+		settings.OptOutKeyword = optOutKeyword.String
+	}
+	settings.ProcessWebhooks = processWebhooks.Valid && processWebhooks.Bool
+	if nsfwMode.String != "" {
+		settings.NSFWMode = nsfwMode.String
+	}
+	if logChannelID.Valid && logChannelID.Int64 != 0 {
+		settings.LogChannel = fmt.Sprint(logChannelID.Int64)
+	}
+	settings.Simulate = simulate.Valid && simulate.Bool
+	settings.Leaderboard = leaderboard.Valid && leaderboard.Bool
+	settings.Locale = locale.String
+	settings.RepostTemplate = repostTemplate.String
+	settings.EmbedColor = int(embedColor.Int64)
+	settings.Attribution = attribution.String
+	settings.DMFallback = !dmFallback.Valid || dmFallback.Bool
+	return settings, nil
+}
+
+// parseStoredList parses a list stored as a Python-like repr: ['A', 'B']
+func parseStoredList(stored string) []string {
+	var list []string
+	// stored as Python repr(list) in original; attempt simple parse: remove brackets and quotes
+	s := strings.TrimSpace(stored)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	parts := strings.Split(s, ",")
+	for _, p := range parts {
+		q := strings.TrimSpace(p)
+		q = strings.Trim(q, `"'`)
+		if q != "" {
+			list = append(list, q)
+		}
+	}
+	return list
 }
 
-func loadSettings(db *sql.DB) error {
-	rows, err := db.Query("SELECT guild_id, enabled_services, mention_users, delete_original FROM guild_settings")
+// formatStoredList is the inverse of parseStoredList
+func formatStoredList(list []string) string {
+	parts := make([]string, 0, len(list))
+	for _, s := range list {
+		parts = append(parts, fmt.Sprintf("%q", s))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (st *sqliteStore) LoadGuildSettings() (map[int64]*GuildSettings, error) {
+	rows, err := st.db.Query("SELECT guild_id, " + guildSettingsColumns + " FROM guild_settings")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	botSettings.Lock()
-	defer botSettings.Unlock()
-
+	loaded := make(map[int64]*GuildSettings)
 	for rows.Next() {
 		var guildID int64
-		var enabledServices sql.NullString
-		var mentionUsers sql.NullBool
-		var deleteOriginal sql.NullBool
-		if err := rows.Scan(&guildID, &enabledServices, &mentionUsers, &deleteOriginal); err != nil {
+		settings, err := scanGuildSettings(rows, &guildID)
+		if err != nil {
 			continue
 		}
-		var svcList []string
-		if enabledServices.Valid && enabledServices.String != "" {
-			// stored as Python repr(list) in original; attempt simple parse: remove brackets and quotes
-			s := enabledServices.String
-			s = strings.TrimSpace(s)
-			s = strings.TrimPrefix(s, "[")
-			s = strings.TrimSuffix(s, "]")
-			parts := strings.Split(s, ",")
-			for _, p := range parts {
-				q := strings.TrimSpace(p)
-				q = strings.Trim(q, `"'`)
-				if q != "" {
-					svcList = append(svcList, q)
-				}
-			}
-		}
-		if len(svcList) == 0 {
-			svcList = defaultServices()
-		}
-		mention := true
-		if mentionUsers.Valid {
-			mention = mentionUsers.Bool
-		}
-		deleteO := true
-		if deleteOriginal.Valid {
-			deleteO = deleteOriginal.Bool
-		}
-		botSettings.m[guildID] = &GuildSettings{
-			EnabledServices: svcList,
-			MentionUsers:    mention,
-			DeleteOriginal:  deleteO,
-		}
+		loaded[guildID] = settings
 	}
+	return loaded, nil
+}
 
+func loadSettings(st Store) error {
+	loaded, err := st.LoadGuildSettings()
+	if err != nil {
+		return err
+	}
+	botSettings.Lock()
+	botSettings.m = loaded
+	botSettings.Unlock()
 	return nil
 }
-func getGuildSettingsFromDB(db *sql.DB, guildID int64) (*GuildSettings, error) {
+
+func (st *sqliteStore) GuildSettings(guildID int64) (*GuildSettings, error) {
 	// Try to read a single guild's settings from DB and parse them into GuildSettings.
-	row := db.QueryRow("SELECT enabled_services, mention_users, delete_original FROM guild_settings WHERE guild_id = ?", guildID)
-	var enabledServices sql.NullString
-	var mentionUsers sql.NullBool
-	var deleteOriginal sql.NullBool
-	if err := row.Scan(&enabledServices, &mentionUsers, &deleteOriginal); err != nil {
+	row := st.db.QueryRow("SELECT "+guildSettingsColumns+" FROM guild_settings WHERE guild_id = ?", guildID)
+	settings, err := scanGuildSettings(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	return settings, nil
+}
 
-	var svcList []string
-	if enabledServices.Valid && enabledServices.String != "" {
-		s := enabledServices.String
-		s = strings.TrimSpace(s)
-		s = strings.TrimPrefix(s, "[")
-		s = strings.TrimSuffix(s, "]")
-		parts := strings.Split(s, ",")
-		for _, p := range parts {
-			q := strings.TrimSpace(p)
-			q = strings.Trim(q, `"'`)
-			if q != "" {
-				svcList = append(svcList, q)
-			}
-		}
+// getGuildSettings returns the cached settings for a guild, falling back to the DB and then defaults.
+func getGuildSettings(guildID int64) *GuildSettings {
+	botSettings.RLock()
+	settings := botSettings.m[guildID]
+	botSettings.RUnlock()
+	if settings != nil {
+		return settings
 	}
-	if len(svcList) == 0 {
-		svcList = defaultServices()
+	if gs, err := store.GuildSettings(guildID); err == nil && gs != nil {
+		return gs
 	}
+	return defaultGuildSettings()
+}
 
-	mention := true
-	if mentionUsers.Valid {
-		mention = mentionUsers.Bool
-	}
-	deleteO := true
-	if deleteOriginal.Valid {
-		deleteO = deleteOriginal.Bool
+func (st *sqliteStore) UpdateChannelState(channelID int64, state bool) error {
+	// retry on locked
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		_, err := st.db.Exec("INSERT OR REPLACE INTO channel_states (channel_id, state) VALUES (?, ?)", channelID, boolToInt(state))
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
 	}
-
-	return &GuildSettings{
-		EnabledServices: svcList,
-		MentionUsers:    mention,
-		DeleteOriginal:  deleteO,
-	}, nil
+	return lastErr
 }
 
-func updateChannelState(db *sql.DB, channelID int64, state bool) error {
-	// retry on locked
+// updateChannelStates stores the same state for many channels in one transaction.
+func (st *sqliteStore) UpdateChannelStates(channelIDs []int64, state bool) error {
 	var lastErr error
 	for i := 0; i < 5; i++ {
-		_, err := db.Exec("INSERT OR REPLACE INTO channel_states (channel_id, state) VALUES (?, ?)", channelID, boolToInt(state))
+		err := func() error {
+			tx, err := st.db.Begin()
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			stmt, err := tx.Prepare("INSERT OR REPLACE INTO channel_states (channel_id, state) VALUES (?, ?)")
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+			for _, channelID := range channelIDs {
+				if _, err := stmt.Exec(channelID, boolToInt(state)); err != nil {
+					return err
+				}
+			}
+			return tx.Commit()
+		}()
 		if err == nil {
 			return nil
 		}
@@ -280,18 +493,16 @@ func updateChannelState(db *sql.DB, channelID int64, state bool) error {
 	return lastErr
 }
 
-func updateSetting(db *sql.DB, guildID int64, enabledServices []string, mentionUsers bool, deleteOriginal bool) error {
+func (st *sqliteStore) UpdateSetting(guildID int64, enabledServices []string, mentionUsers bool, deleteOriginal bool) error {
 	// store enabledServices as a simple CSV-ish Python-like repr: ['A','B']
 	// We'll store as "['A','B']" to remain close to Python repr used previously.
-	parts := make([]string, 0, len(enabledServices))
-	for _, s := range enabledServices {
-		parts = append(parts, fmt.Sprintf("%q", s))
-	}
-	stored := "[" + strings.Join(parts, ", ") + "]"
+	stored := formatStoredList(enabledServices)
 
 	var lastErr error
 	for i := 0; i < 5; i++ {
-		_, err := db.Exec("INSERT OR REPLACE INTO guild_settings (guild_id, enabled_services, mention_users, delete_original) VALUES (?, ?, ?, ?)",
+		// upsert so columns managed elsewhere (e.g. the digest channel) survive
+		_, err := st.db.Exec(`INSERT INTO guild_settings (guild_id, enabled_services, mention_users, delete_original) VALUES (?, ?, ?, ?)
+			ON CONFLICT(guild_id) DO UPDATE SET enabled_services = excluded.enabled_services, mention_users = excluded.mention_users, delete_original = excluded.delete_original`,
 			guildID, stored, mentionUsers, deleteOriginal)
 		if err == nil {
 			return nil
@@ -306,6 +517,25 @@ func updateSetting(db *sql.DB, guildID int64, enabledServices []string, mentionU
 	return lastErr
 }
 
+// UpdateGuildColumn upserts a single guild_settings column, leaving the others untouched.
+func (st *sqliteStore) UpdateGuildColumn(guildID int64, column string, value interface{}) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		_, err := st.db.Exec(fmt.Sprintf("INSERT INTO guild_settings (guild_id, %[1]s) VALUES (?, ?) ON CONFLICT(guild_id) DO UPDATE SET %[1]s = excluded.%[1]s", column),
+			guildID, value)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -314,9 +544,21 @@ func boolToInt(b bool) int {
 }
 
 // Interaction (slash command) handling
-func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.InteractionCreate) {
+func onInteractionCreate(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	// Only handle application commands and component interactions
+	// interactions carry the guild's current entitlements
+	for _, e := range i.Entitlements {
+		trackEntitlement(e, true)
+	}
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		handleAutocomplete(s, i)
+		return
+	}
 	if i.Type == discordgo.InteractionApplicationCommand {
+		if isConfigurationCommand(i.ApplicationCommandData().Name) && !canConfigure(i) {
+			respondNotAllowed(s, i)
+			return
+		}
 		switch i.ApplicationCommandData().Name {
 		case "activate":
 			// optional channel option
@@ -327,17 +569,16 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			} else {
 				channelID = i.ChannelID
 			}
-			// mark as active
-			cidInt, _ := discordIDStringToInt64(channelID)
-			channelStates.Lock()
-			channelStates.m[cidInt] = true
-			channelStates.Unlock()
-			_ = updateChannelState(db, cidInt, true)
+			// mark as active (along with everything in it, for a category)
+			description := T(interactionLocale(i), "activate.done", "<#"+channelID+">")
+			if n := setChannelState(st, s, i.GuildID, channelID, true); n > 0 {
+				description = T(interactionLocale(i), "activate.done_children", "<#"+channelID+">", n)
+			}
 
 			embed := &discordgo.MessageEmbed{
 				Title:       s.State.User.Username,
-				Description: fmt.Sprintf("✅ Activated for <#%s>!", channelID),
-				Color:       0x78b159,
+				Description: description,
+				Color:       accentColor(i.GuildID, 0x78b159),
 			}
 			createFooter(embed, s)
 			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -346,6 +587,14 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 					Embeds: []*discordgo.MessageEmbed{embed},
 				},
 			})
+		case "status":
+			handleStatusCommand(st, s, i)
+		case "setup":
+			handleSetupCommand(st, s, i)
+		case "activate-all":
+			handleActivateAllCommand(st, s, i, true)
+		case "deactivate-all":
+			handleActivateAllCommand(st, s, i, false)
 		case "deactivate":
 			var channelID string
 			opts := i.ApplicationCommandData().Options
@@ -354,16 +603,15 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			} else {
 				channelID = i.ChannelID
 			}
-			cidInt, _ := discordIDStringToInt64(channelID)
-			channelStates.Lock()
-			channelStates.m[cidInt] = false
-			channelStates.Unlock()
-			_ = updateChannelState(db, cidInt, false)
+			description := T(interactionLocale(i), "deactivate.done", "<#"+channelID+">")
+			if n := setChannelState(st, s, i.GuildID, channelID, false); n > 0 {
+				description = T(interactionLocale(i), "deactivate.done_children", "<#"+channelID+">", n)
+			}
 
 			embed := &discordgo.MessageEmbed{
 				Title:       s.State.User.Username,
-				Description: fmt.Sprintf("❌ Deactivated for <#%s>!", channelID),
-				Color:       0xff0000, // red
+				Description: description,
+				Color:       accentColor(i.GuildID, 0x78b159),
 			}
 			createFooter(embed, s)
 			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -373,29 +621,26 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 				},
 			})
 		case "about":
+			locale := interactionLocale(i)
 			embed := &discordgo.MessageEmbed{
-				Title:       "About",
-				Description: "This bot fixes the lack of embed support in Discord.",
+				Title:       T(locale, "about.title"),
+				Description: T(locale, "about.description"),
 				Color:       0x7289DA,
 			}
 			embed.Fields = []*discordgo.MessageEmbedField{
 				{
-					Name: "🎉 Quick Links",
-					Value: "- [Invite FixEmbed](https://discord.com/oauth2/authorize?client_id=1360722454678605914)\n" +
-						"- [Star our Source Code on GitHub](https://github.com/ld3z/fixembed-go)",
+					Name: T(locale, "about.links"),
+					Value: "- [" + T(locale, "about.invite") + "](" + inviteURL(s) + ")\n" +
+						"- [" + T(locale, "about.source") + "](https://github.com/ld3z/fixembed-go)",
 					Inline: false,
 				},
 				{
-					Name: "📜 Credits",
-					Value: "- [FxTwitter](https://github.com/FixTweet/FxTwitter), created by FixTweet\n" +
-						"- [InstaFix](https://github.com/Wikidepia/InstaFix), created by Wikidepia\n" +
-						"- [vxReddit](https://github.com/dylanpdx/vxReddit), created by dylanpdx\n" +
-						"- [fixthreads](https://github.com/milanmdev/fixthreads), created by milanmdev\n" +
-						"- [phixiv](https://github.com/thelaao/phixiv), created by thelaao\n" +
-						"- [VixBluesky](https://github.com/Rapougnac/VixBluesky), created by Rapougnac",
+					Name:   T(locale, "about.credits"),
+					Value:  aboutCredits(locale),
 					Inline: false,
 				},
 			}
+			embed.Fields = append(embed.Fields, runtimeFields(locale, s)...)
 			createFooter(embed, s)
 			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -403,6 +648,60 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 					Embeds: []*discordgo.MessageEmbed{embed},
 				},
 			})
+		case "help":
+			handleHelpCommand(s, i)
+		case "ping":
+			handlePingCommand(s, i)
+		case "vote":
+			handleVoteCommand(s, i)
+		case "fix":
+			handleFixCommand(st, s, i)
+		case "test":
+			handleTestCommand(st, s, i)
+		case "stats":
+			handleStatsCommand(st, s, i)
+		case "leaderboard":
+			handleLeaderboardCommand(st, s, i)
+		case "reset":
+			handleResetCommand(s, i)
+		case "language":
+			handleLanguageCommand(st, s, i)
+		case "service":
+			handleServiceCommand(st, s, i)
+		case "mention":
+			handleMentionCommand(st, s, i)
+		case "attribution":
+			handleAttributionCommand(st, s, i)
+		case "delivery":
+			handleDeliveryCommand(st, s, i)
+		case "Fix Links":
+			handleFixLinksCommand(st, s, i)
+		case "retention":
+			handleRetentionCommand(st, s, i)
+		case "digest":
+			handleDigestCommand(st, s, i)
+		case "mastodon":
+			handleMastodonCommand(st, s, i)
+		case "translate":
+			handleTranslateCommand(st, s, i)
+		case "linklimit":
+			handleLinkLimitCommand(st, s, i)
+		case "nofix":
+			handleNofixCommand(st, s, i)
+		case "optout":
+			handleOptOutCommand(st, s, i, true)
+		case "optin":
+			handleOptOutCommand(st, s, i, false)
+		case "pingme":
+			handlePingMeCommand(st, s, i)
+		case "ignore":
+			handleIgnoreCommand(st, s, i)
+		case "nsfw":
+			handleNSFWCommand(st, s, i)
+		case "logchannel":
+			handleLogChannelCommand(st, s, i)
+		case "channelsettings":
+			handleChannelSettingsCommand(st, s, i)
 		case "owner":
 			// Owner-only command: show detailed guild info (rich embeds)
 			userID := ""
@@ -420,6 +719,8 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 						Flags:   1 << 6, // ephemeral
 					},
 				})
+			} else if opts := i.ApplicationCommandData().Options; len(opts) > 0 && opts[0].Name == "stats" {
+				handleOwnerStats(st, s, i)
 			} else {
 				// Build up to 10 embeds with useful guild information (name, id, members, owner, icon)
 				embeds := make([]*discordgo.MessageEmbed, 0, 10)
@@ -539,28 +840,31 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 				})
 			}
 		case "settings":
+			if opts := i.ApplicationCommandData().Options; len(opts) > 0 {
+				switch opts[0].Name {
+				case "history":
+					handleSettingsHistory(st, s, i)
+					return
+				case "export":
+					handleSettingsExport(st, s, i)
+					return
+				case "import":
+					handleSettingsImport(st, s, i)
+					return
+				}
+			}
 			// Provide a simple text-based settings reply summarizing current settings.
 			guildID := i.GuildID
 			var settings *GuildSettings
 			if guildID == "" {
-				settings = &GuildSettings{EnabledServices: defaultServices(), MentionUsers: true, DeleteOriginal: true}
+				settings = defaultGuildSettings()
 			} else {
 				gidInt, _ := discordIDStringToInt64(guildID)
-				// First try the in-memory cache
-				botSettings.RLock()
-				settings = botSettings.m[gidInt]
-				botSettings.RUnlock()
-				// If not present, fallback to reading directly from DB (handles races / missed loads)
-				if settings == nil {
-					if gs, err := getGuildSettingsFromDB(db, gidInt); err == nil && gs != nil {
-						settings = gs
-					} else {
-						settings = &GuildSettings{EnabledServices: defaultServices(), MentionUsers: true, DeleteOriginal: true}
-					}
-				}
+				// in-memory cache first, then the DB (handles races / missed loads)
+				settings = getGuildSettings(gidInt)
 			}
 			serviceStatus := ""
-			for _, sname := range defaultServices() {
+			for _, sname := range availableServices(settings) {
 				status := "🔴"
 				for _, enabled := range settings.EnabledServices {
 					if enabled == sname {
@@ -570,84 +874,122 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 				}
 				serviceStatus += fmt.Sprintf("%s %s\n", status, sname)
 			}
+			locale := interactionLocale(i)
 			embed := &discordgo.MessageEmbed{
-				Title:       "Settings",
-				Description: "Configure FixEmbed's settings",
-				Color:       0x5865F2, // blurple-like
+				Title:       T(locale, "settings.title"),
+				Description: T(locale, "settings.description"),
+				Color:       accentColor(guildID, 0x5865F2),
 				Fields: []*discordgo.MessageEmbedField{
 					{
-						Name:  "Service Settings",
+						Name:  T(locale, "settings.services"),
 						Value: serviceStatus,
 					},
 					{
-						Name:  "Mention Users",
+						Name:  T(locale, "settings.mention_users"),
 						Value: fmt.Sprintf("%t", settings.MentionUsers),
 					},
 					{
-						Name:  "Delete Original",
+						Name:  T(locale, "settings.attribution"),
+						Value: attributionMode(settings),
+					},
+					{
+						Name:  T(locale, "settings.delete_original"),
 						Value: fmt.Sprintf("%t", settings.DeleteOriginal),
 					},
+					{
+						Name:  T(locale, "settings.link_buttons"),
+						Value: fmt.Sprintf("%t", settings.LinkButtons),
+					},
+					{
+						Name:  T(locale, "settings.direct_media"),
+						Value: fmt.Sprintf("%t", settings.DirectMedia),
+					},
+					{
+						Name:  T(locale, "settings.rich_embeds"),
+						Value: fmt.Sprintf("%t", settings.RichEmbeds),
+					},
+					{
+						Name:  T(locale, "settings.reupload_media"),
+						Value: fmt.Sprintf("%t", settings.ReuploadMedia),
+					},
+					{
+						Name:  T(locale, "settings.preserve_text"),
+						Value: fmt.Sprintf("%t", settings.PreserveText),
+					},
+					{
+						Name:  T(locale, "settings.link_limit"),
+						Value: T(locale, "settings.link_limit_value", settings.LinkLimit),
+					},
+					{
+						Name: T(locale, "settings.log_channel"),
+						Value: func() string {
+							if settings.LogChannel == "" {
+								return T(locale, "settings.off")
+							}
+							return "<#" + settings.LogChannel + ">"
+						}(),
+					},
+					{
+						Name:  T(locale, "settings.opt_out_keyword"),
+						Value: "`" + settings.OptOutKeyword + "`",
+					},
+					{
+						Name:  T(locale, "settings.nsfw"),
+						Value: settings.NSFWMode,
+					},
+					{
+						Name: T(locale, "settings.template"),
+						Value: func() string {
+							if settings.RepostTemplate == "" {
+								return "`" + DEFAULT_REPOST_TEMPLATE + "`"
+							}
+							return "`" + settings.RepostTemplate + "`"
+						}(),
+					},
+					{
+						Name: T(locale, "settings.embed_color"),
+						Value: func() string {
+							if settings.EmbedColor == 0 {
+								return T(locale, "settings.default")
+							}
+							return formatColor(settings.EmbedColor)
+						}(),
+					},
+					{
+						Name: T(locale, "settings.language"),
+						Value: func() string {
+							if settings.Locale == "" {
+								return T(locale, "settings.automatic")
+							}
+							return localeNames[discordgo.Locale(settings.Locale)]
+						}(),
+					},
+					{
+						Name:  T(locale, "settings.webhooks"),
+						Value: fmt.Sprintf("%t", settings.ProcessWebhooks),
+					},
+					{
+						Name:  T(locale, "settings.simulate"),
+						Value: fmt.Sprintf("%t", settings.Simulate),
+					},
+					{
+						Name:  T(locale, "settings.leaderboard"),
+						Value: fmt.Sprintf("%t", settings.Leaderboard),
+					},
+					{
+						Name:  T(locale, "settings.dm_fallback"),
+						Value: fmt.Sprintf("%t", settings.DMFallback),
+					},
+					{
+						Name:  T(locale, "settings.frontends"),
+						Value: describeFrontends(settings),
+					},
 				},
 			}
 			createFooter(embed, s)
 
 			// Build the interactive settings select (mirrors Python SettingsDropdown)
-			// Compute current state indicators for emojis
-			activated := true
-			if i.GuildID != "" && s.State != nil {
-				for _, g := range s.State.Guilds {
-					if g.ID == i.GuildID {
-						for _, ch := range g.Channels {
-							if ch.Type == discordgo.ChannelTypeGuildText {
-								cidInt, _ := discordIDStringToInt64(ch.ID)
-								channelStates.RLock()
-								v, ok := channelStates.m[cidInt]
-								channelStates.RUnlock()
-								if !ok || !v {
-									activated = false
-									break
-								}
-							}
-						}
-						break
-					}
-				}
-			}
-			mentionUsersVal := settings.MentionUsers
-			deleteOriginalVal := settings.DeleteOriginal
-
-			settingsOptions := []discordgo.SelectMenuOption{
-				{Label: "FixEmbed", Value: "FixEmbed", Description: "Activate or deactivate the bot in all channels", Emoji: &discordgo.ComponentEmoji{Name: func() string {
-					if activated {
-						return "🟢"
-					}
-					return "🔴"
-				}()}},
-				{Label: "Mention Users", Value: "Mention Users", Description: "Toggle mentioning users in messages", Emoji: &discordgo.ComponentEmoji{Name: func() string {
-					if mentionUsersVal {
-						return "🔔"
-					}
-					return "🔕"
-				}()}},
-				{Label: "Delivery Method", Value: "Delivery Method", Description: "Toggle original message deletion", Emoji: &discordgo.ComponentEmoji{Name: func() string {
-					if deleteOriginalVal {
-						return "📬"
-					}
-					return "📪"
-				}()}},
-				{Label: "Service Settings", Value: "Service Settings", Description: "Configure which services are activated", Emoji: &discordgo.ComponentEmoji{Name: "⚙️"}},
-				{Label: "Debug", Value: "Debug", Description: "Show current debug information", Emoji: &discordgo.ComponentEmoji{Name: "🐞"}},
-			}
-			minVal := new(int)
-			*minVal = 1
-			maxVal := 1
-			settingsSM := &discordgo.SelectMenu{
-				CustomID:    "settings_select",
-				Placeholder: "Choose an option...",
-				MinValues:   minVal,
-				MaxValues:   maxVal,
-				Options:     settingsOptions,
-			}
+			settingsSM := settingsSelectMenu(locale, settings, guildActivated(s, i.GuildID))
 			components := []discordgo.MessageComponent{
 				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{settingsSM}},
 			}
@@ -662,6 +1004,19 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			})
 		}
 	}
+	if i.Type == discordgo.InteractionModalSubmit {
+		if !canConfigure(i) {
+			respondNotAllowed(s, i)
+			return
+		}
+		switch i.ModalSubmitData().CustomID {
+		case "repost_template":
+			handleRepostTemplateModal(st, s, i)
+		case "embed_color":
+			handleEmbedColorModal(st, s, i)
+		}
+		return
+	}
 	// Handle component interactions (select menus / buttons) similar to the Python version
 	if i.Type == discordgo.InteractionMessageComponent {
 		data := i.MessageComponentData()
@@ -672,6 +1027,37 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			gidInt, _ = discordIDStringToInt64(guildID)
 		}
 
+		// the settings panels act for whoever clicks them, so check the clicker rather than whoever opened the panel
+		if !publicComponents[custom] && !canConfigure(i) {
+			respondNotAllowed(s, i)
+			return
+		}
+
+		if premiumComponents[custom] && !isPremium(guildID) {
+			respondPremiumRequired(s, i, T(interactionLocale(i), "premium.webhooks"))
+			return
+		}
+
+		if strings.HasPrefix(custom, "setup_") {
+			handleSetupComponent(st, s, i, custom)
+			return
+		}
+
+		if strings.HasPrefix(custom, "reset_") {
+			handleResetComponent(st, s, i, custom)
+			return
+		}
+
+		if page, ok := strings.CutPrefix(custom, "status_page:"); ok {
+			handleStatusPage(st, s, i, page)
+			return
+		}
+
+		if t := settingsToggle("", custom); t != nil {
+			handleSettingsToggle(st, s, i, t)
+			return
+		}
+
 		switch custom {
 		case "settings_select":
 			choice := ""
@@ -681,24 +1067,14 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			switch choice {
 			case "Service Settings":
 				// Build services multi-select reflecting current settings
-				var current []string
+				gs := defaultGuildSettings()
 				if gidInt != 0 {
-					botSettings.RLock()
-					if gs, ok := botSettings.m[gidInt]; ok && gs != nil {
-						current = gs.EnabledServices
-					}
-					botSettings.RUnlock()
-					if current == nil {
-						if gs2, _ := getGuildSettingsFromDB(db, gidInt); gs2 != nil {
-							current = gs2.EnabledServices
-						}
-					}
-				}
-				if current == nil {
-					current = defaultServices()
+					gs = getGuildSettings(gidInt)
 				}
-				opts := make([]discordgo.SelectMenuOption, 0, len(defaultServices()))
-				for _, svc := range defaultServices() {
+				current := gs.EnabledServices
+				services := availableServices(gs)
+				opts := make([]discordgo.SelectMenuOption, 0, len(services))
+				for _, svc := range services {
 					def := false
 					for _, en := range current {
 						if en == svc {
@@ -722,7 +1098,7 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 				components := []discordgo.MessageComponent{
 					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{sm}},
 				}
-				embed := &discordgo.MessageEmbed{Title: "Service Settings", Description: "Configure which services are activated.", Color: 0x5865F2}
+				embed := &discordgo.MessageEmbed{Title: "Service Settings", Description: "Configure which services are activated.", Color: accentColor(guildID, 0x5865F2)}
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseUpdateMessage,
 					Data: &discordgo.InteractionResponseData{
@@ -730,31 +1106,19 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 						Components: components,
 					},
 				})
-			case "Mention Users":
-				// Build a toggle button that reflects current state
-				mentionVal := true
-				if gidInt != 0 {
-					if gs, ok := botSettings.m[gidInt]; ok && gs != nil {
-						mentionVal = gs.MentionUsers
-					} else if gs2, _ := getGuildSettingsFromDB(db, gidInt); gs2 != nil {
-						mentionVal = gs2.MentionUsers
-					}
-				}
-				label := "Activated"
-				style := discordgo.SuccessButton
-				if !mentionVal {
-					label = "Deactivated"
-					style = discordgo.DangerButton
-				}
-				btn := &discordgo.Button{
-					CustomID: "toggle_mention",
-					Label:    label,
-					Style:    style,
+			case "Channels":
+				// Channel picker: every channel picked is flipped
+				picker := discordgo.SelectMenu{
+					MenuType:     discordgo.ChannelSelectMenu,
+					CustomID:     "channel_select",
+					Placeholder:  "Pick channels to toggle",
+					MaxValues:    25,
+					ChannelTypes: activatableChannelTypes,
 				}
 				components := []discordgo.MessageComponent{
-					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
+					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{picker}},
 				}
-				embed := &discordgo.MessageEmbed{Title: "Mention Users Settings", Description: "Toggle mentioning users in messages.", Color: 0x00ff00}
+				embed := &discordgo.MessageEmbed{Title: "Channel Settings", Description: "Pick channels to activate or deactivate them. Picking a category toggles every channel in it.", Color: accentColor(guildID, 0x00ff00)}
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseUpdateMessage,
 					Data: &discordgo.InteractionResponseData{
@@ -762,75 +1126,37 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 						Components: components,
 					},
 				})
-			case "Delivery Method":
-				// Build a toggle button reflecting current delete_original state
-				deleteVal := true
-				if gidInt != 0 {
-					if gs, ok := botSettings.m[gidInt]; ok && gs != nil {
-						deleteVal = gs.DeleteOriginal
-					} else if gs2, _ := getGuildSettingsFromDB(db, gidInt); gs2 != nil {
-						deleteVal = gs2.DeleteOriginal
-					}
-				}
-				label := "Activated"
-				style := discordgo.SuccessButton
-				if !deleteVal {
-					label = "Deactivated"
-					style = discordgo.DangerButton
-				}
-				btn := &discordgo.Button{
-					CustomID: "toggle_delete",
-					Label:    label,
-					Style:    style,
+			case "Repost Template":
+				if !isPremium(guildID) {
+					respondPremiumRequired(s, i, T(interactionLocale(i), "premium.template"))
+					return
 				}
-				components := []discordgo.MessageComponent{
-					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
+				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseModal,
+					Data: repostTemplateModal(getGuildSettings(gidInt)),
+				})
+			case "Embed Color":
+				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseModal,
+					Data: embedColorModal(getGuildSettings(gidInt)),
+				})
+			case "Fixer Frontends":
+				gs := defaultGuildSettings()
+				if gidInt != 0 {
+					gs = getGuildSettings(gidInt)
 				}
-				embed := &discordgo.MessageEmbed{Title: "Delivery Method Settings", Description: "Toggle original message deletion.", Color: 0x00ff00}
+				embed := &discordgo.MessageEmbed{Title: "Fixer Frontends", Description: "Choose which fixer frontend links are rewritten to.\n\n" + describeFrontends(gs), Color: accentColor(guildID, 0x5865F2)}
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseUpdateMessage,
 					Data: &discordgo.InteractionResponseData{
 						Embeds:     []*discordgo.MessageEmbed{embed},
-						Components: components,
+						Components: []discordgo.MessageComponent{&discordgo.ActionsRow{Components: []discordgo.MessageComponent{frontendSelectMenu()}}},
 					},
 				})
 			case "FixEmbed":
-				// Build a toggle button that reflects whether all guild channels are activated
-				activated := true
-				if gidInt != 0 && s.State != nil {
-					for _, g := range s.State.Guilds {
-						if g.ID == guildID {
-							for _, ch := range g.Channels {
-								if ch.Type == discordgo.ChannelTypeGuildText {
-									cidInt, _ := discordIDStringToInt64(ch.ID)
-									channelStates.RLock()
-									v, ok := channelStates.m[cidInt]
-									channelStates.RUnlock()
-									if !ok || !v {
-										activated = false
-										break
-									}
-								}
-							}
-							break
-						}
-					}
-				}
-				label := "Activated"
-				style := discordgo.SuccessButton
-				if !activated {
-					label = "Deactivated"
-					style = discordgo.DangerButton
-				}
-				btn := &discordgo.Button{
-					CustomID: "toggle_fixembed",
-					Label:    label,
-					Style:    style,
-				}
-				components := []discordgo.MessageComponent{
-					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
-				}
-				embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Activate/Deactivate FixEmbed across channels.", Color: 0x00ff00}
+				// the button reflects whether all guild channels are activated
+				components := []discordgo.MessageComponent{toggleButton("toggle_fixembed", guildActivated(s, guildID))}
+				embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Activate/Deactivate FixEmbed across channels.", Color: accentColor(guildID, 0x00ff00)}
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseUpdateMessage,
 					Data: &discordgo.InteractionResponseData{
@@ -847,22 +1173,23 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 						Flags:  1 << 6, // ephemeral
 					},
 				})
+			default:
+				if t := settingsToggle(choice, ""); t != nil {
+					respondTogglePanel(s, i, t, getGuildSettings(gidInt), t.Help)
+				}
 			}
 		case "service_select":
 			values := data.Values
+			updated := *defaultGuildSettings()
 			// Persist selection and update in-memory settings
 			if gidInt != 0 {
-				gs, _ := getGuildSettingsFromDB(db, gidInt)
-				mention := true
-				deleteO := true
+				gs, _ := st.GuildSettings(gidInt)
 				if gs != nil {
-					mention = gs.MentionUsers
-					deleteO = gs.DeleteOriginal
+					updated = *gs
 				}
-				_ = updateSetting(db, gidInt, values, mention, deleteO)
-				botSettings.Lock()
-				botSettings.m[gidInt] = &GuildSettings{EnabledServices: values, MentionUsers: mention, DeleteOriginal: deleteO}
-				botSettings.Unlock()
+				updated.EnabledServices = values
+				_ = st.UpdateSetting(gidInt, values, updated.MentionUsers, updated.DeleteOriginal)
+				cacheGuildSettings(gidInt, &updated)
 			}
 
 			// Rebuild the services multi-select with current selection set as defaults
@@ -870,8 +1197,9 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			if len(enabled) == 0 {
 				enabled = defaultServices()
 			}
-			opts := make([]discordgo.SelectMenuOption, 0, len(defaultServices()))
-			for _, svc := range defaultServices() {
+			services := availableServices(&updated)
+			opts := make([]discordgo.SelectMenuOption, 0, len(services))
+			for _, svc := range services {
 				def := false
 				for _, en := range enabled {
 					if en == svc {
@@ -893,30 +1221,14 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 			}
 
 			// Rebuild the settings select so both appear together (mirrors Python view)
-			settingsOptions := []discordgo.SelectMenuOption{
-				{Label: "FixEmbed", Value: "FixEmbed", Description: "Activate or deactivate the bot in all channels"},
-				{Label: "Mention Users", Value: "Mention Users", Description: "Toggle mentioning users in messages"},
-				{Label: "Delivery Method", Value: "Delivery Method", Description: "Toggle original message deletion"},
-				{Label: "Service Settings", Value: "Service Settings", Description: "Configure which services are activated"},
-				{Label: "Debug", Value: "Debug", Description: "Show current debug information"},
-			}
-			setMin := new(int)
-			*setMin = 1
-			setMax := 1
-			settingsSM := &discordgo.SelectMenu{
-				CustomID:    "settings_select",
-				Placeholder: "Choose an option...",
-				MinValues:   setMin,
-				MaxValues:   setMax,
-				Options:     settingsOptions,
-			}
+			settingsSM := settingsSelectMenu(interactionLocale(i), &updated, guildActivated(s, guildID))
 
 			components := []discordgo.MessageComponent{
 				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{serviceSM}},
 				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{settingsSM}},
 			}
 
-			embed := &discordgo.MessageEmbed{Title: "Service Settings", Description: "Saved service settings.", Color: 0x5865F2}
+			embed := &discordgo.MessageEmbed{Title: "Service Settings", Description: "Saved service settings.", Color: accentColor(guildID, 0x5865F2)}
 			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseUpdateMessage,
 				Data: &discordgo.InteractionResponseData{
@@ -924,148 +1236,46 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 					Components: components,
 				},
 			})
-		case "toggle_mention":
-			if gidInt != 0 {
-				gs, _ := getGuildSettingsFromDB(db, gidInt)
-				mention := true
-				services := defaultServices()
-				deleteO := true
-				if gs != nil {
-					mention = gs.MentionUsers
-					services = gs.EnabledServices
-					deleteO = gs.DeleteOriginal
-				}
-				mention = !mention
-				_ = updateSetting(db, gidInt, services, mention, deleteO)
-				botSettings.Lock()
-				botSettings.m[gidInt] = &GuildSettings{EnabledServices: services, MentionUsers: mention, DeleteOriginal: deleteO}
-				botSettings.Unlock()
-
-				// Build updated toggle button reflecting new state
-				label := "Activated"
-				style := discordgo.SuccessButton
-				if !mention {
-					label = "Deactivated"
-					style = discordgo.DangerButton
-				}
-				btn := &discordgo.Button{
-					CustomID: "toggle_mention",
-					Label:    label,
-					Style:    style,
+		case "frontend_select":
+			handleFrontendSelect(st, s, i, gidInt, data.Values)
+		case "delete_repost":
+			handleDeleteRepost(st, s, i)
+		case "help_topic":
+			handleHelpTopic(s, i, data.Values)
+		case "channel_select":
+			// flip each picked channel; the panel stays open for more picks
+			lines := make([]string, 0, len(data.Values))
+			for _, channelID := range data.Values {
+				enabled, ok := channelState(s, channelID)
+				newState := ok && !enabled
+				n := setChannelState(st, s, guildID, channelID, newState)
+				line := fmt.Sprintf("✅ Activated <#%s>", channelID)
+				if !newState {
+					line = fmt.Sprintf("❌ Deactivated <#%s>", channelID)
 				}
-				components := []discordgo.MessageComponent{
-					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
+				if n > 0 {
+					line += fmt.Sprintf(" and its %d channel(s)", n)
 				}
-				embed := &discordgo.MessageEmbed{Title: "Mention Users Settings", Description: "Toggled mention users.", Color: 0x00ff00}
-				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseUpdateMessage,
-					Data: &discordgo.InteractionResponseData{
-						Embeds:     []*discordgo.MessageEmbed{embed},
-						Components: components,
-					},
-				})
-			} else {
-				embed := &discordgo.MessageEmbed{Title: "Mention Users Settings", Description: "Toggled mention users.", Color: 0x00ff00}
-				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseUpdateMessage,
-					Data: &discordgo.InteractionResponseData{
-						Embeds: []*discordgo.MessageEmbed{embed},
-					},
-				})
-			}
-		case "toggle_delete":
-			if gidInt != 0 {
-				gs, _ := getGuildSettingsFromDB(db, gidInt)
-				deleteO := true
-				services := defaultServices()
-				mention := true
-				if gs != nil {
-					deleteO = gs.DeleteOriginal
-					services = gs.EnabledServices
-					mention = gs.MentionUsers
-				}
-				deleteO = !deleteO
-				_ = updateSetting(db, gidInt, services, mention, deleteO)
-				botSettings.Lock()
-				botSettings.m[gidInt] = &GuildSettings{EnabledServices: services, MentionUsers: mention, DeleteOriginal: deleteO}
-				botSettings.Unlock()
-
-				// Build updated toggle button reflecting new state
-				label := "Activated"
-				style := discordgo.SuccessButton
-				if !deleteO {
-					label = "Deactivated"
-					style = discordgo.DangerButton
-				}
-				btn := &discordgo.Button{
-					CustomID: "toggle_delete",
-					Label:    label,
-					Style:    style,
-				}
-				components := []discordgo.MessageComponent{
-					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
-				}
-				embed := &discordgo.MessageEmbed{Title: "Delivery Method Settings", Description: "Toggled original message deletion.", Color: 0x00ff00}
-				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseUpdateMessage,
-					Data: &discordgo.InteractionResponseData{
-						Embeds:     []*discordgo.MessageEmbed{embed},
-						Components: components,
-					},
-				})
-			} else {
-				embed := &discordgo.MessageEmbed{Title: "Delivery Method Settings", Description: "Toggled original message deletion.", Color: 0x00ff00}
-				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseUpdateMessage,
-					Data: &discordgo.InteractionResponseData{
-						Embeds: []*discordgo.MessageEmbed{embed},
-					},
-				})
+				lines = append(lines, line)
 			}
+			embed := &discordgo.MessageEmbed{Title: "Channel Settings", Description: strings.Join(lines, "\n"), Color: accentColor(guildID, 0x00ff00)}
+			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: &discordgo.InteractionResponseData{
+					Embeds:     []*discordgo.MessageEmbed{embed},
+					Components: i.Message.Components,
+				},
+			})
 		case "toggle_fixembed":
 			if gidInt != 0 && s.State != nil {
 				for _, g := range s.State.Guilds {
 					if g.ID == guildID {
-						allActivated := true
-						for _, ch := range g.Channels {
-							if ch.Type == discordgo.ChannelTypeGuildText {
-								cidInt, _ := discordIDStringToInt64(ch.ID)
-								channelStates.RLock()
-								v, ok := channelStates.m[cidInt]
-								channelStates.RUnlock()
-								if !ok || !v {
-									allActivated = false
-									break
-								}
-							}
-						}
-						newState := !allActivated
-						for _, ch := range g.Channels {
-							if ch.Type == discordgo.ChannelTypeGuildText {
-								cidInt, _ := discordIDStringToInt64(ch.ID)
-								channelStates.Lock()
-								channelStates.m[cidInt] = newState
-								channelStates.Unlock()
-								_ = updateChannelState(db, cidInt, newState)
-							}
-						}
+						newState := !channelsActivated(g)
+						_, _ = setGuildState(st, g, newState, nil)
 
 						// Build updated toggle button reflecting new overall state
-						label := "Activated"
-						style := discordgo.SuccessButton
-						if !newState {
-							label = "Deactivated"
-							style = discordgo.DangerButton
-						}
-						btn := &discordgo.Button{
-							CustomID: "toggle_fixembed",
-							Label:    label,
-							Style:    style,
-						}
-						components := []discordgo.MessageComponent{
-							&discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}},
-						}
-						embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Toggled FixEmbed for guild channels.", Color: 0x00ff00}
+						components := []discordgo.MessageComponent{toggleButton("toggle_fixembed", newState)}
+						embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Toggled FixEmbed for guild channels.", Color: accentColor(guildID, 0x00ff00)}
 						_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 							Type: discordgo.InteractionResponseUpdateMessage,
 							Data: &discordgo.InteractionResponseData{
@@ -1078,7 +1288,7 @@ func onInteractionCreate(db *sql.DB, s *discordgo.Session, i *discordgo.Interact
 					}
 				}
 			} else {
-				embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Toggled FixEmbed for guild channels.", Color: 0x00ff00}
+				embed := &discordgo.MessageEmbed{Title: "FixEmbed Settings", Description: "Toggled FixEmbed for guild channels.", Color: accentColor(guildID, 0x00ff00)}
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseUpdateMessage,
 					Data: &discordgo.InteractionResponseData{
@@ -1099,263 +1309,396 @@ func discordIDStringToInt64(s string) (int64, error) {
 	return id, err
 }
 
-func onMessageCreate(db *sql.DB, s *discordgo.Session, m *discordgo.MessageCreate) {
-	// ignore own messages
-	if s.State.User != nil && m.Author.ID == s.State.User.ID {
+func onMessageCreate(st Store, s *discordgo.Session, m *discordgo.MessageCreate) {
+	// Debug: log incoming message for troubleshooting link processing
+	logf(LOG_DEBUG, "onMessageCreate: guild=%s channel=%s author=%s message=%s length=%d", m.GuildID, m.ChannelID, m.Author.ID, m.ID, len(m.Content))
+
+	// PluralKit reposts proxied messages through a webhook; fix that repost, attributed to its sender
+	msg, _ := pluralKitProxy(s, m.Message)
+
+	settings, ok := fixableMessage(s, msg)
+	if !ok {
 		return
 	}
-	if m.GuildID == "" {
+	content, links := findLinks(msg, settings)
+	if len(links) == 0 {
+		return
+	}
+	// before fetching anything for the reposts: PluralKit may be about to replace the message
+	if msg.WebhookID == "" && guildHasPluralKit(s, m.GuildID) && proxiedByPluralKit(s, msg) {
+		logf(LOG_DEBUG, "onMessageCreate: message %s was proxied by PluralKit, leaving it to the proxied copy", m.ID)
 		return
 	}
-	gidInt, _ := discordIDStringToInt64(m.GuildID)
 
-	// Debug: log incoming message for troubleshooting link processing
-	log.Printf("[DEBUG] onMessageCreate: guild=%s channel=%s author=%s content=%q", m.GuildID, m.ChannelID, m.Author.ID, m.Content)
+	// trial run: say what would have happened and leave the message alone, without fetching
+	// embeds or media for a repost that won't be sent
+	if settings.Simulate {
+		logf(LOG_INFO, "[SIMULATE] guild=%s channel=%s message=%s: would repost %s (delete original: %t)", m.GuildID, m.ChannelID, m.ID, repostSignature(links), settings.DeleteOriginal)
+		action := "Would repost and hide the original's preview"
+		if settings.DeleteOriginal {
+			action = "Would repost and delete the original"
+		}
+		postFixLog(s, msg, settings, links, action)
+		return
+	}
+
+	sends := buildLinkReposts(s, msg, settings, content, links)
+	if len(sends) == 0 {
+		return
+	}
+
+	// FixEmbed can't post here: the author gets the fix by DM, if the server allows it
+	if !canSendMessages(s, m.ChannelID) {
+		_ = recordBotEvent(st, m.GuildID, m.ChannelID, "permission", "send: missing Send Messages")
+		notePermissionProblem(s, msg, settings, "Send Messages")
+		if settings.DMFallback && deliverByDM(st, s, msg, sends, guildLocale(s, m.GuildID, settings)) {
+			for _, link := range links {
+				_ = recordLinkFix(st, msg, link.Service.Name)
+			}
+			postFixLog(s, msg, settings, links, "Sent to the author by DM (missing Send Messages)")
+		}
+		return
+	}
+
+	var sentAny bool
+	deliver := func() {
+		for _, send := range sends {
+			sent, err := rateLimitedSendComplex(s, m.ChannelID, send)
+			if err != nil {
+				if recordDeliveryError(st, msg, "send", err) {
+					notePermissionProblem(s, msg, settings, "Send Messages")
+				}
+				continue
+			}
+			sentAny = true
+			_ = recordFixMessage(st, sent, msg, repostSignature(links))
+			rememberRepost(msg, sent, repostSignature(links))
+		}
+	}
+	action := "Reposted"
+	switch {
+	case !canManageMessages(s, m.ChannelID):
+		// deleting or un-embedding the original needs Manage Messages; rather than fail halfway
+		// (and leave a duplicate behind a failed delete), post the fix and leave the original alone
+		logf(LOG_WARN, "Warning: missing Manage Messages in channel %s, leaving the original as it is", m.ChannelID)
+		_ = recordBotEvent(st, m.GuildID, m.ChannelID, "permission", DEGRADED_DELIVERY)
+		notePermissionProblem(s, msg, settings, "Manage Messages")
+		action = "Reposted, original left as is (missing Manage Messages)"
+		deliver()
+	case settings.DeleteOriginal:
+		deliver()
+		if !sentAny {
+			// the send failures are already recorded; with nothing posted, the original is all there is
+			logf(LOG_WARN, "Warning: no fix could be posted for message %s, keeping the original", m.ID)
+			break
+		}
+		if err := deleteRepostedOriginal(s, msg); err != nil {
+			if recordDeliveryError(st, msg, "delete", err) {
+				notePermissionProblem(s, msg, settings, "Manage Messages")
+			}
+		} else {
+			action = "Reposted, original deleted"
+		}
+	default:
+		if err := suppressOriginalEmbeds(s, msg); err != nil {
+			if recordDeliveryError(st, msg, "suppress", err) {
+				notePermissionProblem(s, msg, settings, "Manage Messages")
+			}
+		} else {
+			action = "Reposted, original's preview hidden"
+		}
+		deliver()
+	}
+	if sentAny {
+		for _, link := range links {
+			_ = recordLinkFix(st, msg, link.Service.Name)
+		}
+		postFixLog(s, msg, settings, links, action)
+	}
+}
+
+// fixableMessage returns the guild's settings if FixEmbed should look at the message at all.
+func fixableMessage(s *discordgo.Session, m *discordgo.Message) (*GuildSettings, bool) {
+	// ignore own messages
+	if m.Author == nil || (s.State.User != nil && m.Author.ID == s.State.User.ID) {
+		return nil, false
+	}
+	if m.GuildID == "" {
+		return nil, false
+	}
+	if isOptedOut(m.Author.ID) {
+		logf(LOG_DEBUG, "onMessageCreate: author %s opted out, skipping message", m.Author.ID)
+		return nil, false
+	}
+	if isIgnored(m.GuildID, m.Author.ID) {
+		logf(LOG_DEBUG, "onMessageCreate: author %s is on the guild's ignore list, skipping message", m.Author.ID)
+		return nil, false
+	}
+	gidInt, _ := discordIDStringToInt64(m.GuildID)
 
 	// fetch guild settings or defaults
 	botSettings.RLock()
 	settings := botSettings.m[gidInt]
 	botSettings.RUnlock()
 	if settings == nil {
-		settings = &GuildSettings{EnabledServices: defaultServices(), MentionUsers: true, DeleteOriginal: true}
+		settings = defaultGuildSettings()
 	}
+	settings = premiumSettings(m.GuildID, settings)
 
 	enabledServices := settings.EnabledServices
 	mentionUsers := settings.MentionUsers
 	deleteOriginal := settings.DeleteOriginal
 
 	// Debug: log effective guild settings
-	log.Printf("[DEBUG] onMessageCreate: guildSettings enabledServices=%v mentionUsers=%t deleteOriginal=%t", enabledServices, mentionUsers, deleteOriginal)
+	logf(LOG_DEBUG, "onMessageCreate: guildSettings enabledServices=%v mentionUsers=%t deleteOriginal=%t", enabledServices, mentionUsers, deleteOriginal)
 
-	// Check if bot enabled in this channel
-	cidInt, _ := discordIDStringToInt64(m.ChannelID)
-	channelStates.RLock()
-	enabled, ok := channelStates.m[cidInt]
-	channelStates.RUnlock()
+	// Check if bot enabled in this channel (or the channel a thread lives in)
+	enabled, ok := channelState(s, m.ChannelID)
 	// Debug: log channel state
-	log.Printf("[DEBUG] onMessageCreate: channelState ok=%t enabled=%t cid=%d", ok, enabled, cidInt)
+	logf(LOG_DEBUG, "onMessageCreate: channelState ok=%t enabled=%t cid=%s", ok, enabled, m.ChannelID)
 	if ok && !enabled {
 		// deactivated for this channel
-		log.Printf("[DEBUG] onMessageCreate: channel is deactivated, skipping message")
-		return
+		logf(LOG_DEBUG, "onMessageCreate: channel is deactivated, skipping message")
+		return nil, false
+	}
+	// webhook messages are authored by the webhook itself, unless attributed to a PluralKit sender
+	if m.WebhookID != "" && m.Author.ID == m.WebhookID && !settings.ProcessWebhooks {
+		logf(LOG_DEBUG, "onMessageCreate: webhook message and webhooks are not processed, skipping")
+		return nil, false
 	}
+	// channel overrides win over the guild's settings
+	settings = channelSettings(s, m.ChannelID, settings)
+	settings, ok = nsfwSettings(s, m.ChannelID, settings)
+	if !ok {
+		logf(LOG_DEBUG, "onMessageCreate: NSFW channel and NSFW channels are skipped")
+		return nil, false
+	}
+	return settings, true
+}
+
+// buildFix fixes every link in a message and composes the repost(s). No sends means there
+// was nothing to fix.
+func buildFix(s *discordgo.Session, m *discordgo.Message, settings *GuildSettings) ([]*discordgo.MessageSend, []*repostLink) {
+	content, links := findLinks(m, settings)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return buildLinkReposts(s, m, settings, content, links), links
+}
 
-	// Patterns from Python ported
-	linkPattern := `https?://(?:www\.)?(twitter\.com/[A-Za-z0-9_]+/status/[0-9]+|x\.com/[A-Za-z0-9_]+/status/[0-9]+|instagram\.com/(?:p|reel)/[A-Za-z0-9_-]+|reddit\.com/r/[A-Za-z0-9_]+/s/[A-Za-z0-9_]+|reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+|old\.reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+|pixiv\.net/(?:en/)?artworks/[0-9]+|threads\.(?:net|com)/@[^/]+/post/[A-Za-z0-9_-]+|bsky\.app/profile/[^/]+/post/[A-Za-z0-9_-]+)`
-	surroundedPattern := `<https?://(?:www\.)?(twitter\.com/[A-Za-z0-9_]+/status/[0-9]+|x\.com/[A-Za-z0-9_]+/status/[0-9]+|instagram\.com/(?:p|reel)/[A-Za-z0-9_-]+|reddit\.com/r/[A-Za-z0-9_]+/s/[A-Za-z0-9_]+|reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+|old\.reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+|pixiv\.net/(?:en/)?artworks/[0-9]+|threads\.(?:net|com)/@[^/]+/post/[A-Za-z0-9_-]+|bsky\.app/profile/[^/]+/post/[A-Za-z0-9_-]+)>`
+// findLinks returns the links in a message FixEmbed would fix, without fetching anything about
+// the posts; content is the message with its short links expanded.
+func findLinks(m *discordgo.Message, settings *GuildSettings) (string, []*repostLink) {
+	enabledServices := settings.EnabledServices
+
+	// the author asked FixEmbed to stand down for this message
+	if hasOptOutKeyword(m.Content, settings.OptOutKeyword) {
+		logf(LOG_DEBUG, "onMessageCreate: message starts with opt-out keyword %q, skipping", settings.OptOutKeyword)
+		return "", nil
+	}
+
+	// Patterns from Python ported, now assembled from the service registry
+	servicePattern := servicePattern(settings)
+	linkPattern := `https?://(?:www\.)?(` + servicePattern + `)`
+	surroundedPattern := `<https?://(?:www\.)?(` + servicePattern + `)>`
 
 	// Debug: show the regex patterns we're using
-	log.Printf("[DEBUG] onMessageCreate: linkPattern=%q surroundedPattern=%q", linkPattern, surroundedPattern)
+	logf(LOG_DEBUG, "onMessageCreate: linkPattern=%q surroundedPattern=%q", linkPattern, surroundedPattern)
 	reLink := regexp.MustCompile(linkPattern)
 	reSurrounded := regexp.MustCompile(surroundedPattern)
 
-	matches := reLink.FindAllStringSubmatch(m.Content, -1)
+	// t.co / pic.twitter.com links never match the patterns until they are expanded; they're
+	// Twitter's, so there's nothing to resolve them for when it's off
+	content := m.Content
+	if slices.Contains(enabledServices, "Twitter") {
+		content = expandShortLinks(content, settings.LinkLimit)
+	}
+	// links in code or quotes are left alone
+	scanned := maskMarkdown(content)
+
+	matches := reLink.FindAllStringSubmatch(scanned, -1)
 	if len(matches) == 0 {
-		log.Printf("[DEBUG] onMessageCreate: no link matches in message")
+		logf(LOG_DEBUG, "onMessageCreate: no link matches in message")
 		// also log whether the message contains a surrounded link (which we skip)
-		if reSurrounded.MatchString(m.Content) {
-			log.Printf("[DEBUG] onMessageCreate: message contains surrounded link; skipping per design")
+		if reSurrounded.MatchString(scanned) {
+			logf(LOG_DEBUG, "onMessageCreate: message contains surrounded link; skipping per design")
 		}
-		return
+		return "", nil
 	}
-	log.Printf("[DEBUG] onMessageCreate: found %d link match(es)", len(matches))
+	logf(LOG_DEBUG, "onMessageCreate: found %d link match(es)", len(matches))
 	// Log each match and its capture groups for diagnostics
 	for mi, mmatch := range matches {
 		if len(mmatch) == 0 {
 			continue
 		}
-		log.Printf("[DEBUG] onMessageCreate: match[%d].full=%q", mi, mmatch[0])
+		logf(LOG_DEBUG, "onMessageCreate: match[%d].full=%q", mi, mmatch[0])
 		for gi, g := range mmatch {
-			log.Printf("[DEBUG] onMessageCreate: match[%d].group[%d]=%q", mi, gi, g)
+			logf(LOG_DEBUG, "onMessageCreate: match[%d].group[%d]=%q", mi, gi, g)
 		}
 	}
 
 	// If any link is surrounded by <...>, skip processing entirely (per original logic which checks per message)
-	if reSurrounded.MatchString(m.Content) {
-		return
+	if reSurrounded.MatchString(scanned) {
+		return "", nil
 	}
 
+	// every link in the message goes into one combined repost
+	var links []*repostLink
 	for _, match := range matches {
+		// copy-paste floods shouldn't turn into bot spam
+		if len(links) >= settings.LinkLimit {
+			logf(LOG_DEBUG, "onMessageCreate: link limit of %d reached, ignoring the remaining links", settings.LinkLimit)
+			break
+		}
 		// match[1] is the captured domain/... part like "twitter.com/user/status/123"
 		originalLink := match[1]
 		if originalLink == "" {
 			continue
 		}
-		displayText := ""
-		modifiedLink := originalLink
-		service := ""
-		userOrCommunity := ""
 
 		// domain is first component before slash
 		parts := strings.Split(originalLink, "/")
 		domain := strings.ToLower(parts[0])
-
-		switch domain {
-		case "twitter.com", "x.com":
-			service = "Twitter"
-			re := regexp.MustCompile(`(?:twitter\.com|x\.com)/([A-Za-z0-9_]+)/status/[0-9]+`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 1 {
-				userOrCommunity = mm[1]
-			} else {
-				userOrCommunity = "Unknown"
-			}
-		case "instagram.com":
-			service = "Instagram"
-			re := regexp.MustCompile(`instagram\.com/(?:p|reel)/([A-Za-z0-9_-]+)`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 1 {
-				userOrCommunity = mm[1]
-			} else {
-				userOrCommunity = "Unknown"
-			}
-		case "reddit.com", "old.reddit.com":
-			service = "Reddit"
-			re := regexp.MustCompile(`(?:reddit\.com|old\.reddit\.com)/r/([A-Za-z0-9_]+)`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 1 {
-				userOrCommunity = mm[1]
-			} else {
-				userOrCommunity = "Unknown"
-			}
-		case "pixiv.net":
-			service = "Pixiv"
-			re := regexp.MustCompile(`pixiv\.net/(?:en/)?artworks/([0-9]+)`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 1 {
-				userOrCommunity = mm[1]
-			} else {
-				userOrCommunity = "Unknown"
-			}
-		case "threads.net", "threads.com":
-			service = "Threads"
-			re := regexp.MustCompile(`threads\.(?:net|com)/@([^/]+)/post/([A-Za-z0-9_-]+)`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 2 {
-				userOrCommunity = mm[1]
-				postID := mm[2]
-				modifiedLink = fmt.Sprintf("fixthreads.net/@%s/post/%s", userOrCommunity, postID)
-				displayText = fmt.Sprintf("Threads • @%s", userOrCommunity)
-			}
-		case "bsky.app":
-			service = "Bluesky"
-			re := regexp.MustCompile(`bsky\.app/profile/([^/]+)/post/([A-Za-z0-9_-]+)`)
-			mm := re.FindStringSubmatch(originalLink)
-			if len(mm) > 2 {
-				userOrCommunity = mm[1]
-				postID := mm[2]
-				modifiedLink = fmt.Sprintf("bskyx.app/profile/%s/post/%s", userOrCommunity, postID)
-				displayText = fmt.Sprintf("Bluesky • %s", userOrCommunity)
-			}
-		}
-
-		// verify enabled
-		if service != "" && userOrCommunity == "" {
-			// when we didn't set userOrCommunity for threads/bluesky (already handled above), fall back:
-			parts2 := strings.Split(originalLink, "/")
-			if len(parts2) > 1 {
-				userOrCommunity = parts2[1]
-			} else {
-				userOrCommunity = "Unknown"
-			}
+		svc := serviceForDomain(domain, settings)
+		if svc == nil {
+			continue
 		}
 
-		// check if service is enabled for this guild
+		// check if service is enabled for this guild (before resolving anything over the network)
 		enabled := false
 		for _, sname := range enabledServices {
-			if sname == service {
+			if sname == svc.Name {
 				enabled = true
 				break
 			}
 		}
-		if service != "" && userOrCommunity != "" && !enabled {
-			log.Printf("[DEBUG] onMessageCreate: service %s is not enabled for this guild (enabledServices=%v)", service, enabledServices)
+		if !enabled {
+			logf(LOG_DEBUG, "onMessageCreate: service %s is not enabled for this guild (enabledServices=%v)", svc.Name, enabledServices)
+			continue
+		}
+
+		fixed, ok := svc.fix(originalLink, domain, settings)
+		if !ok {
+			continue
 		}
+		// Debug: log the rewritten link before sending
+		logf(LOG_DEBUG, "onMessageCreate: original=%s service=%s userOrCommunity=%s modified=%s deleteOriginal=%t", fixed.Original, svc.Name, fixed.User, fixed.Fixed, settings.DeleteOriginal)
+		links = append(links, &repostLink{FixedLink: fixed, Match: match[0]})
+	}
+	return content, links
+}
 
-		if service != "" && userOrCommunity != "" && enabled {
-			if displayText == "" {
-				displayText = fmt.Sprintf("%s • %s", service, userOrCommunity)
+// buildLinkReposts composes the repost(s) of links found by findLinks, first fetching the
+// rich embeds and media the guild wants.
+func buildLinkReposts(s *discordgo.Session, m *discordgo.Message, settings *GuildSettings, content string, links []*repostLink) []*discordgo.MessageSend {
+	uploadLimit := guildUploadLimit(s, m.GuildID)
+	attachments := 0
+	for _, link := range links {
+		svc := link.Service
+		var meta *PostMetadata
+		if (settings.RichEmbeds || settings.ReuploadMedia) && svc.Metadata != nil {
+			var err error
+			if meta, err = svc.fetchMetadata(link.Original); err != nil {
+				logf(LOG_DEBUG, "onMessageCreate: could not fetch metadata for %s: %v", link.Original, err)
 			}
-			// apply domain replacements
-			switch service {
-			case "Twitter":
-				modifiedLink = strings.ReplaceAll(originalLink, "twitter.com", "fxtwitter.com")
-				modifiedLink = strings.ReplaceAll(modifiedLink, "x.com", "fixupx.com")
-			case "Instagram":
-				modifiedLink = strings.ReplaceAll(originalLink, "instagram.com", "instafix.ldez.top")
-			case "Reddit":
-				if strings.Contains(originalLink, "old.reddit.com") {
-					modifiedLink = strings.ReplaceAll(originalLink, "old.reddit.com", "old.rxddit.com")
-				} else {
-					modifiedLink = strings.ReplaceAll(originalLink, "reddit.com", "vxreddit.ldez.workers.dev")
+		}
+		if meta != nil && settings.RichEmbeds {
+			link.Embed = richEmbed(svc, meta, link.Original)
+		}
+		if meta != nil && settings.ReuploadMedia {
+			// attached media survives the fixer going down; too-large media stays a link
+			if files, size, ok := downloadMedia(meta.Media, MAX_ATTACHMENTS-attachments, uploadLimit); ok {
+				link.Files = files
+				attachments += len(files)
+				uploadLimit -= size
+				if link.Embed != nil {
+					link.Embed.Image = nil
 				}
-			case "Threads":
-				modifiedLink = strings.ReplaceAll(originalLink, "threads.net", "fixthreads.net")
-				modifiedLink = strings.ReplaceAll(modifiedLink, "threads.com", "fixthreads.net")
-			case "Pixiv":
-				modifiedLink = strings.ReplaceAll(originalLink, "pixiv.net", "phixiv.net")
-			case "Bluesky":
-				modifiedLink = strings.ReplaceAll(originalLink, "bsky.app", "fxbsky.app")
-			}
-
-			formattedMessage := fmt.Sprintf("[%s](https://%s)", displayText, modifiedLink)
-			if mentionUsers {
-				formattedMessage = formattedMessage + fmt.Sprintf(" | Sent by <@%s>", m.Author.ID)
-			} else {
-				formattedMessage = formattedMessage + fmt.Sprintf(" | Sent by %s", m.Author.Username)
 			}
+		}
+	}
 
-			// Debug: log the rewritten message before sending
-			log.Printf("[DEBUG] onMessageCreate: original=%s service=%s userOrCommunity=%s modified=%s formatted=%s deleteOriginal=%t", originalLink, service, userOrCommunity, modifiedLink, formattedMessage, deleteOriginal)
+	// reposts are read by the whole channel, so they're written in the server's language
+	locale := guildLocale(s, m.GuildID, settings)
+	sentBy := T(locale, "repost.sent_by", escapeMarkdown(m.Author.Username))
+	// only the author is ever pinged, never mentions smuggled in through handles or kept text
+	allowedMentions := &discordgo.MessageAllowedMentions{}
+	switch {
+	case attributionMode(settings) == ATTRIBUTION_NONE:
+		sentBy = ""
+	case attributionMode(settings) == ATTRIBUTION_SILENT:
+		// the mention is shown but, with no users allowed, nobody is notified
+		sentBy = T(locale, "repost.sent_by", "<@"+m.Author.ID+">")
+	case shouldMention(m.Author.ID, settings):
+		sentBy = T(locale, "repost.sent_by", "<@"+m.Author.ID+">")
+		allowedMentions.Users = []string{m.Author.ID}
+	}
 
-			if deleteOriginal {
-				_ = rateLimitedSend(s, m.ChannelID, formattedMessage)
-				_ = s.ChannelMessageDelete(m.ChannelID, m.ID)
-			} else {
-				// Attempt to suppress embeds on the original message (set SUPPRESS_EMBEDS flag)
-				// In Discord, SUPPRESS_EMBEDS == 4
-				// discordgo MessageEdit.Flags is discordgo.MessageFlags; construct value accordingly
-				flags := discordgo.MessageFlags(1 << 2)
-				_, _ = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-					ID:      m.ID,
-					Channel: m.ChannelID,
-					Content: &m.Content,
-					Flags:   flags,
-				})
-				_ = rateLimitedSend(s, m.ChannelID, formattedMessage)
-			}
-		}
+	sends := buildReposts(links, content, m.Author, sentBy, settings, locale)
+	for _, send := range sends {
+		send.AllowedMentions = allowedMentions
 	}
+	return sends
 }
 
-func onGuildCreate(db *sql.DB, s *discordgo.Session, g *discordgo.GuildCreate) {
+func onGuildCreate(st Store, s *discordgo.Session, g *discordgo.GuildCreate) {
 	// when joining a guild, create default settings if missing
 	if g.Guild.ID == "" {
 		return
 	}
 	gidInt, _ := discordIDStringToInt64(g.Guild.ID)
-	botSettings.Lock()
-	if _, ok := botSettings.m[gidInt]; !ok {
-		botSettings.m[gidInt] = &GuildSettings{
-			EnabledServices: defaultServices(),
-			MentionUsers:    true,
-			DeleteOriginal:  true,
-		}
-		_ = updateSetting(db, gidInt, botSettings.m[gidInt].EnabledServices, true, true)
+	botSettings.RLock()
+	_, ok := botSettings.m[gidInt]
+	botSettings.RUnlock()
+	if !ok {
+		settings := defaultGuildSettings()
+		cacheGuildSettings(gidInt, settings)
+		_ = st.UpdateSetting(gidInt, settings.EnabledServices, true, true)
 	}
-	botSettings.Unlock()
+	// guilds joined mid-run weren't there when Ready synced the commands
+	if syncGuildCommands(s, g.Guild.ID) {
+		logf(LOG_INFO, "Synchronized commands in new guild %s", g.Guild.ID)
+	}
+	sendOnboarding(s, g.Guild)
+}
+
+// Statuses with live numbers, shown between the configured ones (turn off with LIVE_STATUSES=false)
+var liveStatuses = []string{"{guilds} servers", "{fixed_today} links fixed today"}
+
+// statusAt is the status for the tick'th rotation: the configured statuses in turn, with a
+// live one after each when those are on.
+func statusAt(tick int) string {
+	runtimeConfig.RLock()
+	defer runtimeConfig.RUnlock()
+	if !cfg.LiveStatuses || len(liveStatuses) == 0 {
+		return statuses[tick%len(statuses)]
+	}
+	if tick%2 == 1 {
+		return liveStatuses[(tick/2)%len(liveStatuses)]
+	}
+	return statuses[(tick/2)%len(statuses)]
 }
 
-func startStatusRotator(s *discordgo.Session, stop <-chan struct{}) {
-	ticker := time.NewTicker(60 * time.Second)
-	idx := 0
+func startStatusRotator(st Store, s *discordgo.Session, stop <-chan struct{}) {
+	runtimeConfig.RLock()
+	interval := cfg.StatusInterval
+	runtimeConfig.RUnlock()
+	ticker := time.NewTicker(interval)
+	tick := 0
 	// set initial presence immediately
-	_ = updateStatus(s, statuses[idx])
+	_ = updateStatus(st, s, statusAt(tick))
 	for {
 		select {
 		case <-ticker.C:
-			idx = (idx + 1) % len(statuses)
-			_ = updateStatus(s, statuses[idx])
+			runtimeConfig.RLock()
+			// a config reload can change both the texts and the interval
+			if cfg.StatusInterval != interval {
+				interval = cfg.StatusInterval
+				ticker.Reset(interval)
+			}
+			runtimeConfig.RUnlock()
+			tick++
+			_ = updateStatus(st, s, statusAt(tick))
 		case <-stop:
 			ticker.Stop()
 			return
@@ -1363,9 +1706,30 @@ func startStatusRotator(s *discordgo.Session, stop <-chan struct{}) {
 	}
 }
 
-func updateStatus(s *discordgo.Session, text string) error {
+// renderStatus fills in a status text's placeholders: {guilds}, {fixed_today}, {services}
+// and {version}. They're looked up every time, so the numbers are current on each rotation.
+func renderStatus(st Store, s *discordgo.Session, text string) string {
+	guilds := 0
+	if s.State != nil {
+		s.State.RLock()
+		guilds = len(s.State.Guilds)
+		s.State.RUnlock()
+	}
+	fixedToday := 0
+	if strings.Contains(text, "{fixed_today}") {
+		fixedToday, _ = st.FixedLinks(time.Now().UTC().Format(time.DateOnly))
+	}
+	return strings.NewReplacer(
+		"{guilds}", strconv.Itoa(guilds),
+		"{fixed_today}", strconv.Itoa(fixedToday),
+		"{services}", strconv.Itoa(len(services)),
+		"{version}", VERSION,
+	).Replace(text)
+}
+
+func updateStatus(st Store, s *discordgo.Session, text string) error {
 	act := &discordgo.Activity{
-		Name: text,
+		Name: renderStatus(st, s, text),
 		Type: discordgo.ActivityTypeWatching,
 	}
 	return s.UpdateStatusComplex(discordgo.UpdateStatusData{
@@ -1373,24 +1737,646 @@ func updateStatus(s *discordgo.Session, text string) error {
 	})
 }
 
+// applicationCommands builds FixEmbed's slash commands: the ones registered in each guild,
+// and the global ones that also work in DMs and user installs.
+func applicationCommands() (commands, globalCommands []*discordgo.ApplicationCommand) {
+	commands = []*discordgo.ApplicationCommand{
+		{
+			Name:                     "activate",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Description:              "Activate link processing in this channel or another channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to activate link processing in (leave blank for current channel)",
+					Required:     false,
+					ChannelTypes: activatableChannelTypes,
+				},
+			},
+		},
+		{
+			Name:                     "deactivate",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Description:              "Deactivate link processing in this channel or another channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to deactivate link processing in (leave blank for current channel)",
+					Required:     false,
+					ChannelTypes: activatableChannelTypes,
+				},
+			},
+		},
+		{
+			Name:                     "activate-all",
+			Description:              "Activate link processing in every channel",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options:                  exceptChannelOptions("activated"),
+		},
+		{
+			Name:                     "deactivate-all",
+			Description:              "Deactivate link processing in every channel",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options:                  exceptChannelOptions("deactivated"),
+		},
+		{
+			Name:                     "setup",
+			Description:              "Walk through FixEmbed's main settings",
+			DefaultMemberPermissions: &manageGuildPermission,
+		},
+		{
+			Name:                     "status",
+			Description:              "List which channels FixEmbed is active in",
+			DefaultMemberPermissions: &manageGuildPermission,
+		},
+		{
+			Name:        "about",
+			Description: "Show information about the bot",
+		},
+		{
+			Name:        "help",
+			Description: "Learn how to use FixEmbed",
+		},
+		{
+			Name:        "ping",
+			Description: "Check FixEmbed's connection to Discord",
+		},
+		{
+			Name:        "stats",
+			Description: "Show how many links FixEmbed has fixed in this server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "Only count the last few days (default: all time)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Last 7 days", Value: 7},
+						{Name: "Last 30 days", Value: 30},
+						{Name: "Last 365 days", Value: 365},
+					},
+				},
+				serviceOption("Only count links to one service", false),
+			},
+		},
+		{
+			Name:        "leaderboard",
+			Description: "Show whose links get fixed the most",
+		},
+		{
+			Name:        "test",
+			Description: "Check what FixEmbed would do with a link in this channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "The link to test",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "service",
+			Description:              "Turn fixing for a single service on or off",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "enable",
+					Description: "Start fixing a service's links",
+					Options:     []*discordgo.ApplicationCommandOption{serviceOption("The service to enable", true)},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "disable",
+					Description: "Stop fixing a service's links",
+					Options:     []*discordgo.ApplicationCommandOption{serviceOption("The service to disable", true)},
+				},
+			},
+		},
+		{
+			Name:                     "mention",
+			Description:              "Choose whether reposts mention whoever posted the link",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether to mention the poster",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "attribution",
+			Description:              "Choose how reposts credit whoever posted the link",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "How the poster is credited",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Mention them", Value: ATTRIBUTION_MENTION},
+						{Name: "Mention them without a ping", Value: ATTRIBUTION_SILENT},
+						{Name: "Show their name", Value: ATTRIBUTION_NAME},
+						{Name: "No attribution", Value: ATTRIBUTION_NONE},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "delivery",
+			Description:              "Choose whether the original message is deleted after reposting",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "delete_original",
+					Description: "Delete the original message (otherwise only its embeds are suppressed)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "language",
+			Description:              "Choose the language FixEmbed writes in on this server",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "language",
+					Description: "The language to use",
+					Required:    true,
+					Choices:     languageChoices(),
+				},
+			},
+		},
+		{
+			Name:                     "reset",
+			Description:              "Put FixEmbed's settings on this server back to the defaults",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "channels",
+					Description: "Also activate FixEmbed in every channel",
+				},
+			},
+		},
+		{
+			Name:                     "settings",
+			Description:              "Configure FixEmbed's settings",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "panel",
+					Description: "Open the settings panel",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "Show who changed which setting recently",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "export",
+					Description: "Download this server's settings as a file",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "import",
+					Description: "Load settings from a file made with /settings export",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "The exported settings file",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "owner",
+			Description: "Owner-only command: lists guilds the bot is in",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "guilds",
+					Description: "List the guilds the bot is in",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stats",
+					Description: "Show bot-wide statistics",
+				},
+			},
+		},
+		{
+			Name:                     "retention",
+			Description:              "Delete FixEmbed's messages in a channel after a number of days",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "Days to keep fixed links for (0 keeps them forever)",
+					Required:    true,
+					MinValue:    &retentionMinDays,
+					MaxValue:    retentionMaxDays,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "channel",
+					Description: "The channel to apply the policy to (leave blank for current channel)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "digest",
+			Description:              "Post a weekly activity summary to a channel",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether the weekly digest should be posted",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "channel",
+					Description: "The channel to post the digest in (leave blank for current channel)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "logchannel",
+			Description:              "Log every fixed link to a channel",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether fixed links should be logged",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to log to (leave blank for current channel)",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "translate",
+			Description:              "Translate Twitter posts through FxTwitter",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether tweets should be translated",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "language",
+					Description: "Two-letter language code to translate to (default: en)",
+					Required:    false,
+					MinLength:   &languageCodeLength,
+					MaxLength:   languageCodeLength,
+				},
+			},
+		},
+		{
+			Name:                     "linklimit",
+			Description:              "Set how many links per message are fixed",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max",
+					Description: fmt.Sprintf("Maximum links fixed per message (default %d)", DEFAULT_LINK_LIMIT),
+					Required:    true,
+					MinValue:    &linkLimitMin,
+					MaxValue:    linkLimitMax,
+				},
+			},
+		},
+		{
+			Name:                     "nofix",
+			Description:              "Set the keyword that stops FixEmbed from fixing a message",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "keyword",
+					Description: fmt.Sprintf("Messages starting with this word are skipped (default: %s)", DEFAULT_OPT_OUT_KEYWORD),
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "optout",
+			Description: "Stop FixEmbed from fixing your links in every server",
+		},
+		{
+			Name:        "optin",
+			Description: "Let FixEmbed fix your links again",
+		},
+		{
+			Name:                     "nsfw",
+			Description:              "Choose how links in NSFW channels are handled",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "What to do with links posted in NSFW channels",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Fix them like anywhere else", Value: NSFW_MODE_FIX},
+						{Name: "Leave them alone", Value: NSFW_MODE_SKIP},
+						{Name: "Fix them to direct media links", Value: NSFW_MODE_DIRECT},
+					},
+				},
+			},
+		},
+		{
+			Name:        "pingme",
+			Description: "Choose whether your reposts ping you",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Whether the \"Sent by\" line should mention you",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Follow the server's setting", Value: "default"},
+						{Name: "Always ping me", Value: "always"},
+						{Name: "Never ping me", Value: "never"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "ignore",
+			Description:              "Manage accounts whose messages FixEmbed never rewrites",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Stop fixing links posted by a user or bot",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "The user or bot to ignore",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Fix links posted by a user or bot again",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "The user or bot to stop ignoring",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the ignored accounts",
+				},
+			},
+		},
+		{
+			Name:                     "channelsettings",
+			Description:              "Override the server settings in a channel",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Override settings in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "mention_users",
+							Description: "Mention the poster in this channel",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "delete_original",
+							Description: "Delete the original message in this channel",
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The channel to override (leave blank for current channel)",
+							ChannelTypes: trackedChannelTypes,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Make a channel follow the server settings again",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The channel to clear (leave blank for current channel)",
+							ChannelTypes: trackedChannelTypes,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the channels with overrides",
+				},
+			},
+		},
+		{
+			Name:                     "mastodon",
+			Description:              "Manage the Mastodon instances FixEmbed recognises",
+			DefaultMemberPermissions: &manageGuildPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Treat links from an instance as Mastodon posts",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "domain",
+							Description: "Instance domain, e.g. mastodon.social",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop recognising links from an instance",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "domain",
+							Description: "Instance domain, e.g. mastodon.social",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List the registered Mastodon instances",
+				},
+			},
+		},
+	}
+
+	if topggToken != "" {
+		commands = append(commands, &discordgo.ApplicationCommand{
+			Name:        "vote",
+			Description: "Vote for FixEmbed on top.gg",
+		})
+	}
+
+	// commands that also work outside the guilds FixEmbed is in (DMs, or installed to a user) are global
+	contexts := []discordgo.InteractionContextType{discordgo.InteractionContextGuild, discordgo.InteractionContextBotDM, discordgo.InteractionContextPrivateChannel}
+	integrationTypes := []discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall, discordgo.ApplicationIntegrationUserInstall}
+	globalCommands = []*discordgo.ApplicationCommand{
+		{
+			Name:             "fix",
+			Description:      "Fix a link right now, even where FixEmbed is deactivated",
+			Contexts:         &contexts,
+			IntegrationTypes: &integrationTypes,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "The link to fix",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "private",
+					Description: "Only show the fixed link to you",
+				},
+			},
+		},
+		{
+			Name:             "Fix Links",
+			Type:             discordgo.MessageApplicationCommand,
+			Contexts:         &contexts,
+			IntegrationTypes: &integrationTypes,
+		},
+	}
+	localizeCommands(commands)
+	localizeCommands(globalCommands)
+	return commands, globalCommands
+}
+
 func main() {
 	// Load .env
 	_ = godotenv.Load()
-	token := os.Getenv("BOT_TOKEN")
+	if path := configPath(os.Args[1:]); path != "" {
+		cfg.ConfigFile = path
+		if err := loadConfigFile(path); err != nil {
+			log.Fatalf("Error loading config file: %v", err)
+		}
+		logf(LOG_INFO, "Loaded config from %s", path)
+	}
+	args, err := parseFlags(os.Args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.Fatalf("Error parsing flags: %v", err)
+	}
+	setLogLevel(cfg.LogLevel)
+	token, err := botToken()
+	if err != nil {
+		log.Fatalf("Error reading the token file: %v", err)
+	}
 	if token == "" {
 		log.Fatalln("BOT_TOKEN is not set in environment")
 	}
 	// single owner ID for owner-only commands (set via OWNER_ID environment variable)
 	ownerID = os.Getenv("OWNER_ID")
 	if ownerID == "" {
-		log.Println("Warning: OWNER_ID is not set; owner-only command will be disabled")
+		logf(LOG_WARN, "Warning: OWNER_ID is not set; owner-only command will be disabled")
+	}
+
+	// self-hosters can add or override services without patching the registry
+	servicesFile := os.Getenv("SERVICES_FILE")
+	if servicesFile == "" {
+		servicesFile = "services.json"
+	}
+	if n, err := loadCustomServices(servicesFile); err == nil {
+		logf(LOG_INFO, "Loaded %d custom service(s) from %s", n, servicesFile)
+	} else if !os.IsNotExist(err) {
+		log.Fatalf("Error loading custom services: %v", err)
+	}
+	applyRuntimeConfig()
+
+	if v := os.Getenv("METADATA_CACHE_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil {
+			metadataCacheTTL = ttl
+		} else {
+			logf(LOG_WARN, "METADATA_CACHE_TTL: %v", err)
+		}
+	}
+	if v := os.Getenv("METADATA_CACHE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			metadataCacheSize = size
+		} else {
+			logf(LOG_WARN, "METADATA_CACHE_SIZE: %v", err)
+		}
 	}
+	topggToken = os.Getenv("TOPGG_TOKEN")
+	premiumSKU = os.Getenv("PREMIUM_SKU_ID")
 
-	db, err := initDB("fixembed_data.db")
+	// register-commands, unregister-commands and list-commands exit without starting the bot
+	if runCLI(token, args) {
+		return
+	}
+
+	sqlite, err := openSQLiteStore(cfg.DBPath)
 	if err != nil {
 		log.Fatalf("DB init error: %v", err)
 	}
-	defer db.Close()
+	defer sqlite.Close()
+	store = sqlite
+
+	// optional; without it guild settings and channel states live in this process only
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		if err := initRedis(url); err != nil {
+			logf(LOG_WARN, "Warning: Redis is unavailable, keeping state in memory: %v", err)
+		} else {
+			logf(LOG_INFO, "Sharing guild settings and channel states through Redis")
+		}
+	}
 
 	intents := discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuilds
 	dg, err := discordgo.New("Bot " + token)
@@ -1398,80 +2384,73 @@ func main() {
 		log.Fatalf("Error creating Discord session: %v", err)
 	}
 	dg.Identify.Intents = intents
+	if cfg.ShardCount > 1 {
+		// Discord sends each shard only its own guilds' events, so no message is fixed twice
+		dg.ShardID = cfg.ShardID
+		dg.ShardCount = cfg.ShardCount
+		logf(LOG_INFO, "Running shard %d of %d", cfg.ShardID, cfg.ShardCount)
+		if !cfg.Primary {
+			logf(LOG_INFO, "Scheduled jobs are off in this instance; set PRIMARY_INSTANCE=true in exactly one of them")
+		}
+	}
 
 	// Add handlers
 	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		log.Printf("We have logged in as %s", s.State.User.Username)
+		logf(LOG_INFO, "We have logged in as %s", s.State.User.Username)
 		// load channel states and settings now that session.State is populated
-		if err := loadChannelStates(db, s); err != nil {
-			log.Printf("Error loading channel states: %v", err)
-		}
-		if err := loadSettings(db); err != nil {
-			log.Printf("Error loading settings: %v", err)
-		}
+		loadCaches(store, s)
+		loadEntitlements(s)
 
 		// Register application commands per-guild to mirror Python client.tree.sync behaviour.
-		commands := []*discordgo.ApplicationCommand{
-			{
-				Name:        "activate",
-				Description: "Activate link processing in this channel or another channel",
-				Options: []*discordgo.ApplicationCommandOption{
-					{
-						Type:        discordgo.ApplicationCommandOptionChannel,
-						Name:        "channel",
-						Description: "The channel to activate link processing in (leave blank for current channel)",
-						Required:    false,
-					},
-				},
-			},
-			{
-				Name:        "deactivate",
-				Description: "Deactivate link processing in this channel or another channel",
-				Options: []*discordgo.ApplicationCommandOption{
-					{
-						Type:        discordgo.ApplicationCommandOptionChannel,
-						Name:        "channel",
-						Description: "The channel to deactivate link processing in (leave blank for current channel)",
-						Required:    false,
-					},
-				},
-			},
-			{
-				Name:        "about",
-				Description: "Show information about the bot",
-			},
-			{
-				Name:        "settings",
-				Description: "Configure FixEmbed's settings",
-			},
-			{
-				Name:        "owner",
-				Description: "Owner-only command: lists guilds the bot is in",
-			},
+		commands, globalCommands := applicationCommands()
+		registerConfigurationCommands(commands)
+		commandList = append(append([]*discordgo.ApplicationCommand{}, commands...), globalCommands...)
+		if !cfg.RegisterCommands {
+			// commands are managed with register-commands instead
+			return
+		}
+		if _, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, "", globalCommands); err != nil {
+			logf(LOG_WARN, "Warning: failed to sync global commands: %v", err)
 		}
 
+		setGuildCommands(commands)
 		created := 0
 		// Force-sync commands for each guild to avoid duplicates left from previous runs.
-		// ApplicationCommandBulkOverwrite replaces the guild's commands with exactly `commands`.
 		for _, g := range s.State.Guilds {
-			_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, g.ID, commands)
-			if err != nil {
-				log.Printf("Warning: failed to sync commands in guild %s: %v", g.ID, err)
-			} else {
+			if syncGuildCommands(s, g.ID) {
 				created++
 			}
 		}
-		log.Printf("Synchronized commands across %d guild(s)", created)
+		logf(LOG_INFO, "Synchronized commands across %d guild(s)", created)
 	})
 
 	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		onInteractionCreate(db, s, i)
+		auditInteraction(store, s, i, func() { onInteractionCreate(store, s, i) })
 	})
 	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		onMessageCreate(db, s, m)
+		onMessageCreate(store, s, m)
 	})
 	dg.AddHandler(func(s *discordgo.Session, g *discordgo.GuildCreate) {
-		onGuildCreate(db, s, g)
+		onGuildCreate(store, s, g)
+	})
+	dg.AddHandler(func(s *discordgo.Session, e *discordgo.EntitlementCreate) {
+		trackEntitlement(e.Entitlement, true)
+	})
+	dg.AddHandler(func(s *discordgo.Session, e *discordgo.EntitlementUpdate) {
+		// an update with an end date is a cancelled or lapsed subscription
+		trackEntitlement(e.Entitlement, e.EndsAt == nil || e.EndsAt.After(time.Now()))
+	})
+	dg.AddHandler(func(s *discordgo.Session, e *discordgo.EntitlementDelete) {
+		trackEntitlement(e.Entitlement, false)
+	})
+	dg.AddHandler(func(s *discordgo.Session, c *discordgo.ChannelCreate) {
+		onChannelCreate(store, s, c)
+	})
+	dg.AddHandler(func(s *discordgo.Session, d *discordgo.MessageDelete) {
+		onMessageDelete(store, s, d)
+	})
+	dg.AddHandler(func(s *discordgo.Session, u *discordgo.MessageUpdate) {
+		onMessageUpdate(store, s, u)
 	})
 
 	// Open websocket
@@ -1484,15 +2463,28 @@ func main() {
 
 	// Start status rotator
 	stopStatus := make(chan struct{})
-	go startStatusRotator(dg, stopStatus)
+	go startStatusRotator(store, dg, stopStatus)
+	go startStoreSync(store, dg, stopStatus)
+	go startRedisSubscriber(stopStatus)
+	// with several instances on one database, only the primary runs the scheduled jobs
+	if cfg.Primary {
+		go startRetentionSweeper(store, dg, stopStatus)
+		go startDigestScheduler(store, dg, stopStatus)
+		go startTopggPoster(dg, stopStatus)
+	}
 
-	// Wait for CTRL-C or SIGTERM
-	log.Println("Bot is now running. Press CTRL-C to exit.")
+	// Wait for CTRL-C or SIGTERM; SIGHUP reloads the config file
+	logf(LOG_INFO, "Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	for sig := range sc {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig()
+	}
 
 	// Cleanup
 	close(stopStatus)
-	log.Println("Shutting down.")
+	logf(LOG_INFO, "Shutting down.")
 }
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newTestDB opens a private in-memory database with FixEmbed's schema.
+func newTestDB(t *testing.T) *sqliteStore {
+	t.Helper()
+	st, err := openSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := store
+	store = st
+	t.Cleanup(func() {
+		store = previous
+		st.Close()
+	})
+	return st
+}
+
+// fakeDiscord stands in for Discord's REST API, recording every call as "METHOD /path" along with its body.
+type fakeDiscord struct {
+	sync.Mutex
+	requests []string
+	bodies   []string
+	respond  func(r *http.Request) (int, string)
+}
+
+func (f *fakeDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	call := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion)
+	var sent []byte
+	if r.Body != nil {
+		sent, _ = io.ReadAll(r.Body)
+	}
+	f.Lock()
+	f.requests = append(f.requests, call)
+	f.bodies = append(f.bodies, string(sent))
+	f.Unlock()
+	status, body := http.StatusOK, "{}"
+	if f.respond != nil {
+		status, body = f.respond(r)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+func (f *fakeDiscord) calls() []string {
+	f.Lock()
+	defer f.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// body returns the body of the latest call made as "METHOD /path", or "" if there was none.
+func (f *fakeDiscord) body(call string) string {
+	f.Lock()
+	defer f.Unlock()
+	for idx := len(f.requests) - 1; idx >= 0; idx-- {
+		if f.requests[idx] == call {
+			return f.bodies[idx]
+		}
+	}
+	return ""
+}
+
+// slashCommand is a use of the /name command in guild 1, channel 20, by member 5.
+func slashCommand(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "900",
+		Token:     "token",
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   "1",
+		ChannelID: "20",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "5"}},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}}
+}
+
+// option is a command option; a nil value makes it a subcommand holding sub.
+func option(name string, value interface{}, sub ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	opt := &discordgo.ApplicationCommandInteractionDataOption{Name: name, Value: value, Options: sub}
+	switch value.(type) {
+	case nil:
+		opt.Type = discordgo.ApplicationCommandOptionSubCommand
+	case string:
+		opt.Type = discordgo.ApplicationCommandOptionString
+	case bool:
+		opt.Type = discordgo.ApplicationCommandOptionBoolean
+	case float64:
+		opt.Type = discordgo.ApplicationCommandOptionInteger
+	}
+	return opt
+}
+
+// newTestSession returns a session whose REST calls go to a fakeDiscord; respond may be nil to answer 200 {}.
+func newTestSession(t *testing.T, respond func(r *http.Request) (int, string)) (*discordgo.Session, *fakeDiscord) {
+	t.Helper()
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeDiscord{respond: respond}
+	s.Client = &http.Client{Transport: fake}
+	s.MaxRestRetries = 0
+	s.State.User = &discordgo.User{ID: "1", Username: "FixEmbed"}
+	return s, fake
+}
+
+// postMessage runs onMessageCreate on content posted by member 5 in guild 1, channel 20,
+// with settings as the guild's cached settings. Fixers count as up.
+func postMessage(t *testing.T, db *sqliteStore, s *discordgo.Session, settings *GuildSettings, content string) {
+	t.Helper()
+	botSettings.Lock()
+	botSettings.m[1] = settings
+	botSettings.Unlock()
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+	// guild 1 has no PluralKit, so nothing waits for it to proxy
+	pluralKitGuilds.Lock()
+	pluralKitGuilds.m["1"] = pluralKitPresence{present: false, checked: time.Now()}
+	pluralKitGuilds.Unlock()
+	// tests post far more often than the send rate limit allows
+	tsMutex.Lock()
+	times = nil
+	tsMutex.Unlock()
+	fixerHealthCache.Lock()
+	for _, host := range []string{"fxtwitter.com", "fixupx.com"} {
+		fixerHealthCache.m[host] = fixerHealth{up: true, checked: time.Now()}
+	}
+	fixerHealthCache.Unlock()
+
+	onMessageCreate(db, s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "70",
+		ChannelID: "20",
+		GuildID:   "1",
+		Content:   content,
+		Author:    &discordgo.User{ID: "5", Username: "someone"},
+	}})
+}
+
+func TestOnMessageCreatePreserveText(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	settings.PreserveText = true
+	postMessage(t, db, s, settings, "look at https://x.com/someone/status/123 wow")
+
+	body := fake.body("POST /channels/20/messages")
+	if !strings.Contains(body, `look at [Twitter • someone](https://fixupx.com/someone/status/123) wow`) {
+		t.Errorf("repost %s does not keep the message text", body)
+	}
+	if !slices.Contains(fake.calls(), "DELETE /channels/20/messages/70") {
+		t.Errorf("calls %v do not delete the original", fake.calls())
+	}
+}
+
+func TestOnMessageCreateMentions(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	settings.PreserveText = true
+	postMessage(t, db, s, settings, "@everyone https://x.com/some_one/status/123")
+
+	body := fake.body("POST /channels/20/messages")
+	if !strings.Contains(body, `Twitter • some\\_one`) {
+		t.Errorf("repost %s does not escape the handle", body)
+	}
+	if !strings.Contains(body, `"allowed_mentions":{"parse":null,"users":["5"]`) {
+		t.Errorf("repost %s may ping more than its author", body)
+	}
+}
+
+func TestOnMessageCreateWebhooks(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	post := func() {
+		// postMessage's author stands in for the webhook
+		botSettings.Lock()
+		botSettings.m[1] = settings
+		botSettings.Unlock()
+		onMessageCreate(db, s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID: "73", ChannelID: "20", GuildID: "1", WebhookID: "5", Content: "https://x.com/a/status/1",
+			Author: &discordgo.User{ID: "5", Username: "feed", Bot: true},
+		}})
+	}
+	postMessage(t, db, s, settings, "") // sets up guild 1
+
+	post()
+	if slices.Contains(fake.calls(), "POST /channels/20/messages") {
+		t.Fatal("a webhook message was fixed with webhooks off")
+	}
+	settings.ProcessWebhooks = true
+	post()
+	if !slices.Contains(fake.calls(), "POST /channels/20/messages") {
+		t.Errorf("a webhook message was not fixed with webhooks on: %v", fake.calls())
+	}
+}
+
+func TestChannelState(t *testing.T) {
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "30", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "31", GuildID: "1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "30"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "32", GuildID: "1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "30"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "34", GuildID: "1", Type: discordgo.ChannelTypeGuildForum})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "35", GuildID: "1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "34"})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	channelStates.Lock()
+	channelStates.m = map[int64]bool{30: false, 32: true, 34: true}
+	channelStates.Unlock()
+
+	for _, tt := range []struct {
+		channel     string
+		enabled, ok bool
+	}{
+		{"30", false, true},
+		{"31", false, true}, // inherits its parent
+		{"32", true, true},  // overrides its parent
+		{"33", false, false},
+		{"35", true, true}, // a forum post inherits its forum
+	} {
+		if enabled, ok := channelState(s, tt.channel); enabled != tt.enabled || ok != tt.ok {
+			t.Errorf("channelState(%s) = %t, %t; want %t, %t", tt.channel, enabled, ok, tt.enabled, tt.ok)
+		}
+	}
+}
+
+func TestIsTrackedChannel(t *testing.T) {
+	for typ, want := range map[discordgo.ChannelType]bool{
+		discordgo.ChannelTypeGuildText:         true,
+		discordgo.ChannelTypeGuildNews:         true,
+		discordgo.ChannelTypeGuildForum:        true,
+		discordgo.ChannelTypeGuildVoice:        false,
+		discordgo.ChannelTypeGuildPublicThread: false,
+	} {
+		if got := isTrackedChannel(&discordgo.Channel{Type: typ}); got != want {
+			t.Errorf("isTrackedChannel(type %d) = %t, want %t", typ, got, want)
+		}
+	}
+}
+
+func TestChannelSelect(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "30", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	pick := func() string {
+		click := componentClick("channel_select")
+		click.Data = discordgo.MessageComponentInteractionData{CustomID: "channel_select", Values: []string{"30"}}
+		click.Message = &discordgo.Message{}
+		onInteractionCreate(db, s, click)
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	// a channel without a stored state is active, so the first pick deactivates it
+	if body := pick(); !strings.Contains(body, "Deactivated \\u003c#30\\u003e") {
+		t.Errorf("first pick = %s", body)
+	}
+	if body := pick(); !strings.Contains(body, "Activated \\u003c#30\\u003e") {
+		t.Errorf("second pick = %s", body)
+	}
+	if enabled, ok := channelState(s, "30"); !ok || !enabled {
+		t.Errorf("channel state = %t, %t; want activated", enabled, ok)
+	}
+}
+
+func TestRenderStatus(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	s.State.GuildAdd(&discordgo.Guild{ID: "2"})
+	msg := &discordgo.Message{GuildID: "1", ChannelID: "20", Author: &discordgo.User{ID: "5"}}
+	for range 3 {
+		if err := recordLinkFix(db, msg, "Twitter"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := renderStatus(db, s, "in {guilds} servers | v{version}"), "in 2 servers | v"+VERSION; got != want {
+		t.Errorf("renderStatus = %q, want %q", got, want)
+	}
+	if got := renderStatus(db, s, "{fixed_today} links fixed today"); got != "3 links fixed today" {
+		t.Errorf("renderStatus = %q, want today's 3 links", got)
+	}
+	if got, want := renderStatus(db, s, "{services} services"), strconv.Itoa(len(services))+" services"; got != want {
+		t.Errorf("renderStatus = %q, want %q", got, want)
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	savedStatuses, savedLive := statuses, cfg.LiveStatuses
+	t.Cleanup(func() { statuses, cfg.LiveStatuses = savedStatuses, savedLive })
+	statuses = []string{"a", "b"}
+
+	cfg.LiveStatuses = true
+	var got []string
+	for tick := range 6 {
+		got = append(got, statusAt(tick))
+	}
+	if want := []string{"a", liveStatuses[0], "b", liveStatuses[1], "a", liveStatuses[0]}; !slices.Equal(got, want) {
+		t.Errorf("rotation = %q, want %q", got, want)
+	}
+	cfg.LiveStatuses = false
+	if got := statusAt(1); got != "b" {
+		t.Errorf("statusAt(1) = %q without live statuses, want b", got)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markdown whose links are clearly not meant to be embedded: code blocks and spans, and quotes
+var (
+	codeBlockRe  = regexp.MustCompile("(?s)```.*?(?:```|$)")
+	codeSpanRe   = regexp.MustCompile("``[^`]+``|`[^`\n]+`")
+	blockQuoteRe = regexp.MustCompile(`(?ms)^>>> .*`) // quotes the rest of the message
+	lineQuoteRe  = regexp.MustCompile(`(?m)^> .*$`)
+)
+
+// maskMarkdown blanks out code and quoted text so the link patterns don't see links in them.
+// Masked text is replaced byte for byte, so offsets into the result match the input.
+func maskMarkdown(content string) string {
+	blank := func(s string) string { return strings.Repeat(" ", len(s)) }
+	content = codeBlockRe.ReplaceAllStringFunc(content, blank)
+	content = codeSpanRe.ReplaceAllStringFunc(content, blank)
+	content = blockQuoteRe.ReplaceAllStringFunc(content, blank)
+	return lineQuoteRe.ReplaceAllStringFunc(content, blank)
+}
+
+var markdownSpecialRe = regexp.MustCompile("([\\\\*_~`|>\\[\\]()#<@:-])")
+
+// escapeMarkdown makes untrusted text (handles, subreddit names) render literally inside
+// our own markdown, e.g. so a crafted name can't close the masked link early.
+func escapeMarkdown(text string) string {
+	return markdownSpecialRe.ReplaceAllString(text, `\$1`)
+}
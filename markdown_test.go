@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"see https://x.com/a/status/1":                       "see https://x.com/a/status/1",
+		"`https://x.com/a/status/1` and https://x.com/b":     "                           and https://x.com/b",
+		"```\nhttps://x.com/a/status/1\n``` after":           strings.Repeat(" ", 33) + "after",
+		"```unclosed https://x.com/a/status/1":               "                                    ",
+		"> quoted https://x.com/a\nhttps://x.com/b":          "                        \nhttps://x.com/b",
+		"first https://x.com/b\n>>> quoted\nhttps://x.com/a": "first https://x.com/b\n                          ",
+	}
+	for content, want := range tests {
+		got := maskMarkdown(content)
+		if got != want {
+			t.Errorf("maskMarkdown(%q) = %q, want %q", content, got, want)
+		}
+		if len(got) != len(content) {
+			t.Errorf("maskMarkdown(%q) changed the length", content)
+		}
+	}
+	if got := maskMarkdown("`é` https://x.com/a"); !strings.HasSuffix(got, "https://x.com/a") {
+		t.Errorf("masking multibyte code moved the link: %q", got)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"someone":          "someone",
+		"some_one":         `some\_one`,
+		"a](https://evil)": `a\]\(https\://evil\)`,
+		"<@123>":           `\<\@123\>`,
+	}
+	for text, want := range tests {
+		if got := escapeMarkdown(text); got != want {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Frontend that proxies posts from any Mastodon instance (override with MASTODON_FIXER_DOMAIN)
+var mastodonFixerDomain = "fxmastodon.net"
+
+// Matches the instance-relative part of a post link, e.g. mastodon.social/@user/123
+var mastodonPostRe = regexp.MustCompile(`^[^/]+/@([A-Za-z0-9_]+(?:@[A-Za-z0-9.-]+)?)/[0-9]+`)
+
+var mastodonDomainRe = regexp.MustCompile(`^[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}$`)
+
+// normalizeInstanceDomain accepts "mastodon.social", "https://Mastodon.social/" and similar.
+func normalizeInstanceDomain(input string) (string, bool) {
+	d := strings.ToLower(strings.TrimSpace(input))
+	d = strings.TrimPrefix(d, "https://")
+	d = strings.TrimPrefix(d, "http://")
+	if idx := strings.Index(d, "/"); idx >= 0 {
+		d = d[:idx]
+	}
+	return d, mastodonDomainRe.MatchString(d)
+}
+
+func handleMastodonCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Mastodon Instances",
+		Color: accentColor(i.GuildID, 0x6364FF),
+	}
+	respond := func() {
+		createFooter(embed, s)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+				Flags:  1 << 6, // ephemeral
+			},
+		})
+	}
+
+	if i.GuildID == "" {
+		embed.Description = "Mastodon instances can only be configured in a server."
+		respond()
+		return
+	}
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	gs, _ := st.GuildSettings(gidInt)
+	updated := *defaultGuildSettings()
+	if gs != nil {
+		updated = *gs
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name == "list" {
+		if len(updated.MastodonInstances) == 0 {
+			embed.Description = "No instances registered. Add one with `/mastodon add`."
+		} else {
+			embed.Description = "- " + strings.Join(updated.MastodonInstances, "\n- ")
+		}
+		respond()
+		return
+	}
+
+	domain, ok := normalizeInstanceDomain(sub.Options[0].StringValue())
+	if !ok {
+		embed.Description = fmt.Sprintf("❌ `%s` doesn't look like an instance domain.", sub.Options[0].StringValue())
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+
+	instances := make([]string, 0, len(updated.MastodonInstances)+1)
+	for _, inst := range updated.MastodonInstances {
+		if inst != domain {
+			instances = append(instances, inst)
+		}
+	}
+	switch sub.Name {
+	case "add":
+		instances = append(instances, domain)
+		// enable the service along with the first instance so links start working right away
+		if len(updated.MastodonInstances) == 0 && !slices.Contains(updated.EnabledServices, "Mastodon") {
+			updated.EnabledServices = append(append([]string{}, updated.EnabledServices...), "Mastodon")
+		}
+		embed.Description = fmt.Sprintf("✅ Links from `%s` will now be fixed.", domain)
+	case "remove":
+		embed.Description = fmt.Sprintf("❌ Links from `%s` will no longer be fixed.", domain)
+	}
+	updated.MastodonInstances = instances
+
+	err := st.UpdateGuildColumn(gidInt, "mastodon_instances", formatStoredList(instances))
+	if err == nil {
+		err = st.UpdateSetting(gidInt, updated.EnabledServices, updated.MentionUsers, updated.DeleteOriginal)
+	}
+	if err != nil {
+		logf(LOG_WARN, "Error updating Mastodon instances for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the Mastodon instances."
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	cacheGuildSettings(gidInt, &updated)
+	respond()
+}
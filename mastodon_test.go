@@ -0,0 +1,89 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeInstanceDomain(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"mastodon.social", "mastodon.social", true},
+		{" https://Mastodon.Social/@someone ", "mastodon.social", true},
+		{"http://fosstodon.org", "fosstodon.org", true},
+		{"localhost", "localhost", false},
+		{"not a domain", "not a domain", false},
+	}
+	for _, tt := range tests {
+		if got, ok := normalizeInstanceDomain(tt.input); got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeInstanceDomain(%q) = %q, %t; want %q, %t", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMastodonPattern(t *testing.T) {
+	settings := defaultGuildSettings()
+	settings.MastodonInstances = []string{"mastodon.social", "hachyderm.io"}
+	re := regexp.MustCompile(`^(?:` + servicePattern(settings) + `)$`)
+	for link, want := range map[string]bool{
+		"mastodon.social/@someone/123":              true,
+		"hachyderm.io/@someone@mastodon.social/123": true,
+		"mastodon.social/@someone":                  false,
+		"mastodonxsocial/@someone/123":              false,
+		"other.example/@someone/123":                false,
+	} {
+		if re.MatchString(link) != want {
+			t.Errorf("%s matched: %t, want %t", link, !want, want)
+		}
+	}
+	if mm := mastodonPostRe.FindStringSubmatch("hachyderm.io/@someone@mastodon.social/123"); len(mm) < 2 || mm[1] != "someone@mastodon.social" {
+		t.Errorf("mastodonPostRe took %q as the user", mm)
+	}
+}
+
+func TestStoredList(t *testing.T) {
+	for _, list := range [][]string{{"Twitter"}, {"Twitter", "Mastodon"}, {"mastodon.social", "hachyderm.io"}} {
+		if got := parseStoredList(formatStoredList(list)); !slices.Equal(got, list) {
+			t.Errorf("round trip of %q = %q", list, got)
+		}
+	}
+	if got := parseStoredList(`['Twitter', 'Pixiv']`); !slices.Equal(got, []string{"Twitter", "Pixiv"}) {
+		t.Errorf("parseStoredList of a Python list = %q", got)
+	}
+	if got := parseStoredList(""); got != nil {
+		t.Errorf("parseStoredList(\"\") = %q, want nil", got)
+	}
+}
+
+func TestHandleMastodonCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	run := func(sub, domain string) string {
+		handleMastodonCommand(db, s, slashCommand("mastodon", option(sub, nil, option("domain", domain))))
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	run("add", "https://Mastodon.Social/")
+	gs, _ := db.GuildSettings(1)
+	if !slices.Equal(gs.MastodonInstances, []string{"mastodon.social"}) || !slices.Contains(gs.EnabledServices, "Mastodon") {
+		t.Fatalf("after add: %+v", gs)
+	}
+	run("add", "hachyderm.io")
+	run("add", "mastodon.social") // already there: not added twice
+	if reply := run("add", "not a domain"); !strings.Contains(reply, "doesn't look like an instance domain") {
+		t.Errorf("invalid domain answered with %s", reply)
+	}
+	run("remove", "mastodon.social")
+	gs, _ = db.GuildSettings(1)
+	if !slices.Equal(gs.MastodonInstances, []string{"hachyderm.io"}) {
+		t.Errorf("instances = %q, want only hachyderm.io", gs.MastodonInstances)
+	}
+	if !slices.Contains(availableServices(gs), "Mastodon") || slices.Contains(availableServices(defaultGuildSettings()), "Mastodon") {
+		t.Errorf("Mastodon should only be available with an instance")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Fixer API lookups are remembered for metadataCacheTTL, up to metadataCacheSize posts
+// (override with METADATA_CACHE_TTL and METADATA_CACHE_SIZE)
+var (
+	metadataCacheTTL  = 10 * time.Minute
+	metadataCacheSize = 512
+)
+
+type metadataEntry struct {
+	link    string
+	meta    *PostMetadata
+	fetched time.Time
+}
+
+// LRU cache of post metadata keyed by canonical link
+var metadataCache = struct {
+	sync.Mutex
+	order *list.List // front = most recently used
+	m     map[string]*list.Element
+}{order: list.New(), m: make(map[string]*list.Element)}
+
+func cachedMetadata(link string) (*PostMetadata, bool) {
+	metadataCache.Lock()
+	defer metadataCache.Unlock()
+	el, ok := metadataCache.m[link]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*metadataEntry)
+	if time.Since(entry.fetched) > metadataCacheTTL {
+		metadataCache.order.Remove(el)
+		delete(metadataCache.m, link)
+		return nil, false
+	}
+	metadataCache.order.MoveToFront(el)
+	return entry.meta, true
+}
+
+func storeMetadata(link string, meta *PostMetadata) {
+	metadataCache.Lock()
+	defer metadataCache.Unlock()
+	if el, ok := metadataCache.m[link]; ok {
+		el.Value = &metadataEntry{link: link, meta: meta, fetched: time.Now()}
+		metadataCache.order.MoveToFront(el)
+		return
+	}
+	metadataCache.m[link] = metadataCache.order.PushFront(&metadataEntry{link: link, meta: meta, fetched: time.Now()})
+	for metadataCache.order.Len() > metadataCacheSize {
+		oldest := metadataCache.order.Back()
+		metadataCache.order.Remove(oldest)
+		delete(metadataCache.m, oldest.Value.(*metadataEntry).link)
+	}
+}
+
+// fetchMetadata looks a canonical link up through its service's API, reusing recent results
+// so repeated shares of the same post don't hit the fixer again.
+func (svc *Service) fetchMetadata(link string) (*PostMetadata, error) {
+	if meta, ok := cachedMetadata(link); ok {
+		return meta, nil
+	}
+	meta, err := svc.Metadata(link)
+	if err != nil {
+		return nil, err
+	}
+	if metadataCacheTTL > 0 && metadataCacheSize > 0 {
+		storeMetadata(link, meta)
+	}
+	return meta, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFetchMetadataCache(t *testing.T) {
+	calls := 0
+	svc := &Service{Name: "Test", Metadata: func(link string) (*PostMetadata, error) {
+		calls++
+		return &PostMetadata{URL: "https://" + link}, nil
+	}}
+
+	for range 2 {
+		if meta, err := svc.fetchMetadata("example.com/cached"); err != nil || meta.URL != "https://example.com/cached" {
+			t.Fatalf("fetchMetadata = %+v, %v", meta, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("the API was called %d times, want the second lookup cached", calls)
+	}
+
+	ttl := metadataCacheTTL
+	t.Cleanup(func() { metadataCacheTTL = ttl })
+	metadataCacheTTL = -time.Second
+	svc.fetchMetadata("example.com/cached")
+	if calls != 2 {
+		t.Errorf("an expired entry was served from the cache")
+	}
+}
+
+func TestMetadataCacheEviction(t *testing.T) {
+	size := metadataCacheSize
+	t.Cleanup(func() { metadataCacheSize = size })
+	metadataCacheSize = 3
+
+	for n := range 4 {
+		storeMetadata(fmt.Sprintf("example.com/%d", n), &PostMetadata{})
+		if n == 1 {
+			cachedMetadata("example.com/0") // used, so 1 is now the oldest
+		}
+	}
+	for link, want := range map[string]bool{"example.com/0": true, "example.com/1": false, "example.com/3": true} {
+		if _, ok := cachedMetadata(link); ok != want {
+			t.Errorf("%s cached: %t, want %t", link, ok, want)
+		}
+	}
+}
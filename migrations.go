@@ -0,0 +1,190 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Numbered schema migrations, "NNNN_name.sql", applied in order and recorded in schema_version.
+// Add a new file for every schema change; never edit one that has shipped.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		number, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.sql", entry.Name())
+		}
+		data, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(data)})
+	}
+	sort.Slice(migrations, func(a, b int) bool { return migrations[a].version < migrations[b].version })
+	for idx := 1; idx < len(migrations); idx++ {
+		if migrations[idx].version == migrations[idx-1].version {
+			return nil, fmt.Errorf("two migrations numbered %d", migrations[idx].version)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate brings the database schema up to date, one migration per transaction.
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, name TEXT, applied_at INTEGER)`)
+	if err != nil {
+		return err
+	}
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&current); err != nil {
+		return err
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		legacy, err := upgradeLegacySchema(db)
+		if err != nil {
+			return fmt.Errorf("upgrading the schema to the baseline: %w", err)
+		}
+		if legacy {
+			// the baseline is what upgradeLegacySchema just produced
+			if _, err := db.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (1, ?, ?)", migrations[0].name, time.Now().Unix()); err != nil {
+				return err
+			}
+			current = 1
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)", m.version, m.name, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		logf(LOG_INFO, "Applied migration %04d_%s", m.version, m.name)
+	}
+	return nil
+}
+
+// guild_settings columns added before migrations existed, in the order they were added
+var legacyGuildSettingsColumns = []string{
+	"mention_users BOOLEAN DEFAULT 1",
+	"delete_original BOOLEAN DEFAULT 1",
+	"digest_channel_id INTEGER DEFAULT 0",
+	"last_digest_at INTEGER DEFAULT 0",
+	"link_buttons BOOLEAN DEFAULT 0",
+	"mastodon_instances TEXT",
+	"frontends TEXT",
+	"direct_media BOOLEAN DEFAULT 0",
+	"translate_language TEXT",
+	"rich_embeds BOOLEAN DEFAULT 0",
+	"reupload_media BOOLEAN DEFAULT 0",
+	"preserve_text BOOLEAN DEFAULT 0",
+	"link_limit INTEGER",
+	"optout_keyword TEXT",
+	"process_webhooks BOOLEAN DEFAULT 0",
+	"nsfw_mode TEXT",
+	"log_channel_id INTEGER DEFAULT 0",
+	"simulate BOOLEAN DEFAULT 0",
+	"leaderboard BOOLEAN DEFAULT 0",
+	"locale TEXT",
+	"repost_template TEXT",
+	"embed_color INTEGER DEFAULT 0",
+	"attribution TEXT",
+	"dm_fallback BOOLEAN DEFAULT 1",
+}
+
+// upgradeLegacySchema brings a database created before migrations up to the baseline: the
+// tables it's missing are created and guild_settings gets the columns it's missing. It
+// reports false for a new, empty database, which the baseline migration sets up instead.
+func upgradeLegacySchema(db *sql.DB) (bool, error) {
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'guild_settings'").Scan(&tables); err != nil {
+		return false, err
+	}
+	if tables == 0 {
+		return false, nil
+	}
+
+	rows, err := db.Query("SELECT name FROM pragma_table_info('guild_settings')")
+	if err != nil {
+		return true, err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			existing[name] = true
+		}
+	}
+	rows.Close()
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return true, err
+	}
+	var dailyStats int
+	_ = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'daily_stats'").Scan(&dailyStats)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return true, err
+	}
+	defer tx.Rollback()
+	// the baseline only creates what isn't there, so it fills in any missing tables
+	if _, err := tx.Exec(migrations[0].sql); err != nil {
+		return true, err
+	}
+	for _, column := range legacyGuildSettingsColumns {
+		name, _, _ := strings.Cut(column, " ")
+		if existing[name] {
+			continue
+		}
+		if _, err := tx.Exec("ALTER TABLE guild_settings ADD COLUMN " + column); err != nil {
+			return true, err
+		}
+	}
+	if dailyStats == 0 {
+		// seed the bot-wide totals from the per-link rows collected so far
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO daily_stats (day, service, links)
+			SELECT date(created_at, 'unixepoch'), service, COUNT(*) FROM link_stats GROUP BY 1, 2`); err != nil {
+			return true, err
+		}
+	}
+	return true, tx.Commit()
+}
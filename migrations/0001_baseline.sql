@@ -0,0 +1,62 @@
+-- The schema as it was before migrations. Databases created before then are brought up to
+-- it by upgradeLegacySchema instead.
+
+CREATE TABLE IF NOT EXISTS channel_states (channel_id INTEGER PRIMARY KEY, state BOOLEAN);
+
+CREATE TABLE IF NOT EXISTS guild_settings (
+	guild_id INTEGER PRIMARY KEY,
+	enabled_services TEXT,
+	mention_users BOOLEAN,
+	delete_original BOOLEAN DEFAULT 1,
+	digest_channel_id INTEGER DEFAULT 0,
+	last_digest_at INTEGER DEFAULT 0,
+	link_buttons BOOLEAN DEFAULT 0,
+	mastodon_instances TEXT,
+	frontends TEXT,
+	direct_media BOOLEAN DEFAULT 0,
+	translate_language TEXT,
+	rich_embeds BOOLEAN DEFAULT 0,
+	reupload_media BOOLEAN DEFAULT 0,
+	preserve_text BOOLEAN DEFAULT 0,
+	link_limit INTEGER,
+	optout_keyword TEXT,
+	process_webhooks BOOLEAN DEFAULT 0,
+	nsfw_mode TEXT,
+	log_channel_id INTEGER DEFAULT 0,
+	simulate BOOLEAN DEFAULT 0,
+	leaderboard BOOLEAN DEFAULT 0,
+	locale TEXT,
+	repost_template TEXT,
+	embed_color INTEGER DEFAULT 0,
+	attribution TEXT,
+	dm_fallback BOOLEAN DEFAULT 1
+);
+
+-- Maps each fix message the bot posted back to the message it replaced
+CREATE TABLE IF NOT EXISTS fix_messages (message_id INTEGER PRIMARY KEY, channel_id INTEGER, guild_id INTEGER, original_id INTEGER, author_id INTEGER, created_at INTEGER);
+CREATE INDEX IF NOT EXISTS idx_fix_messages_original ON fix_messages (original_id);
+
+-- Users who opted out of link fixing everywhere
+CREATE TABLE IF NOT EXISTS opted_out_users (user_id INTEGER PRIMARY KEY, created_at INTEGER);
+CREATE TABLE IF NOT EXISTS user_preferences (user_id INTEGER PRIMARY KEY, mention BOOLEAN);
+
+-- Per-guild accounts whose messages are never rewritten
+CREATE TABLE IF NOT EXISTS ignored_users (guild_id INTEGER, user_id INTEGER, created_at INTEGER, PRIMARY KEY (guild_id, user_id));
+
+-- Per-channel overrides of guild settings; NULL columns follow the guild
+CREATE TABLE IF NOT EXISTS channel_settings (channel_id INTEGER PRIMARY KEY, guild_id INTEGER, mention_users BOOLEAN, delete_original BOOLEAN, updated_at INTEGER);
+
+-- Who changed which setting, and when
+CREATE TABLE IF NOT EXISTS settings_audit (id INTEGER PRIMARY KEY AUTOINCREMENT, guild_id INTEGER, user_id INTEGER, setting TEXT, old_value TEXT, new_value TEXT, created_at INTEGER);
+CREATE INDEX IF NOT EXISTS idx_settings_audit_guild ON settings_audit (guild_id, id);
+
+CREATE TABLE IF NOT EXISTS channel_retention (channel_id INTEGER PRIMARY KEY, days INTEGER);
+
+-- Stats: one row per fixed link, plus delivery problems for the weekly digest
+CREATE TABLE IF NOT EXISTS link_stats (id INTEGER PRIMARY KEY AUTOINCREMENT, guild_id INTEGER, channel_id INTEGER, user_id INTEGER, service TEXT, created_at INTEGER);
+CREATE INDEX IF NOT EXISTS idx_link_stats_guild ON link_stats (guild_id, created_at);
+CREATE TABLE IF NOT EXISTS bot_events (id INTEGER PRIMARY KEY AUTOINCREMENT, guild_id INTEGER, channel_id INTEGER, kind TEXT, detail TEXT, created_at INTEGER);
+CREATE INDEX IF NOT EXISTS idx_bot_events_guild ON bot_events (guild_id, created_at);
+
+-- Bot-wide links fixed per service per day (YYYY-MM-DD, UTC); kept after link_stats rows are gone
+CREATE TABLE IF NOT EXISTS daily_stats (day TEXT, service TEXT, links INTEGER, PRIMARY KEY (day, service));
@@ -0,0 +1,42 @@
+-- store_version is bumped on every write to a cached table, so instances sharing the
+-- database know when to reload their caches (see startStoreSync).
+
+CREATE TABLE IF NOT EXISTS store_version (id INTEGER PRIMARY KEY CHECK (id = 1), version INTEGER);
+INSERT OR IGNORE INTO store_version (id, version) VALUES (1, 0);
+
+CREATE TRIGGER IF NOT EXISTS bump_guild_settings_INSERT AFTER INSERT ON guild_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_guild_settings_UPDATE AFTER UPDATE ON guild_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_guild_settings_DELETE AFTER DELETE ON guild_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_states_INSERT AFTER INSERT ON channel_states
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_states_UPDATE AFTER UPDATE ON channel_states
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_states_DELETE AFTER DELETE ON channel_states
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_settings_INSERT AFTER INSERT ON channel_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_settings_UPDATE AFTER UPDATE ON channel_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_channel_settings_DELETE AFTER DELETE ON channel_settings
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_opted_out_users_INSERT AFTER INSERT ON opted_out_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_opted_out_users_UPDATE AFTER UPDATE ON opted_out_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_opted_out_users_DELETE AFTER DELETE ON opted_out_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_user_preferences_INSERT AFTER INSERT ON user_preferences
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_user_preferences_UPDATE AFTER UPDATE ON user_preferences
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_user_preferences_DELETE AFTER DELETE ON user_preferences
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_ignored_users_INSERT AFTER INSERT ON ignored_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_ignored_users_UPDATE AFTER UPDATE ON ignored_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
+CREATE TRIGGER IF NOT EXISTS bump_ignored_users_DELETE AFTER DELETE ON ignored_users
+	BEGIN UPDATE store_version SET version = version + 1 WHERE id = 1; END;
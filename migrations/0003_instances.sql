@@ -0,0 +1,10 @@
+-- Every running instance records itself here on each store sync, so the instances sharing
+-- the database can tell which of them runs the scheduled jobs (see primaryProblem).
+
+CREATE TABLE IF NOT EXISTS instances (
+	id TEXT PRIMARY KEY,
+	shard_id INTEGER,
+	shard_count INTEGER,
+	is_primary INTEGER,
+	seen_at INTEGER
+);
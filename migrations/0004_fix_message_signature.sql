@@ -0,0 +1,4 @@
+-- The fixed links each repost was built from (see repostSignature), so an edit that leaves
+-- them alone is recognised after a restart too.
+
+ALTER TABLE fix_messages ADD COLUMN signature TEXT NOT NULL DEFAULT '';
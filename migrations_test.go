@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := migrations[len(migrations)-1].version
+
+	tests := []struct {
+		name  string
+		setup []string
+	}{
+		{name: "empty database"},
+		{
+			// the schema initDB created before migrations existed, with a guild already configured
+			name: "legacy database",
+			setup: []string{
+				`CREATE TABLE channel_states (channel_id INTEGER PRIMARY KEY, state BOOLEAN)`,
+				`CREATE TABLE guild_settings (guild_id INTEGER PRIMARY KEY, enabled_services TEXT, mention_users BOOLEAN, delete_original BOOLEAN DEFAULT 1)`,
+				`CREATE TABLE link_stats (id INTEGER PRIMARY KEY AUTOINCREMENT, guild_id INTEGER, channel_id INTEGER, user_id INTEGER, service TEXT, created_at INTEGER)`,
+				`INSERT INTO guild_settings (guild_id, enabled_services, mention_users, delete_original) VALUES (1, '["Twitter"]', 0, 1)`,
+				`INSERT INTO channel_states (channel_id, state) VALUES (2, 1)`,
+				`INSERT INTO link_stats (guild_id, channel_id, user_id, service, created_at) VALUES (1, 2, 3, 'Twitter', 86400)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			// every connection to :memory: is a database of its own
+			db.SetMaxOpenConns(1)
+			for _, stmt := range tt.setup {
+				if _, err := db.Exec(stmt); err != nil {
+					t.Fatalf("setup %q: %v", stmt, err)
+				}
+			}
+
+			// a second run finds nothing left to do
+			for run := 1; run <= 2; run++ {
+				if err := migrate(db); err != nil {
+					t.Fatalf("migrate, run %d: %v", run, err)
+				}
+			}
+
+			var version, applied int
+			if err := db.QueryRow("SELECT MAX(version), COUNT(*) FROM schema_version").Scan(&version, &applied); err != nil {
+				t.Fatal(err)
+			}
+			if version != latest || applied != len(migrations) {
+				t.Errorf("schema_version has %d rows up to %d, want %d up to %d", applied, version, len(migrations), latest)
+			}
+
+			columns := make(map[string]bool)
+			rows, err := db.Query("SELECT name FROM pragma_table_info('guild_settings')")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for rows.Next() {
+				var name string
+				if err := rows.Scan(&name); err != nil {
+					t.Fatal(err)
+				}
+				columns[name] = true
+			}
+			rows.Close()
+			for _, column := range legacyGuildSettingsColumns {
+				name, _, _ := strings.Cut(column, " ")
+				if !columns[name] {
+					t.Errorf("guild_settings has no %s column", name)
+				}
+			}
+
+			if _, err := db.Exec("INSERT INTO guild_settings (guild_id, link_buttons) VALUES (10, 1)"); err != nil {
+				t.Errorf("writing a guild: %v", err)
+			}
+			var storeVersion int
+			if err := db.QueryRow("SELECT version FROM store_version WHERE id = 1").Scan(&storeVersion); err != nil || storeVersion == 0 {
+				t.Errorf("store_version = %d, %v after a write, want it bumped", storeVersion, err)
+			}
+
+			if len(tt.setup) == 0 {
+				return
+			}
+			var services string
+			var mention, deleteOriginal, dmFallback bool
+			if err := db.QueryRow("SELECT enabled_services, mention_users, delete_original, dm_fallback FROM guild_settings WHERE guild_id = 1").
+				Scan(&services, &mention, &deleteOriginal, &dmFallback); err != nil {
+				t.Fatal(err)
+			}
+			if services != `["Twitter"]` || mention || !deleteOriginal || !dmFallback {
+				t.Errorf("legacy guild = %s, mention %t, delete %t, DM fallback %t; want its settings kept and new columns defaulted", services, mention, deleteOriginal, dmFallback)
+			}
+			var links int
+			if err := db.QueryRow("SELECT COALESCE(SUM(links), 0) FROM daily_stats WHERE day = '1970-01-02' AND service = 'Twitter'").Scan(&links); err != nil {
+				t.Fatal(err)
+			}
+			if links != 1 {
+				t.Errorf("daily_stats seeded with %d Twitter links, want 1", links)
+			}
+		})
+	}
+}
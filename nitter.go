@@ -0,0 +1,28 @@
+package main
+
+import "strings"
+
+// Nitter instances whose links are treated as Twitter links (override with NITTER_DOMAINS)
+var nitterDomains = []string{"nitter.net", "nitter.poast.org", "nitter.privacydev.net", "xcancel.com", "nitter.space"}
+
+func isNitterDomain(domain string) bool {
+	runtimeConfig.RLock()
+	defer runtimeConfig.RUnlock()
+	for _, d := range nitterDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDomainList reads a comma-separated list of domains, e.g. from an environment variable.
+func parseDomainList(value string) []string {
+	var domains []string
+	for _, d := range strings.Split(value, ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,82 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// How links in age-restricted channels are handled
+const (
+	NSFW_MODE_FIX    = "fix"    // like any other channel
+	NSFW_MODE_SKIP   = "skip"   // leave them alone
+	NSFW_MODE_DIRECT = "direct" // use direct media links; some fixers refuse to card NSFW posts
+)
+
+var nsfwModeDescriptions = map[string]string{
+	NSFW_MODE_FIX:    "🔞 Links in NSFW channels are fixed like anywhere else.",
+	NSFW_MODE_SKIP:   "🙈 Links in NSFW channels are left alone.",
+	NSFW_MODE_DIRECT: "🖼️ Links in NSFW channels are fixed to direct media links.",
+}
+
+// isNSFWChannel reports whether a channel, or the channel a thread lives in, is age-restricted.
+func isNSFWChannel(s *discordgo.Session, channelID string) bool {
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		if ch, err = s.Channel(channelID); err != nil {
+			return false
+		}
+	}
+	if ch.IsThread() && ch.ParentID != "" {
+		return isNSFWChannel(s, ch.ParentID)
+	}
+	return ch.NSFW
+}
+
+// nsfwSettings applies the guild's NSFW mode to a message in channelID; it returns false when the message should be skipped.
+func nsfwSettings(s *discordgo.Session, channelID string, settings *GuildSettings) (*GuildSettings, bool) {
+	if settings.NSFWMode == NSFW_MODE_FIX || !isNSFWChannel(s, channelID) {
+		return settings, true
+	}
+	switch settings.NSFWMode {
+	case NSFW_MODE_SKIP:
+		return nil, false
+	case NSFW_MODE_DIRECT:
+		direct := *settings
+		direct.DirectMedia = true
+		direct.RichEmbeds = false
+		return &direct, true
+	}
+	return settings, true
+}
+
+func handleNSFWCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	mode := NSFW_MODE_FIX
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "mode" {
+			mode = opt.StringValue()
+		}
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if _, ok := nsfwModeDescriptions[mode]; !ok {
+		embed.Description = "❌ Unknown mode."
+		embed.Color = 0xff0000
+	} else if err := st.UpdateGuildColumn(gidInt, "nsfw_mode", mode); err != nil {
+		logf(LOG_WARN, "Error updating NSFW mode for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the NSFW channel setting."
+		embed.Color = 0xff0000
+	} else {
+		updated := *getGuildSettings(gidInt)
+		updated.NSFWMode = mode
+		cacheGuildSettings(gidInt, &updated)
+		embed.Description = nsfwModeDescriptions[mode]
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNSFWSettings(t *testing.T) {
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "30", GuildID: "1", Type: discordgo.ChannelTypeGuildText, NSFW: true})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "31", GuildID: "1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "30"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "32", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+
+	tests := []struct {
+		mode, channel string
+		want          bool // whether the message is fixed
+		direct        bool
+	}{
+		{NSFW_MODE_FIX, "30", true, false},
+		{NSFW_MODE_SKIP, "30", false, false},
+		{NSFW_MODE_SKIP, "31", false, false},
+		{NSFW_MODE_SKIP, "32", true, false},
+		{NSFW_MODE_DIRECT, "31", true, true},
+		{NSFW_MODE_DIRECT, "32", true, false},
+	}
+	for _, tt := range tests {
+		settings := defaultGuildSettings()
+		settings.NSFWMode = tt.mode
+		got, ok := nsfwSettings(s, tt.channel, settings)
+		if ok != tt.want {
+			t.Errorf("nsfwSettings(%s, %s) fixed = %t, want %t", tt.mode, tt.channel, ok, tt.want)
+			continue
+		}
+		if ok && got.DirectMedia != tt.direct {
+			t.Errorf("nsfwSettings(%s, %s) DirectMedia = %t, want %t", tt.mode, tt.channel, got.DirectMedia, tt.direct)
+		}
+	}
+	settings := defaultGuildSettings()
+	settings.NSFWMode = NSFW_MODE_DIRECT
+	if nsfwSettings(s, "30", settings); settings.DirectMedia {
+		t.Error("direct mode changed the guild's own settings")
+	}
+}
+
+func TestNSFWCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	handleNSFWCommand(db, s, slashCommand("nsfw", option("mode", NSFW_MODE_SKIP)))
+	if got := getGuildSettings(1).NSFWMode; got != NSFW_MODE_SKIP {
+		t.Errorf("NSFWMode = %q after /nsfw skip, want %q", got, NSFW_MODE_SKIP)
+	}
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	if got := getGuildSettings(1).NSFWMode; got != NSFW_MODE_SKIP {
+		t.Errorf("NSFWMode = %q from the database, want %q", got, NSFW_MODE_SKIP)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GuildCreate also fires for every guild on startup; only guilds joined this recently get the welcome
+const ONBOARDING_WINDOW = 5 * time.Minute
+
+// onboardingEmbed explains the defaults and where to change them.
+func onboardingEmbed(s *discordgo.Session) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Thanks for adding %s!", s.State.User.Username),
+		Description: "I repost social media links through embed fixers so they get proper previews. " +
+			"I'm already active in every text channel, with every service enabled.",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Defaults",
+				Value: "The original message is deleted and reposted with a fixed link, mentioning the poster.",
+			},
+			{
+				Name: "Configure",
+				Value: strings.Join([]string{
+					"`/setup` walks you through the main choices",
+					"`/settings panel` changes services, mentions, deletion and more",
+					"`/activate` and `/deactivate` pick the channels I work in",
+				}, "\n"),
+			},
+			{
+				Name:  "Permissions",
+				Value: "I need **Send Messages**, **Embed Links** and **Manage Messages** (to delete or un-embed the original) in the channels I work in.",
+			},
+		},
+		Color: 0x78b159,
+	}
+	createFooter(embed, s)
+	return embed
+}
+
+// sendOnboarding welcomes a newly joined guild in its system channel, or DMs the owner when there isn't one.
+func sendOnboarding(s *discordgo.Session, g *discordgo.Guild) {
+	if g.JoinedAt.IsZero() || time.Since(g.JoinedAt) > ONBOARDING_WINDOW {
+		return
+	}
+	send := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{onboardingEmbed(s)}}
+	if g.SystemChannelID != "" {
+		if _, err := rateLimitedSendComplex(s, g.SystemChannelID, send); err == nil {
+			return
+		}
+	}
+	dm, err := s.UserChannelCreate(g.OwnerID)
+	if err == nil {
+		_, err = rateLimitedSendComplex(s, dm.ID, send)
+	}
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not send the welcome message for guild %s: %v", g.ID, err)
+	}
+}
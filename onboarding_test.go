@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSendOnboarding(t *testing.T) {
+	s, fake := newTestSession(t, nil)
+
+	// guilds announced on startup were joined long ago
+	sendOnboarding(s, &discordgo.Guild{ID: "1", SystemChannelID: "20", JoinedAt: time.Now().Add(-time.Hour)})
+	if len(fake.calls()) != 0 {
+		t.Fatalf("an old guild was welcomed: %v", fake.calls())
+	}
+
+	sendOnboarding(s, &discordgo.Guild{ID: "1", SystemChannelID: "20", JoinedAt: time.Now()})
+	if !slices.Contains(fake.calls(), "POST /channels/20/messages") {
+		t.Errorf("a new guild wasn't welcomed in its system channel: %v", fake.calls())
+	}
+}
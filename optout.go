@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Messages starting with this word are left alone, unless a guild picks its own
+const DEFAULT_OPT_OUT_KEYWORD = "nofix"
+
+// hasOptOutKeyword reports whether content starts with the keyword as a whole word.
+func hasOptOutKeyword(content, keyword string) bool {
+	if keyword == "" {
+		return false
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(content), " ")
+	first, _, _ = strings.Cut(first, "\n")
+	return strings.EqualFold(first, keyword)
+}
+
+func handleNofixCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	keyword := DEFAULT_OPT_OUT_KEYWORD
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "keyword" {
+			keyword = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if keyword == "" || strings.ContainsAny(keyword, " \n\t") {
+		embed.Description = "❌ The keyword must be a single word."
+		embed.Color = 0xff0000
+	} else {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		if err := st.UpdateGuildColumn(gidInt, "optout_keyword", keyword); err != nil {
+			logf(LOG_WARN, "Error updating opt-out keyword for guild %s: %v", i.GuildID, err)
+			embed.Description = "❌ Could not update the opt-out keyword."
+			embed.Color = 0xff0000
+		} else {
+			updated := *getGuildSettings(gidInt)
+			updated.OptOutKeyword = keyword
+			cacheGuildSettings(gidInt, &updated)
+			embed.Description = fmt.Sprintf("🙊 Messages starting with `%s` won't be fixed.", keyword)
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
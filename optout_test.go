@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasOptOutKeyword(t *testing.T) {
+	tests := []struct {
+		content, keyword string
+		want             bool
+	}{
+		{"nofix https://x.com/a/status/1", "nofix", true},
+		{"  NoFix\nhttps://x.com/a/status/1", "nofix", true},
+		{"nofixplease https://x.com/a/status/1", "nofix", false},
+		{"https://x.com/a/status/1 nofix", "nofix", false},
+		{"nofix", "", false},
+	}
+	for _, tt := range tests {
+		if got := hasOptOutKeyword(tt.content, tt.keyword); got != tt.want {
+			t.Errorf("hasOptOutKeyword(%q, %q) = %t, want %t", tt.content, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestHandleNofixCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+
+	handleNofixCommand(db, s, slashCommand("nofix", option("keyword", " skip ")))
+	if gs, _ := db.GuildSettings(1); gs.OptOutKeyword != "skip" {
+		t.Fatalf("stored keyword = %q, want skip", gs.OptOutKeyword)
+	}
+	handleNofixCommand(db, s, slashCommand("nofix", option("keyword", "two words")))
+	if reply := fake.body("POST /interactions/900/token/callback"); !strings.Contains(reply, "single word") {
+		t.Errorf("two words answered with %s", reply)
+	}
+
+	postMessage(t, db, s, getGuildSettings(1), "skip https://x.com/a/status/1")
+	if calls := fake.calls(); strings.Contains(strings.Join(calls, " "), "/channels/20/messages") {
+		t.Errorf("an opted-out message was fixed: %v", calls)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelOverride replaces guild settings in one channel; nil fields follow the guild.
+type channelOverride struct {
+	GuildID        int64
+	MentionUsers   *bool
+	DeleteOriginal *bool
+}
+
+var channelOverrides = struct {
+	sync.RWMutex
+	m map[int64]*channelOverride // channel ID -> override
+}{m: make(map[int64]*channelOverride)}
+
+func (st *sqliteStore) LoadChannelOverrides() (map[int64]*channelOverride, error) {
+	rows, err := st.db.Query("SELECT channel_id, guild_id, mention_users, delete_original FROM channel_settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make(map[int64]*channelOverride)
+	for rows.Next() {
+		var channelID, guildID int64
+		var mentionUsers, deleteOriginal sql.NullBool
+		if err := rows.Scan(&channelID, &guildID, &mentionUsers, &deleteOriginal); err != nil {
+			continue
+		}
+		o := &channelOverride{GuildID: guildID}
+		if mentionUsers.Valid {
+			o.MentionUsers = &mentionUsers.Bool
+		}
+		if deleteOriginal.Valid {
+			o.DeleteOriginal = &deleteOriginal.Bool
+		}
+		overrides[channelID] = o
+	}
+	return overrides, nil
+}
+
+func loadChannelOverrides(st Store) error {
+	overrides, err := st.LoadChannelOverrides()
+	if err != nil {
+		return err
+	}
+	channelOverrides.Lock()
+	channelOverrides.m = overrides
+	channelOverrides.Unlock()
+	return nil
+}
+
+// channelOverrideFor returns the override for a channel, falling back to the parent of a thread.
+func channelOverrideFor(s *discordgo.Session, channelID string) *channelOverride {
+	cidInt, _ := discordIDStringToInt64(channelID)
+	channelOverrides.RLock()
+	o := channelOverrides.m[cidInt]
+	channelOverrides.RUnlock()
+	if o != nil {
+		return o
+	}
+	if ch, err := s.State.Channel(channelID); err == nil && ch.IsThread() && ch.ParentID != "" {
+		return channelOverrideFor(s, ch.ParentID)
+	}
+	return nil
+}
+
+// channelSettings applies a channel's override on top of the guild settings: channel, then guild, then defaults.
+func channelSettings(s *discordgo.Session, channelID string, settings *GuildSettings) *GuildSettings {
+	o := channelOverrideFor(s, channelID)
+	if o == nil {
+		return settings
+	}
+	overridden := *settings
+	if o.MentionUsers != nil {
+		overridden.MentionUsers = *o.MentionUsers
+	}
+	if o.DeleteOriginal != nil {
+		overridden.DeleteOriginal = *o.DeleteOriginal
+	}
+	return &overridden
+}
+
+// UpdateChannelOverride stores a channel's override; an override with nothing set is removed.
+func (st *sqliteStore) UpdateChannelOverride(channelID int64, o *channelOverride) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		var err error
+		if o == nil || (o.MentionUsers == nil && o.DeleteOriginal == nil) {
+			_, err = st.db.Exec("DELETE FROM channel_settings WHERE channel_id = ?", channelID)
+		} else {
+			_, err = st.db.Exec("INSERT OR REPLACE INTO channel_settings (channel_id, guild_id, mention_users, delete_original, updated_at) VALUES (?, ?, ?, ?, ?)",
+				channelID, o.GuildID, o.MentionUsers, o.DeleteOriginal, time.Now().Unix())
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+// updateChannelOverride stores a channel's override and caches it.
+func updateChannelOverride(st Store, channelID int64, o *channelOverride) error {
+	if err := st.UpdateChannelOverride(channelID, o); err != nil {
+		return err
+	}
+	cacheChannelOverride(channelID, o)
+	return nil
+}
+
+func describeOverride(o *channelOverride) string {
+	var parts []string
+	if o.MentionUsers != nil {
+		parts = append(parts, fmt.Sprintf("Mention Users: %t", *o.MentionUsers))
+	}
+	if o.DeleteOriginal != nil {
+		parts = append(parts, fmt.Sprintf("Delete Original: %t", *o.DeleteOriginal))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func handleChannelSettingsCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Channel Overrides",
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	respond := func() {
+		createFooter(embed, s)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+				Flags:  1 << 6, // ephemeral
+			},
+		})
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name == "list" {
+		var lines []string
+		channelOverrides.RLock()
+		for cid, o := range channelOverrides.m {
+			if o.GuildID == gidInt {
+				lines = append(lines, fmt.Sprintf("- <#%d>: %s", cid, describeOverride(o)))
+			}
+		}
+		channelOverrides.RUnlock()
+		if len(lines) == 0 {
+			embed.Description = "No channel overrides. Every channel follows the server settings."
+		} else {
+			embed.Description = strings.Join(lines, "\n")
+		}
+		respond()
+		return
+	}
+
+	channelID := i.ChannelID
+	var mentionUsers, deleteOriginal *bool
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.Value.(string)
+		case "mention_users":
+			v := opt.BoolValue()
+			mentionUsers = &v
+		case "delete_original":
+			v := opt.BoolValue()
+			deleteOriginal = &v
+		}
+	}
+	cidInt, _ := discordIDStringToInt64(channelID)
+
+	var o *channelOverride
+	if sub.Name == "set" {
+		channelOverrides.RLock()
+		o = &channelOverride{GuildID: gidInt}
+		if existing := channelOverrides.m[cidInt]; existing != nil {
+			*o = *existing
+		}
+		channelOverrides.RUnlock()
+		if mentionUsers != nil {
+			o.MentionUsers = mentionUsers
+		}
+		if deleteOriginal != nil {
+			o.DeleteOriginal = deleteOriginal
+		}
+	}
+	if err := updateChannelOverride(st, cidInt, o); err != nil {
+		logf(LOG_WARN, "Error updating channel override for %s: %v", channelID, err)
+		embed.Description = fmt.Sprintf("❌ Could not update the overrides for <#%s>.", channelID)
+		embed.Color = 0xff0000
+	} else if o == nil || (o.MentionUsers == nil && o.DeleteOriginal == nil) {
+		embed.Description = fmt.Sprintf("<#%s> now follows the server settings.", channelID)
+	} else {
+		embed.Description = fmt.Sprintf("⚙️ <#%s>: %s", channelID, describeOverride(o))
+	}
+	respond()
+}
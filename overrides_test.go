@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestChannelOverrides(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "20", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "21", GuildID: "1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "20"})
+	forget := func() {
+		channelOverrides.Lock()
+		channelOverrides.m = make(map[int64]*channelOverride)
+		channelOverrides.Unlock()
+	}
+	t.Cleanup(forget)
+
+	handleChannelSettingsCommand(db, s, slashCommand("channelsettings", option("set", nil, option("mention_users", false))))
+	handleChannelSettingsCommand(db, s, slashCommand("channelsettings", option("set", nil, option("delete_original", false))))
+
+	guild := defaultGuildSettings()
+	for _, channel := range []string{"20", "21"} {
+		got := channelSettings(s, channel, guild)
+		if got.MentionUsers || got.DeleteOriginal {
+			t.Errorf("channel %s settings = %+v, want both overridden off", channel, got)
+		}
+	}
+	if !guild.MentionUsers {
+		t.Error("the override changed the guild's settings")
+	}
+
+	forget()
+	if err := loadChannelOverrides(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := channelSettings(s, "20", guild); got.MentionUsers || got.DeleteOriginal {
+		t.Errorf("after reloading = %+v, want both overridden off", got)
+	}
+
+	handleChannelSettingsCommand(db, s, slashCommand("channelsettings", option("clear", nil)))
+	if got := channelSettings(s, "20", guild); got != guild {
+		t.Errorf("after clearing = %+v, want the guild's settings", got)
+	}
+	var n int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM channel_settings").Scan(&n); err != nil || n != 0 {
+		t.Errorf("%d overrides stored after clearing, %v", n, err)
+	}
+}
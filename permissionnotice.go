@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Failures in a channel before the admins are told about a missing permission
+const PERMISSION_NOTICE_THRESHOLD = 3
+
+// Admins are told about the same problem at most this often
+const PERMISSION_NOTICE_INTERVAL = 24 * time.Hour
+
+type permissionProblem struct {
+	failures int
+	notified time.Time
+}
+
+// permission failures per "channel:permission", to notice the ones that keep happening
+var permissionProblems = struct {
+	sync.Mutex
+	m map[string]*permissionProblem
+}{m: make(map[string]*permissionProblem)}
+
+// notePermissionProblem counts a failure caused by a missing permission and, once it keeps
+// happening, tells the server's admins: in the log channel if there is one, else the owner by DM.
+func notePermissionProblem(s *discordgo.Session, m *discordgo.Message, settings *GuildSettings, permission string) {
+	key := m.ChannelID + ":" + permission
+	permissionProblems.Lock()
+	p := permissionProblems.m[key]
+	if p == nil {
+		p = &permissionProblem{}
+		permissionProblems.m[key] = p
+	}
+	p.failures++
+	due := p.failures >= PERMISSION_NOTICE_THRESHOLD && time.Since(p.notified) >= PERMISSION_NOTICE_INTERVAL
+	if due {
+		p.failures = 0
+		p.notified = time.Now()
+	}
+	permissionProblems.Unlock()
+	if !due {
+		return
+	}
+
+	locale := guildLocale(s, m.GuildID, settings)
+	embed := &discordgo.MessageEmbed{
+		Title:       T(locale, "notice.permission_title"),
+		Description: T(locale, "notice.permission", permission, "<#"+m.ChannelID+">"),
+		Color:       0xffcc4d,
+	}
+	createFooter(embed, s)
+	send := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+
+	if settings.LogChannel != "" && canSendMessages(s, settings.LogChannel) {
+		if _, err := rateLimitedSendComplex(s, settings.LogChannel, send); err == nil {
+			return
+		}
+	}
+	g, err := s.State.Guild(m.GuildID)
+	if err != nil {
+		return
+	}
+	ch, err := s.UserChannelCreate(g.OwnerID)
+	if err == nil {
+		_, err = rateLimitedSendComplex(s, ch.ID, send)
+	}
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not tell guild %s about missing %s: %v", m.GuildID, permission, err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNotePermissionProblem(t *testing.T) {
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		permissionProblems.Lock()
+		permissionProblems.m = make(map[string]*permissionProblem)
+		permissionProblems.Unlock()
+	})
+	settings := defaultGuildSettings()
+	settings.LogChannel = "30"
+	m := &discordgo.Message{ID: "70", ChannelID: "20", GuildID: "1"}
+	notices := func() int {
+		n := 0
+		for _, call := range fake.calls() {
+			if call == "POST /channels/30/messages" {
+				n++
+			}
+		}
+		return n
+	}
+
+	for range PERMISSION_NOTICE_THRESHOLD - 1 {
+		notePermissionProblem(s, m, settings, "Send Messages")
+	}
+	if n := notices(); n != 0 {
+		t.Fatalf("%d notices before the threshold", n)
+	}
+	notePermissionProblem(s, m, settings, "Send Messages")
+	if n := notices(); n != 1 {
+		t.Fatalf("%d notices at the threshold, want 1", n)
+	}
+	for range PERMISSION_NOTICE_THRESHOLD {
+		notePermissionProblem(s, m, settings, "Send Messages")
+	}
+	if n := notices(); n != 1 {
+		t.Errorf("%d notices, want no repeat within a day", n)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Members with any of these can change FixEmbed's configuration
+const CONFIGURE_PERMISSIONS = discordgo.PermissionAdministrator | discordgo.PermissionManageGuild | discordgo.PermissionManageChannels
+
+// names of the commands registered with DefaultMemberPermissions; they're re-checked when run,
+// since server admins can grant them to anyone from the Integrations page
+var configurationCommands = struct {
+	sync.RWMutex
+	m map[string]bool
+}{m: make(map[string]bool)}
+
+func registerConfigurationCommands(commands []*discordgo.ApplicationCommand) {
+	configurationCommands.Lock()
+	defer configurationCommands.Unlock()
+	for _, cmd := range commands {
+		if cmd.DefaultMemberPermissions != nil {
+			configurationCommands.m[cmd.Name] = true
+		}
+	}
+}
+
+func isConfigurationCommand(name string) bool {
+	configurationCommands.RLock()
+	defer configurationCommands.RUnlock()
+	return configurationCommands.m[name]
+}
+
+// components anyone can use; every other component is part of a settings panel
+var publicComponents = map[string]bool{
+	"delete_repost": true, // checks the original author itself
+	"help_topic":    true,
+}
+
+// canConfigure reports whether the member behind an interaction may change FixEmbed's configuration.
+func canConfigure(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&CONFIGURE_PERMISSIONS != 0
+}
+
+// bot event recorded when a fix is posted without touching the original, for lack of Manage Messages
+const DEGRADED_DELIVERY = "missing Manage Messages: the original was left as it is"
+
+// canManageMessages reports whether FixEmbed may delete, or hide the previews of, other people's
+// messages in a channel.
+func canManageMessages(s *discordgo.Session, channelID string) bool {
+	perms, err := s.State.UserChannelPermissions(s.State.User.ID, channelID)
+	if err != nil {
+		// unknown, e.g. the channel isn't cached yet: try, and let a failure be recorded
+		return true
+	}
+	return perms&discordgo.PermissionManageMessages != 0
+}
+
+// canSendMessages reports whether FixEmbed may post in a channel (or thread).
+func canSendMessages(s *discordgo.Session, channelID string) bool {
+	perms, err := s.State.UserChannelPermissions(s.State.User.ID, channelID)
+	if err != nil {
+		return true
+	}
+	send := int64(discordgo.PermissionSendMessages)
+	if ch, err := s.State.Channel(channelID); err == nil && ch.IsThread() {
+		send = discordgo.PermissionSendMessagesInThreads
+	}
+	return perms&discordgo.PermissionViewChannel != 0 && perms&send != 0
+}
+
+func respondNotAllowed(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: T(interactionLocale(i), "error.not_allowed"),
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+}
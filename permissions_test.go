@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestConfigurationCommandPermissions(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	manage := int64(discordgo.PermissionManageGuild)
+	registerConfigurationCommands([]*discordgo.ApplicationCommand{{Name: "nsfw", DefaultMemberPermissions: &manage}, {Name: "about"}})
+	if !isConfigurationCommand("nsfw") || isConfigurationCommand("about") {
+		t.Fatal("only commands with default member permissions are configuration commands")
+	}
+	t.Cleanup(func() {
+		configurationCommands.Lock()
+		delete(configurationCommands.m, "nsfw")
+		configurationCommands.Unlock()
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	i := slashCommand("nsfw", option("mode", NSFW_MODE_SKIP))
+	onInteractionCreate(db, s, i)
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Manage Server") {
+		t.Errorf("a member without permissions got %s", body)
+	}
+	if gs, _ := db.GuildSettings(1); gs != nil {
+		t.Errorf("a member without permissions changed the settings: %+v", gs)
+	}
+
+	i.Member.Permissions = discordgo.PermissionManageChannels
+	onInteractionCreate(db, s, i)
+	if got := getGuildSettings(1).NSFWMode; got != NSFW_MODE_SKIP {
+		t.Errorf("NSFWMode = %q after a channel manager ran /nsfw, want %q", got, NSFW_MODE_SKIP)
+	}
+}
+
+func TestSettingsComponentPermissions(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	// a member who can't configure clicks a panel someone else opened
+	click := componentClick("toggle_link_buttons")
+	click.Member.Permissions = 0
+	onInteractionCreate(db, s, click)
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Manage Server") {
+		t.Errorf("response = %s", body)
+	}
+	if getGuildSettings(1).LinkButtons {
+		t.Error("the click toggled link buttons")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handlePingCommand reports the gateway heartbeat latency and a measured REST round trip.
+func handlePingCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Pong!",
+		Color: 0x78b159,
+	}
+	start := time.Now()
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: T(interactionLocale(i), "ping.pinging"),
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+	if err != nil {
+		return
+	}
+	rest := time.Since(start)
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Gateway", Value: fmt.Sprintf("%d ms", s.HeartbeatLatency().Milliseconds()), Inline: true},
+		{Name: "REST", Value: fmt.Sprintf("%d ms", rest.Milliseconds()), Inline: true},
+	}
+	createFooter(embed, s)
+	content := ""
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &[]*discordgo.MessageEmbed{embed},
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PluralKit's bot user
+const PLURALKIT_BOT_ID = "466378653216014359"
+
+// How long PluralKit gets to proxy (delete and repost) a message before FixEmbed handles it
+const PLURALKIT_GRACE = 2 * time.Second
+
+// How long we trust whether a guild has PluralKit
+const PLURALKIT_PRESENCE_TTL = 1 * time.Hour
+
+// How long an account's messages get the grace window after PluralKit last proxied one of them
+const PLURALKIT_USER_TTL = 24 * time.Hour
+
+type pluralKitPresence struct {
+	present bool
+	checked time.Time
+}
+
+var (
+	pluralKitGuilds = struct {
+		sync.Mutex
+		m map[string]pluralKitPresence
+	}{m: make(map[string]pluralKitPresence)}
+
+	// webhook ID -> whether it is one of PluralKit's proxy webhooks
+	pluralKitWebhooks = struct {
+		sync.Mutex
+		m map[string]bool
+	}{m: make(map[string]bool)}
+
+	// account ID -> when PluralKit last proxied one of its messages
+	pluralKitUsers = struct {
+		sync.Mutex
+		m map[string]time.Time
+	}{m: make(map[string]time.Time)}
+)
+
+// usesPluralKit reports whether PluralKit proxied one of an account's messages lately.
+func usesPluralKit(userID string) bool {
+	pluralKitUsers.Lock()
+	defer pluralKitUsers.Unlock()
+	last, ok := pluralKitUsers.m[userID]
+	if ok && time.Since(last) > PLURALKIT_USER_TTL {
+		delete(pluralKitUsers.m, userID)
+		return false
+	}
+	return ok
+}
+
+// guildHasPluralKit reports whether PluralKit is a member of the guild.
+func guildHasPluralKit(s *discordgo.Session, guildID string) bool {
+	pluralKitGuilds.Lock()
+	p, ok := pluralKitGuilds.m[guildID]
+	pluralKitGuilds.Unlock()
+	if ok && time.Since(p.checked) < PLURALKIT_PRESENCE_TTL {
+		return p.present
+	}
+
+	present := false
+	if _, err := s.State.Member(guildID, PLURALKIT_BOT_ID); err == nil {
+		present = true
+	} else if _, err := s.GuildMember(guildID, PLURALKIT_BOT_ID); err == nil {
+		present = true
+	}
+	pluralKitGuilds.Lock()
+	pluralKitGuilds.m[guildID] = pluralKitPresence{present: present, checked: time.Now()}
+	pluralKitGuilds.Unlock()
+	return present
+}
+
+// proxiedByPluralKit waits out PluralKit's proxy window and reports whether the message was
+// deleted meanwhile, in which case PluralKit's webhook repost is the one to fix. Only accounts
+// PluralKit has proxied for lately are waited for; anyone else's first proxied message is fixed
+// straight away, and its repost goes when PluralKit deletes the original.
+func proxiedByPluralKit(s *discordgo.Session, m *discordgo.Message) bool {
+	if !usesPluralKit(m.Author.ID) {
+		return false
+	}
+	time.Sleep(PLURALKIT_GRACE)
+	_, err := s.ChannelMessage(m.ChannelID, m.ID)
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// errNotProxied means PluralKit's API doesn't know the message, so PluralKit didn't proxy it.
+var errNotProxied = errors.New("not proxied by PluralKit")
+
+// pluralKitSender asks PluralKit's API which account sent a proxied message.
+func pluralKitSender(messageID string) (string, error) {
+	var data struct {
+		Sender string `json:"sender"`
+	}
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		// PluralKit stores the message right after proxying it; give it a moment
+		if err = getJSON("https://api.pluralkit.me/v2/messages/"+messageID, &data); err == nil {
+			if data.Sender != "" {
+				return data.Sender, nil
+			}
+			err = fmt.Errorf("PluralKit returned no sender for %s", messageID)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return "", errNotProxied
+	}
+	return "", err
+}
+
+// pluralKitProxy recognises PluralKit's webhook repost of someone's message and returns a copy
+// whose author is the account behind it (so opt-outs, pings and the Delete button apply to
+// them), keeping the member's display name.
+func pluralKitProxy(s *discordgo.Session, m *discordgo.Message) (*discordgo.Message, bool) {
+	if m.WebhookID == "" || !guildHasPluralKit(s, m.GuildID) {
+		return m, false
+	}
+	pluralKitWebhooks.Lock()
+	isProxy, known := pluralKitWebhooks.m[m.WebhookID]
+	pluralKitWebhooks.Unlock()
+	if known && !isProxy {
+		return m, false
+	}
+
+	// PluralKit only knows about messages it proxied
+	sender, err := pluralKitSender(m.ID)
+	if !known && (err == nil || errors.Is(err, errNotProxied)) {
+		// a webhook is PluralKit's or not for good, but only a definite answer says which;
+		// after an outage or a timeout the next message asks again
+		pluralKitWebhooks.Lock()
+		pluralKitWebhooks.m[m.WebhookID] = err == nil
+		pluralKitWebhooks.Unlock()
+	}
+	if err != nil {
+		logf(LOG_DEBUG, "pluralKitProxy: %s is not a PluralKit message: %v", m.ID, err)
+		return m, false
+	}
+	pluralKitUsers.Lock()
+	pluralKitUsers.m[sender] = time.Now()
+	pluralKitUsers.Unlock()
+	attributed := *m
+	attributed.Author = &discordgo.User{ID: sender, Username: m.Author.Username}
+	return &attributed, true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPluralKitProxy(t *testing.T) {
+	s, _ := newTestSession(t, nil)
+	serveRedirectClient(t, map[string]string{
+		"https://api.pluralkit.me/v2/messages/90": `{"sender": "5"}`,
+	})
+	pluralKitGuilds.Lock()
+	pluralKitGuilds.m["3"] = pluralKitPresence{present: true, checked: time.Now()}
+	pluralKitGuilds.Unlock()
+
+	proxied := &discordgo.Message{ID: "90", GuildID: "3", WebhookID: "300", Author: &discordgo.User{ID: "300", Username: "Member Name"}}
+	msg, ok := pluralKitProxy(s, proxied)
+	if !ok || msg.Author.ID != "5" || msg.Author.Username != "Member Name" {
+		t.Errorf("proxied message attributed to %+v, %t; want account 5 under the member's name", msg.Author, ok)
+	}
+	if proxied.Author.ID != "300" {
+		t.Error("pluralKitProxy changed the original message")
+	}
+	t.Cleanup(func() {
+		pluralKitUsers.Lock()
+		delete(pluralKitUsers.m, "5")
+		pluralKitUsers.Unlock()
+	})
+	if !usesPluralKit("5") {
+		t.Error("account 5 isn't remembered as a PluralKit user")
+	}
+
+	// webhook 301 belongs to something else (a bridge); once known, it is not looked up again
+	other := &discordgo.Message{ID: "91", GuildID: "3", WebhookID: "301", Author: &discordgo.User{ID: "301"}}
+	if msg, ok := pluralKitProxy(s, other); ok || msg != other {
+		t.Errorf("a bridge message was attributed to %+v", msg.Author)
+	}
+	pluralKitWebhooks.Lock()
+	isProxy, known := pluralKitWebhooks.m["301"]
+	pluralKitWebhooks.Unlock()
+	if !known || isProxy {
+		t.Errorf("webhook 301 remembered as proxy %t, known %t", isProxy, known)
+	}
+
+	// an outage says nothing about webhook 302, so the next message asks again
+	redirectClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})
+	down := &discordgo.Message{ID: "92", GuildID: "3", WebhookID: "302", Author: &discordgo.User{ID: "302"}}
+	if _, ok := pluralKitProxy(s, down); ok {
+		t.Error("attributed a message PluralKit's API couldn't be asked about")
+	}
+	pluralKitWebhooks.Lock()
+	_, known = pluralKitWebhooks.m["302"]
+	pluralKitWebhooks.Unlock()
+	if known {
+		t.Error("remembered webhook 302 after a failed lookup")
+	}
+}
+
+func TestProxiedByPluralKitWaitsOnlyForPluralKitUsers(t *testing.T) {
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) { return http.StatusNotFound, `{"code": 10008}` })
+	start := time.Now()
+	if proxiedByPluralKit(s, &discordgo.Message{ID: "70", ChannelID: "20", Author: &discordgo.User{ID: "6"}}) {
+		t.Error("a message from an account PluralKit never proxied for counted as proxied")
+	}
+	if elapsed := time.Since(start); elapsed >= PLURALKIT_GRACE || len(fake.calls()) != 0 {
+		t.Errorf("waited %v and made calls %v for an account that doesn't use PluralKit", elapsed, fake.calls())
+	}
+
+	// an account seen long ago counts as not using PluralKit any more
+	pluralKitUsers.Lock()
+	pluralKitUsers.m["7"] = time.Now().Add(-PLURALKIT_USER_TTL - time.Minute)
+	pluralKitUsers.Unlock()
+	if usesPluralKit("7") {
+		t.Error("account 7 is still a PluralKit user a day after its last proxied message")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SKU that unlocks premium features for a guild (PREMIUM_SKU_ID); without one every feature is free
+var premiumSKU string
+
+// guilds holding an active entitlement to premiumSKU
+var premiumGuilds = struct {
+	sync.RWMutex
+	m map[string]bool
+}{m: make(map[string]bool)}
+
+// components that switch on a premium feature
+var premiumComponents = map[string]bool{
+	"toggle_process_webhooks": true,
+}
+
+func isPremium(guildID string) bool {
+	if premiumSKU == "" {
+		return true
+	}
+	premiumGuilds.RLock()
+	defer premiumGuilds.RUnlock()
+	return premiumGuilds.m[guildID]
+}
+
+// trackEntitlement records a guild entitlement to the premium SKU being granted or taken away.
+func trackEntitlement(e *discordgo.Entitlement, active bool) {
+	if premiumSKU == "" || e == nil || e.SKUID != premiumSKU || e.GuildID == "" {
+		return
+	}
+	premiumGuilds.Lock()
+	if active && !e.Deleted {
+		premiumGuilds.m[e.GuildID] = true
+	} else {
+		delete(premiumGuilds.m, e.GuildID)
+	}
+	premiumGuilds.Unlock()
+}
+
+// loadEntitlements fetches the guilds that currently hold the premium SKU.
+func loadEntitlements(s *discordgo.Session) {
+	if premiumSKU == "" {
+		return
+	}
+	entitlements, err := s.Entitlements(s.State.User.ID, &discordgo.EntitlementFilterOptions{
+		SkuIDs:       []string{premiumSKU},
+		ExcludeEnded: true,
+	})
+	if err != nil {
+		logf(LOG_WARN, "Error loading entitlements: %v", err)
+		return
+	}
+	for _, e := range entitlements {
+		trackEntitlement(e, true)
+	}
+}
+
+// premiumSettings turns off the premium features a guild has configured but isn't entitled to.
+func premiumSettings(guildID string, settings *GuildSettings) *GuildSettings {
+	if isPremium(guildID) {
+		return settings
+	}
+	free := *settings
+	free.ProcessWebhooks = false
+	free.LinkLimit = min(free.LinkLimit, DEFAULT_LINK_LIMIT)
+	free.RepostTemplate = ""
+	return &free
+}
+
+// respondPremiumRequired tells an admin that feature (already translated) needs premium, with a button to get it.
+func respondPremiumRequired(s *discordgo.Session, i *discordgo.InteractionCreate, feature string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: T(interactionLocale(i), "premium.required", feature),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Style: discordgo.PremiumButton, SKUID: premiumSKU},
+				}},
+			},
+			Flags: 1 << 6, // ephemeral
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPremium(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	premiumSKU = "77"
+	t.Cleanup(func() {
+		premiumSKU = ""
+		premiumGuilds.Lock()
+		premiumGuilds.m = make(map[string]bool)
+		premiumGuilds.Unlock()
+	})
+
+	settings := defaultGuildSettings()
+	settings.ProcessWebhooks = true
+	settings.LinkLimit = DEFAULT_LINK_LIMIT + 5
+	if free := premiumSettings("1", settings); free.ProcessWebhooks || free.LinkLimit != DEFAULT_LINK_LIMIT || !settings.ProcessWebhooks {
+		t.Errorf("without premium = %+v", free)
+	}
+
+	onInteractionCreate(db, s, componentClick("toggle_process_webhooks"))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "premium feature") {
+		t.Errorf("toggling webhooks without premium = %s", body)
+	}
+
+	trackEntitlement(&discordgo.Entitlement{SKUID: "78", GuildID: "1"}, true)
+	if isPremium("1") {
+		t.Error("an entitlement to another SKU counted")
+	}
+	trackEntitlement(&discordgo.Entitlement{SKUID: "77", GuildID: "1"}, true)
+	if got := premiumSettings("1", settings); got != settings {
+		t.Errorf("with premium = %+v", got)
+	}
+	trackEntitlement(&discordgo.Entitlement{SKUID: "77", GuildID: "1"}, false)
+	if isPremium("1") {
+		t.Error("a removed entitlement still counted")
+	}
+}
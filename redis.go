@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys and the channel instances announce changes on
+const (
+	REDIS_GUILD_SETTINGS    = "fixembed:guild_settings"    // hash: guild ID -> GuildSettings JSON
+	REDIS_CHANNEL_STATES    = "fixembed:channel_states"    // hash: channel ID -> "1" or "0"
+	REDIS_CHANNEL_OVERRIDES = "fixembed:channel_overrides" // hash: channel ID -> channelOverride JSON
+	REDIS_IGNORED_USERS     = "fixembed:ignored_users"     // hash: "<guild ID>:<user ID>" -> "1"
+	REDIS_OPTED_OUT_USERS   = "fixembed:opted_out_users"   // hash: user ID -> "1"
+	REDIS_CHANGES           = "fixembed:changes"           // "<instance> <kind> <field>...", kind as in redisCaches
+)
+
+// Time allowed for one Redis call before falling back to the in-memory state
+const REDIS_TIMEOUT = 2 * time.Second
+
+// redisClient is nil unless REDIS_URL is set; then guild settings and channel states are
+// written through to Redis and changes from other instances show up immediately.
+var redisClient *redis.Client
+
+// identifies this process in REDIS_CHANGES, so it can skip its own announcements
+var instanceID = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+func initRedis(url string) error {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return err
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), REDIS_TIMEOUT)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return err
+	}
+	redisClient = client
+	return nil
+}
+
+// cacheGuildSettings replaces a guild's cached settings.
+func cacheGuildSettings(guildID int64, settings *GuildSettings) {
+	var data []byte
+	var err error
+	botSettings.Lock()
+	botSettings.m[guildID] = settings
+	if redisClient != nil {
+		// marshalled under the lock, so nothing can change the settings halfway
+		data, err = json.Marshal(settings)
+	}
+	botSettings.Unlock()
+	if redisClient == nil || err != nil {
+		return
+	}
+	writeRedis(REDIS_GUILD_SETTINGS, "guild", strconv.FormatInt(guildID, 10), data)
+}
+
+// uncacheGuildSettings drops a guild's cached settings, e.g. after /reset.
+func uncacheGuildSettings(guildID int64) {
+	botSettings.Lock()
+	delete(botSettings.m, guildID)
+	botSettings.Unlock()
+	if redisClient != nil {
+		writeRedis(REDIS_GUILD_SETTINGS, "guild", strconv.FormatInt(guildID, 10), nil)
+	}
+}
+
+// cacheChannelStates sets the cached state of one or more channels.
+func cacheChannelStates(state bool, channelIDs ...int64) {
+	channelStates.Lock()
+	for _, cidInt := range channelIDs {
+		channelStates.m[cidInt] = state
+	}
+	channelStates.Unlock()
+	if redisClient == nil || len(channelIDs) == 0 {
+		return
+	}
+	value := "0"
+	if state {
+		value = "1"
+	}
+	fields := make([]string, 0, len(channelIDs))
+	values := make([]interface{}, 0, 2*len(channelIDs))
+	for _, cidInt := range channelIDs {
+		field := strconv.FormatInt(cidInt, 10)
+		fields = append(fields, field)
+		values = append(values, field, value)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), REDIS_TIMEOUT)
+	defer cancel()
+	if err := redisClient.HSet(ctx, REDIS_CHANNEL_STATES, values...).Err(); err != nil {
+		logf(LOG_WARN, "Warning: could not write channel states to Redis: %v", err)
+		return
+	}
+	publishChange(ctx, "channel", fields...)
+}
+
+// cacheChannelOverride replaces a channel's cached override; nil or an empty one removes it.
+func cacheChannelOverride(channelID int64, o *channelOverride) {
+	if o != nil && o.MentionUsers == nil && o.DeleteOriginal == nil {
+		o = nil
+	}
+	channelOverrides.Lock()
+	if o == nil {
+		delete(channelOverrides.m, channelID)
+	} else {
+		channelOverrides.m[channelID] = o
+	}
+	channelOverrides.Unlock()
+	if redisClient == nil {
+		return
+	}
+	var value interface{}
+	if o != nil {
+		data, err := json.Marshal(o)
+		if err != nil {
+			return
+		}
+		value = data
+	}
+	writeRedis(REDIS_CHANNEL_OVERRIDES, "override", strconv.FormatInt(channelID, 10), value)
+}
+
+// cacheIgnoredUser adds an account to a guild's cached ignore list, or takes it off.
+func cacheIgnoredUser(guildID, userID int64, ignore bool) {
+	ignoredUsers.Lock()
+	if ignore {
+		if ignoredUsers.m[guildID] == nil {
+			ignoredUsers.m[guildID] = make(map[int64]bool)
+		}
+		ignoredUsers.m[guildID][userID] = true
+	} else {
+		delete(ignoredUsers.m[guildID], userID)
+	}
+	ignoredUsers.Unlock()
+	if redisClient == nil {
+		return
+	}
+	var value interface{}
+	if ignore {
+		value = "1"
+	}
+	writeRedis(REDIS_IGNORED_USERS, "ignored", fmt.Sprintf("%d:%d", guildID, userID), value)
+}
+
+// cacheOptOut records in the cache whether a user opted out.
+func cacheOptOut(userID int64, optOut bool) {
+	optedOutUsers.Lock()
+	if optOut {
+		optedOutUsers.m[userID] = true
+	} else {
+		delete(optedOutUsers.m, userID)
+	}
+	optedOutUsers.Unlock()
+	if redisClient == nil {
+		return
+	}
+	var value interface{}
+	if optOut {
+		value = "1"
+	}
+	writeRedis(REDIS_OPTED_OUT_USERS, "optout", strconv.FormatInt(userID, 10), value)
+}
+
+// writeRedis sets one field of a Redis hash, or deletes it when value is nil, and announces
+// the change to the other instances.
+func writeRedis(key, kind, field string, value interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), REDIS_TIMEOUT)
+	defer cancel()
+	var err error
+	if value == nil {
+		err = redisClient.HDel(ctx, key, field).Err()
+	} else {
+		err = redisClient.HSet(ctx, key, field, value).Err()
+	}
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not write %s %s to Redis: %v", kind, field, err)
+		return
+	}
+	publishChange(ctx, kind, field)
+}
+
+func publishChange(ctx context.Context, kind string, ids ...string) {
+	message := instanceID + " " + kind + " " + strings.Join(ids, " ")
+	if err := redisClient.Publish(ctx, REDIS_CHANGES, message).Err(); err != nil {
+		logf(LOG_WARN, "Warning: could not announce a change in Redis: %v", err)
+	}
+}
+
+// redisCache is one in-memory cache that Redis holds a copy of, as a hash.
+type redisCache struct {
+	kind string // in REDIS_CHANGES announcements
+	key  string
+	// load fills the cache from the store
+	load func(st Store, s *discordgo.Session) error
+	// clear empties the cache; apply sets one field, or removes it when it's gone from Redis
+	clear func()
+	apply func(field, value string, present bool)
+	// fields returns the cache as hash fields, to copy into Redis
+	fields func() map[string]string
+}
+
+var redisCaches = []redisCache{
+	{
+		kind: "guild", key: REDIS_GUILD_SETTINGS,
+		load: func(st Store, s *discordgo.Session) error { return loadSettings(st) },
+		clear: func() {
+			botSettings.Lock()
+			botSettings.m = make(map[int64]*GuildSettings)
+			botSettings.Unlock()
+		},
+		apply: func(field, value string, present bool) {
+			guildID, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return
+			}
+			botSettings.Lock()
+			defer botSettings.Unlock()
+			if !present {
+				delete(botSettings.m, guildID)
+			} else if gs := defaultGuildSettings(); json.Unmarshal([]byte(value), gs) == nil {
+				botSettings.m[guildID] = gs
+			}
+		},
+		fields: func() map[string]string {
+			botSettings.RLock()
+			defer botSettings.RUnlock()
+			fields := make(map[string]string, len(botSettings.m))
+			for guildID, settings := range botSettings.m {
+				if data, err := json.Marshal(settings); err == nil {
+					fields[strconv.FormatInt(guildID, 10)] = string(data)
+				}
+			}
+			return fields
+		},
+	},
+	{
+		kind: "channel", key: REDIS_CHANNEL_STATES,
+		load: loadChannelStates,
+		clear: func() {
+			channelStates.Lock()
+			channelStates.m = make(map[int64]bool)
+			channelStates.Unlock()
+		},
+		apply: func(field, value string, present bool) {
+			cidInt, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return
+			}
+			channelStates.Lock()
+			defer channelStates.Unlock()
+			if present {
+				channelStates.m[cidInt] = value == "1"
+			} else {
+				delete(channelStates.m, cidInt)
+			}
+		},
+		fields: func() map[string]string {
+			channelStates.RLock()
+			defer channelStates.RUnlock()
+			fields := make(map[string]string, len(channelStates.m))
+			for cidInt, state := range channelStates.m {
+				fields[strconv.FormatInt(cidInt, 10)] = strconv.Itoa(boolToInt(state))
+			}
+			return fields
+		},
+	},
+	{
+		kind: "override", key: REDIS_CHANNEL_OVERRIDES,
+		load: func(st Store, s *discordgo.Session) error { return loadChannelOverrides(st) },
+		clear: func() {
+			channelOverrides.Lock()
+			channelOverrides.m = make(map[int64]*channelOverride)
+			channelOverrides.Unlock()
+		},
+		apply: func(field, value string, present bool) {
+			channelID, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return
+			}
+			channelOverrides.Lock()
+			defer channelOverrides.Unlock()
+			o := &channelOverride{}
+			if !present {
+				delete(channelOverrides.m, channelID)
+			} else if json.Unmarshal([]byte(value), o) == nil {
+				channelOverrides.m[channelID] = o
+			}
+		},
+		fields: func() map[string]string {
+			channelOverrides.RLock()
+			defer channelOverrides.RUnlock()
+			fields := make(map[string]string, len(channelOverrides.m))
+			for channelID, o := range channelOverrides.m {
+				if data, err := json.Marshal(o); err == nil {
+					fields[strconv.FormatInt(channelID, 10)] = string(data)
+				}
+			}
+			return fields
+		},
+	},
+	{
+		kind: "ignored", key: REDIS_IGNORED_USERS,
+		load: func(st Store, s *discordgo.Session) error { return loadIgnoredUsers(st) },
+		clear: func() {
+			ignoredUsers.Lock()
+			ignoredUsers.m = make(map[int64]map[int64]bool)
+			ignoredUsers.Unlock()
+		},
+		apply: func(field, value string, present bool) {
+			guild, user, _ := strings.Cut(field, ":")
+			guildID, err := strconv.ParseInt(guild, 10, 64)
+			userID, err2 := strconv.ParseInt(user, 10, 64)
+			if err != nil || err2 != nil {
+				return
+			}
+			ignoredUsers.Lock()
+			defer ignoredUsers.Unlock()
+			if !present {
+				delete(ignoredUsers.m[guildID], userID)
+				return
+			}
+			if ignoredUsers.m[guildID] == nil {
+				ignoredUsers.m[guildID] = make(map[int64]bool)
+			}
+			ignoredUsers.m[guildID][userID] = true
+		},
+		fields: func() map[string]string {
+			ignoredUsers.RLock()
+			defer ignoredUsers.RUnlock()
+			fields := make(map[string]string)
+			for guildID, users := range ignoredUsers.m {
+				for userID := range users {
+					fields[fmt.Sprintf("%d:%d", guildID, userID)] = "1"
+				}
+			}
+			return fields
+		},
+	},
+	{
+		kind: "optout", key: REDIS_OPTED_OUT_USERS,
+		load: func(st Store, s *discordgo.Session) error { return loadOptedOutUsers(st) },
+		clear: func() {
+			optedOutUsers.Lock()
+			optedOutUsers.m = make(map[int64]bool)
+			optedOutUsers.Unlock()
+		},
+		apply: func(field, value string, present bool) {
+			userID, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return
+			}
+			optedOutUsers.Lock()
+			defer optedOutUsers.Unlock()
+			if present {
+				optedOutUsers.m[userID] = true
+			} else {
+				delete(optedOutUsers.m, userID)
+			}
+		},
+		fields: func() map[string]string {
+			optedOutUsers.RLock()
+			defer optedOutUsers.RUnlock()
+			fields := make(map[string]string, len(optedOutUsers.m))
+			for userID := range optedOutUsers.m {
+				fields[strconv.FormatInt(userID, 10)] = "1"
+			}
+			return fields
+		},
+	},
+}
+
+// loadRedisState fills the Redis-backed caches from Redis. A cache Redis doesn't have yet (or
+// can't be read) comes from the store instead, and is copied into Redis for the next start.
+func loadRedisState(st Store, s *discordgo.Session) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*REDIS_TIMEOUT)
+	defer cancel()
+	for _, c := range redisCaches {
+		hash, err := redisClient.HGetAll(ctx, c.key).Result()
+		if err == nil && len(hash) > 0 {
+			c.clear()
+			for field, value := range hash {
+				c.apply(field, value, true)
+			}
+			if c.key == REDIS_CHANNEL_STATES {
+				defaultChannelStates(s)
+			}
+			continue
+		}
+		if err != nil {
+			logf(LOG_WARN, "Warning: could not read %s from Redis: %v", c.key, err)
+		}
+		if loadErr := c.load(st, s); loadErr != nil {
+			logf(LOG_WARN, "Error loading %s: %v", c.key, loadErr)
+			continue
+		}
+		if err == nil {
+			// Redis is up but empty, e.g. the first start with it
+			seedRedis(ctx, c)
+		}
+	}
+}
+
+// seedRedis copies a cache into Redis. Fields are only added, so nothing another instance
+// wrote in the meantime is overwritten.
+func seedRedis(ctx context.Context, c redisCache) {
+	fields := c.fields()
+	if len(fields) == 0 {
+		return
+	}
+	pipe := redisClient.Pipeline()
+	for field, value := range fields {
+		pipe.HSetNX(ctx, c.key, field, value)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logf(LOG_WARN, "Warning: could not copy %s to Redis: %v", c.key, err)
+	}
+}
+
+// startRedisSubscriber applies the changes other instances announce.
+func startRedisSubscriber(stop <-chan struct{}) {
+	if redisClient == nil {
+		return
+	}
+	sub := redisClient.Subscribe(context.Background(), REDIS_CHANGES)
+	defer sub.Close()
+	messages := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			applyRedisChange(msg.Payload)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func applyRedisChange(payload string) {
+	parts := strings.Fields(payload)
+	if len(parts) < 3 || parts[0] == instanceID {
+		return
+	}
+	for _, c := range redisCaches {
+		if c.kind != parts[1] {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), REDIS_TIMEOUT)
+		defer cancel()
+		fields := parts[2:]
+		values, err := redisClient.HMGet(ctx, c.key, fields...).Result()
+		if err != nil {
+			logf(LOG_WARN, "Warning: could not read %s from Redis: %v", c.key, err)
+			return
+		}
+		for idx, field := range fields {
+			value, present := values[idx].(string)
+			c.apply(field, value, present)
+		}
+		return
+	}
+}
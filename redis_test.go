@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis speaks just enough RESP2 for FixEmbed's Redis layer: hashes, transactions and
+// publish/subscribe.
+type fakeRedis struct {
+	sync.Mutex
+	hashes      map[string]map[string]string
+	subscribers map[string][]net.Conn
+}
+
+// newFakeRedis starts a fakeRedis and points redisClient at it for the rest of the test.
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{hashes: make(map[string]map[string]string), subscribers: make(map[string][]net.Conn)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	if err := initRedis("redis://" + ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		redisClient.Close()
+		redisClient = nil
+		ln.Close()
+	})
+	return r
+}
+
+func (r *fakeRedis) hash(key string) map[string]string {
+	r.Lock()
+	defer r.Unlock()
+	h := make(map[string]string, len(r.hashes[key]))
+	for field, value := range r.hashes[key] {
+		h[field] = value
+	}
+	return h
+}
+
+func (r *fakeRedis) set(key, field, value string) {
+	r.Lock()
+	defer r.Unlock()
+	if r.hashes[key] == nil {
+		r.hashes[key] = make(map[string]string)
+	}
+	r.hashes[key][field] = value
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	in := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(in)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "MULTI":
+			inMulti = true
+			io.WriteString(conn, "+OK\r\n")
+		case name == "EXEC":
+			inMulti = false
+			reply := fmt.Sprintf("*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				reply += r.run(conn, cmd)
+			}
+			queued = nil
+			io.WriteString(conn, reply)
+		case inMulti:
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+		default:
+			io.WriteString(conn, r.run(conn, args))
+		}
+	}
+}
+
+func (r *fakeRedis) run(conn net.Conn, args []string) string {
+	r.Lock()
+	defer r.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "CLIENT", "SELECT":
+		return "+OK\r\n"
+	case "DEL":
+		for _, key := range args[1:] {
+			delete(r.hashes, key)
+		}
+		return ":1\r\n"
+	case "HSET":
+		if r.hashes[args[1]] == nil {
+			r.hashes[args[1]] = make(map[string]string)
+		}
+		for idx := 2; idx+1 < len(args); idx += 2 {
+			r.hashes[args[1]][args[idx]] = args[idx+1]
+		}
+		return ":1\r\n"
+	case "HSETNX":
+		if r.hashes[args[1]] == nil {
+			r.hashes[args[1]] = make(map[string]string)
+		}
+		if _, ok := r.hashes[args[1]][args[2]]; ok {
+			return ":0\r\n"
+		}
+		r.hashes[args[1]][args[2]] = args[3]
+		return ":1\r\n"
+	case "HDEL":
+		for _, field := range args[2:] {
+			delete(r.hashes[args[1]], field)
+		}
+		return ":1\r\n"
+	case "HGET":
+		value, ok := r.hashes[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "HMGET":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-2)
+		for _, field := range args[2:] {
+			if value, ok := r.hashes[args[1]][field]; ok {
+				reply += bulk(value)
+			} else {
+				reply += "$-1\r\n"
+			}
+		}
+		return reply
+	case "HGETALL":
+		h := r.hashes[args[1]]
+		reply := fmt.Sprintf("*%d\r\n", 2*len(h))
+		for field, value := range h {
+			reply += bulk(field) + bulk(value)
+		}
+		return reply
+	case "SUBSCRIBE":
+		reply := ""
+		for idx, channel := range args[1:] {
+			r.subscribers[channel] = append(r.subscribers[channel], conn)
+			reply += "*3\r\n" + bulk("subscribe") + bulk(channel) + ":" + strconv.Itoa(idx+1) + "\r\n"
+		}
+		return reply
+	case "PUBLISH":
+		for _, sub := range r.subscribers[args[1]] {
+			io.WriteString(sub, "*3\r\n"+bulk("message")+bulk(args[1])+bulk(args[2]))
+		}
+		return ":" + strconv.Itoa(len(r.subscribers[args[1]])) + "\r\n"
+	}
+	// HELLO included: go-redis falls back to RESP2
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(in *bufio.Reader) ([]string, error) {
+	line, err := in.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	args := make([]string, n)
+	for idx := range args {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(in, buf); err != nil {
+			return nil, err
+		}
+		args[idx] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestLoadCachesFromRedis(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	r := newFakeRedis(t)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		botSettings.m = make(map[int64]*GuildSettings)
+		botSettings.Unlock()
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	if err := db.UpdateGuildColumn(1, "link_buttons", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty Redis is filled from the database
+	loadCaches(db, s)
+	if data := r.hash(REDIS_GUILD_SETTINGS)["1"]; !strings.Contains(data, `"LinkButtons":true`) {
+		t.Fatalf("Redis holds %q for guild 1, want the database's settings", data)
+	}
+
+	// once it has them, Redis wins over the database and isn't written back
+	r.set(REDIS_GUILD_SETTINGS, "1", `{"LinkButtons":false,"DirectMedia":true}`)
+	loadCaches(db, s)
+	if gs := getGuildSettings(1); gs.LinkButtons || !gs.DirectMedia {
+		t.Errorf("settings = %+v, want Redis's", gs)
+	}
+	if data := r.hash(REDIS_GUILD_SETTINGS)["1"]; !strings.Contains(data, `"DirectMedia":true`) {
+		t.Errorf("Redis holds %q for guild 1 after a restart, want it untouched", data)
+	}
+}
+
+func TestLoadCachesFallsBackPerHash(t *testing.T) {
+	db := newTestDB(t)
+	r := newFakeRedis(t)
+	t.Cleanup(func() {
+		optedOutUsers.Lock()
+		optedOutUsers.m = make(map[int64]bool)
+		optedOutUsers.Unlock()
+		ignoredUsers.Lock()
+		ignoredUsers.m = make(map[int64]map[int64]bool)
+		ignoredUsers.Unlock()
+	})
+	if err := db.UpdateUserOptOut(5, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateIgnoredUser(1, 6, true); err != nil {
+		t.Fatal(err)
+	}
+	r.set(REDIS_OPTED_OUT_USERS, "7", "1")
+
+	loadCaches(db, nil)
+	if isOptedOut("5") || !isOptedOut("7") {
+		t.Errorf("opted out 5 = %t, 7 = %t; want Redis's list", isOptedOut("5"), isOptedOut("7"))
+	}
+	if !isIgnored("1", "6") {
+		t.Error("the ignore list missing from Redis wasn't loaded from the database")
+	}
+	if got := r.hash(REDIS_IGNORED_USERS); got["1:6"] != "1" {
+		t.Errorf("Redis ignore list = %v, want it seeded from the database", got)
+	}
+}
+
+func TestApplyRedisChange(t *testing.T) {
+	r := newFakeRedis(t)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+		channelStates.Lock()
+		delete(channelStates.m, 20)
+		channelStates.Unlock()
+	})
+	r.set(REDIS_GUILD_SETTINGS, "1", `{"LinkButtons":true}`)
+	r.set(REDIS_CHANNEL_STATES, "20", "0")
+
+	applyRedisChange(instanceID + " guild 1") // this instance's own change
+	botSettings.RLock()
+	_, cached := botSettings.m[1]
+	botSettings.RUnlock()
+	if cached {
+		t.Fatal("applied this instance's own announcement")
+	}
+
+	applyRedisChange("other guild 1")
+	applyRedisChange("other channel 20")
+	botSettings.RLock()
+	gs := botSettings.m[1]
+	botSettings.RUnlock()
+	channelStates.RLock()
+	state, ok := channelStates.m[20]
+	channelStates.RUnlock()
+	if gs == nil || !gs.LinkButtons || !ok || state {
+		t.Errorf("settings = %+v, channel 20 = %t/%t; want the other instance's", gs, state, ok)
+	}
+}
+
+func TestRedisOverridesIgnoresAndOptOuts(t *testing.T) {
+	db := newTestDB(t)
+	r := newFakeRedis(t)
+	t.Cleanup(func() {
+		channelOverrides.Lock()
+		channelOverrides.m = make(map[int64]*channelOverride)
+		channelOverrides.Unlock()
+		ignoredUsers.Lock()
+		ignoredUsers.m = make(map[int64]map[int64]bool)
+		ignoredUsers.Unlock()
+		optedOutUsers.Lock()
+		optedOutUsers.m = make(map[int64]bool)
+		optedOutUsers.Unlock()
+	})
+	mention := true
+	if err := updateChannelOverride(db, 20, &channelOverride{GuildID: 1, MentionUsers: &mention}); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateIgnoredUser(db, 1, 6, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateUserOptOut(db, 7, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.hash(REDIS_CHANNEL_OVERRIDES)["20"]; !strings.Contains(got, `"MentionUsers":true`) {
+		t.Errorf("Redis override for channel 20 = %q", got)
+	}
+	if r.hash(REDIS_IGNORED_USERS)["1:6"] != "1" || r.hash(REDIS_OPTED_OUT_USERS)["7"] != "1" {
+		t.Errorf("Redis ignore list = %v, opt-outs = %v", r.hash(REDIS_IGNORED_USERS), r.hash(REDIS_OPTED_OUT_USERS))
+	}
+
+	// another instance resets the guild and lifts both: this one follows
+	if err := resetGuild(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.hash(REDIS_CHANNEL_OVERRIDES)["20"]; ok {
+		t.Error("the reset left channel 20's override in Redis")
+	}
+	r.set(REDIS_CHANNEL_OVERRIDES, "21", `{"GuildID":1,"DeleteOriginal":false}`)
+	r.Lock()
+	delete(r.hashes[REDIS_IGNORED_USERS], "1:6")
+	delete(r.hashes[REDIS_OPTED_OUT_USERS], "7")
+	r.Unlock()
+	applyRedisChange("other override 21")
+	applyRedisChange("other ignored 1:6")
+	applyRedisChange("other optout 7")
+	channelOverrides.RLock()
+	o := channelOverrides.m[21]
+	channelOverrides.RUnlock()
+	if o == nil || o.DeleteOriginal == nil || *o.DeleteOriginal {
+		t.Errorf("channel 21 override = %+v, want the other instance's", o)
+	}
+	if isIgnored("1", "6") || isOptedOut("7") {
+		t.Error("kept an ignore or opt-out the other instance lifted")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// guards the settings that a config reload can change while the bot is running
+var runtimeConfig sync.RWMutex
+
+// the values those settings had before any configuration was applied, so that removing
+// one from the config file puts the default back
+var runtimeDefaults struct {
+	sync.Once
+	nitterDomains []string
+	mastodon      string
+	messageLimit  int
+	timeWindow    time.Duration
+	fallbacks     map[*Service][]string
+}
+
+// applyRuntimeConfig reads the reloadable settings from the environment: statuses, fixer
+// domains and fallbacks, and the send rate limit.
+func applyRuntimeConfig() {
+	runtimeDefaults.Do(func() {
+		runtimeDefaults.nitterDomains = nitterDomains
+		runtimeDefaults.mastodon = mastodonFixerDomain
+		runtimeDefaults.messageLimit = MESSAGE_LIMIT
+		runtimeDefaults.timeWindow = TIME_WINDOW
+		runtimeDefaults.fallbacks = make(map[*Service][]string)
+		for _, svc := range services {
+			runtimeDefaults.fallbacks[svc] = svc.Fallbacks
+		}
+	})
+
+	runtimeConfig.Lock()
+	defer runtimeConfig.Unlock()
+	statuses = defaultStatuses()
+	if v := os.Getenv("STATUSES"); v != "" {
+		statuses = strings.Split(v, STATUS_SEPARATOR)
+	}
+
+	nitterDomains = runtimeDefaults.nitterDomains
+	if v, ok := os.LookupEnv("NITTER_DOMAINS"); ok {
+		nitterDomains = parseDomainList(v)
+	}
+	mastodonFixerDomain = runtimeDefaults.mastodon
+	if d := os.Getenv("MASTODON_FIXER_DOMAIN"); d != "" {
+		mastodonFixerDomain = d
+	}
+
+	MESSAGE_LIMIT = runtimeDefaults.messageLimit
+	if v := os.Getenv("RATE_LIMIT_MESSAGES"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil && limit > 0 {
+			MESSAGE_LIMIT = limit
+		} else {
+			logf(LOG_WARN, "RATE_LIMIT_MESSAGES: invalid value %q", v)
+		}
+	}
+	TIME_WINDOW = envDuration("RATE_LIMIT_WINDOW", runtimeDefaults.timeWindow)
+
+	for svc, hosts := range runtimeDefaults.fallbacks {
+		svc.Fallbacks = hosts
+	}
+	// self-hosted deployments can point the fallbacks at their own mirrors
+	for name, hosts := range parseFallbacks(os.Getenv("FIXER_FALLBACKS")) {
+		if svc := findService(name); svc != nil {
+			svc.Fallbacks = hosts
+		} else {
+			logf(LOG_WARN, "FIXER_FALLBACKS: unknown service %q", name)
+		}
+	}
+}
+
+// reloadConfig re-reads the config file (on SIGHUP) and applies what can change without a
+// restart. Settings given on the command line or in the real environment keep their values.
+func reloadConfig() {
+	if cfg.ConfigFile == "" {
+		logf(LOG_WARN, "Warning: no config file to reload")
+		return
+	}
+	if err := reloadConfigFile(cfg.ConfigFile); err != nil {
+		logf(LOG_WARN, "Error reloading config file: %v", err)
+		return
+	}
+	if !explicitFlags["log-level"] {
+		if level := envString("LOG_LEVEL", LOG_INFO); isLogLevel(level) {
+			cfg.LogLevel = level
+		}
+	}
+	if !explicitFlags["status-interval"] {
+		if interval := envDuration("STATUS_INTERVAL", 60*time.Second); interval > 0 {
+			runtimeConfig.Lock()
+			cfg.StatusInterval = interval
+			runtimeConfig.Unlock()
+		}
+	}
+	if !explicitFlags["live-statuses"] {
+		runtimeConfig.Lock()
+		cfg.LiveStatuses = envBool("LIVE_STATUSES", true)
+		runtimeConfig.Unlock()
+	}
+	setLogLevel(cfg.LogLevel)
+	applyRuntimeConfig()
+	logf(LOG_INFO, "Reloaded config from %s", cfg.ConfigFile)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadConfig(t *testing.T) {
+	savedCfg, savedLimit := cfg, MESSAGE_LIMIT
+	t.Cleanup(func() {
+		for key := range configFileEnv {
+			os.Unsetenv(key)
+		}
+		configFileEnv = make(map[string]bool)
+		cfg = savedCfg
+		applyRuntimeConfig()
+		MESSAGE_LIMIT = savedLimit
+	})
+	path := filepath.Join(t.TempDir(), "config.toml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaultLimit := MESSAGE_LIMIT
+
+	write("[rate_limit]\nmessages = 9\n")
+	cfg.ConfigFile = path
+	if err := loadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	applyRuntimeConfig()
+	if MESSAGE_LIMIT != 9 {
+		t.Fatalf("MESSAGE_LIMIT = %d, want the file's 9", MESSAGE_LIMIT)
+	}
+
+	write("[rate_limit]\nmessages = 4\n")
+	reloadConfig()
+	if MESSAGE_LIMIT != 4 {
+		t.Errorf("MESSAGE_LIMIT = %d after a reload, want 4", MESSAGE_LIMIT)
+	}
+
+	// a broken file keeps the previous values
+	write("[rate_limit\n")
+	reloadConfig()
+	if MESSAGE_LIMIT != 4 {
+		t.Errorf("MESSAGE_LIMIT = %d after a failed reload, want 4", MESSAGE_LIMIT)
+	}
+
+	// dropping the setting from the file puts the default back
+	write("")
+	reloadConfig()
+	if MESSAGE_LIMIT != defaultLimit {
+		t.Errorf("MESSAGE_LIMIT = %d, want the default %d", MESSAGE_LIMIT, defaultLimit)
+	}
+}
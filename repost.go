@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's message limits
+const (
+	MAX_MESSAGE_LENGTH = 2000
+	MAX_EMBEDS         = 10
+)
+
+// repostLink is one fixed link on its way into the combined repost.
+type repostLink struct {
+	*FixedLink
+	Match string // the link as it appeared in the message
+
+	Embed *discordgo.MessageEmbed // rich embed built from the fixer's API, if any
+	Files []*discordgo.File       // re-uploaded media, if any
+}
+
+// text is how the link shows up in the repost's content.
+func (l *repostLink) text(settings *GuildSettings) string {
+	if settings.LinkButtons {
+		return l.DisplayText
+	}
+	if l.Embed != nil || len(l.Files) > 0 {
+		// our own embed or attachments replace the fixer's, so keep Discord from unfurling the link too
+		return fmt.Sprintf("[%s](<https://%s>)", l.DisplayText, l.Fixed)
+	}
+	return fmt.Sprintf("[%s](https://%s)", l.DisplayText, l.Fixed)
+}
+
+// buildReposts combines every fixed link from one message into as few messages as Discord's
+// limits allow. Embeds, attachments and buttons go on the last message.
+func buildReposts(links []*repostLink, content string, author *discordgo.User, sentBy string, settings *GuildSettings, locale discordgo.Locale) []*discordgo.MessageSend {
+	texts := make([]string, 0, len(links))
+	for _, l := range links {
+		texts = append(texts, l.text(settings))
+	}
+	body := strings.Join(texts, "\n")
+	if settings.PreserveText {
+		// put each link text where the link was in the user's message
+		body = content
+		for idx, l := range links {
+			body = strings.Replace(body, l.Match, texts[idx], 1)
+		}
+	}
+	body = renderRepost(settings.RepostTemplate, body, links, author, sentBy)
+
+	last := &discordgo.MessageSend{}
+	for _, l := range links {
+		if settings.LinkButtons && l.Embed == nil && len(l.Files) == 0 {
+			body += "\nhttps://" + l.Fixed
+		}
+		if l.Embed != nil && len(last.Embeds) < MAX_EMBEDS {
+			last.Embeds = append(last.Embeds, l.Embed)
+		}
+		last.Files = append(last.Files, l.Files...)
+	}
+	if settings.LinkButtons {
+		last.Components = linkButtonRows(links)
+	}
+	last.Components = append(last.Components, discordgo.ActionsRow{Components: repostButtons(links, settings, locale)})
+
+	chunks := splitMessage(body, MAX_MESSAGE_LENGTH)
+	sends := make([]*discordgo.MessageSend, 0, len(chunks))
+	for _, chunk := range chunks[:len(chunks)-1] {
+		sends = append(sends, &discordgo.MessageSend{Content: chunk})
+	}
+	last.Content = chunks[len(chunks)-1]
+	return append(sends, last)
+}
+
+// splitMessage breaks content into chunks of at most limit characters, preferring line breaks.
+func splitMessage(content string, limit int) []string {
+	var chunks []string
+	for len([]rune(content)) > limit {
+		runes := []rune(content)
+		cut := strings.LastIndex(string(runes[:limit]), "\n")
+		if cut <= 0 {
+			cut = len(string(runes[:limit]))
+			chunks = append(chunks, content[:cut])
+			content = content[cut:]
+			continue
+		}
+		chunks = append(chunks, content[:cut])
+		content = content[cut+1:]
+	}
+	return append(chunks, content)
+}
+
+// repostButtons is the repost's own button row: a way back to each post on its original
+// platform (unless link buttons already offer one) and the Delete button.
+func repostButtons(links []*repostLink, settings *GuildSettings, locale discordgo.Locale) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	if !settings.LinkButtons {
+		for idx, l := range links {
+			if len(buttons) == MAX_BUTTONS_PER_ROW-1 {
+				break
+			}
+			label := T(locale, "repost.open_on", l.Service.label())
+			if len(links) > 1 {
+				label = fmt.Sprintf("%d: %s", idx+1, label)
+			}
+			buttons = append(buttons, discordgo.Button{Label: label, Style: discordgo.LinkButton, URL: "https://" + l.Original})
+		}
+	}
+	return append(buttons, discordgo.Button{Label: T(locale, "repost.delete"), Style: discordgo.SecondaryButton, CustomID: "delete_repost", Emoji: &discordgo.ComponentEmoji{Name: "🗑️"}})
+}
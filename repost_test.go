@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		want    []string
+	}{
+		{name: "short", content: "hello", limit: 10, want: []string{"hello"}},
+		{name: "exactly the limit", content: "hello", limit: 5, want: []string{"hello"}},
+		{name: "at a line break", content: "one\ntwo\nthree", limit: 8, want: []string{"one\ntwo", "three"}},
+		{name: "without line breaks", content: "abcdefgh", limit: 3, want: []string{"abc", "def", "gh"}},
+		{name: "leading line break", content: "\nabcd", limit: 3, want: []string{"\nab", "cd"}},
+		{name: "multibyte", content: "ééééé", limit: 2, want: []string{"éé", "éé", "é"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitMessage(tt.content, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", tt.content, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReposts(t *testing.T) {
+	twitter, pixiv := findService("Twitter"), findService("Pixiv")
+	links := []*repostLink{
+		{FixedLink: &FixedLink{Service: twitter, Fixed: "fixupx.com/a/status/1", DisplayText: "Twitter • a"}, Match: "https://x.com/a/status/1"},
+		{FixedLink: &FixedLink{Service: pixiv, Fixed: "phixiv.net/artworks/2", DisplayText: "Pixiv • 2"}, Match: "https://pixiv.net/artworks/2",
+			Embed: &discordgo.MessageEmbed{Title: "art"}},
+	}
+
+	sends := buildReposts(links, "see https://x.com/a/status/1 and https://pixiv.net/artworks/2", &discordgo.User{ID: "5"}, "Sent by <@5>", defaultGuildSettings(), discordgo.EnglishUS)
+	want := "[Twitter • a](https://fixupx.com/a/status/1)\n[Pixiv • 2](<https://phixiv.net/artworks/2>) | Sent by <@5>"
+	if len(sends) != 1 || sends[0].Content != want || len(sends[0].Embeds) != 1 {
+		t.Errorf("combined repost = %+v, want one message %q with the embed", sends[0], want)
+	}
+
+	preserve := defaultGuildSettings()
+	preserve.PreserveText = true
+	sends = buildReposts(links, "see https://x.com/a/status/1 and https://pixiv.net/artworks/2", &discordgo.User{ID: "5"}, "Sent by <@5>", preserve, discordgo.EnglishUS)
+	want = "see [Twitter • a](https://fixupx.com/a/status/1) and [Pixiv • 2](<https://phixiv.net/artworks/2>) | Sent by <@5>"
+	if sends[0].Content != want {
+		t.Errorf("repost keeping the text = %q, want %q", sends[0].Content, want)
+	}
+
+	// text over Discord's limit is split; the embed stays on the last message
+	sends = buildReposts(links, strings.Repeat("word ", 500)+"https://x.com/a/status/1 https://pixiv.net/artworks/2", &discordgo.User{ID: "5"}, "Sent by <@5>", preserve, discordgo.EnglishUS)
+	if len(sends) != 2 || sends[0].Embeds != nil || len(sends[1].Embeds) != 1 {
+		t.Fatalf("long repost = %d messages, want 2 with the embed last", len(sends))
+	}
+	for _, send := range sends {
+		if n := len([]rune(send.Content)); n > MAX_MESSAGE_LENGTH {
+			t.Errorf("a message has %d characters", n)
+		}
+	}
+}
+
+func TestRepostButtons(t *testing.T) {
+	link := func(n int) *repostLink {
+		return &repostLink{FixedLink: &FixedLink{Service: findService("Twitter"), Original: fmt.Sprintf("x.com/a/status/%d", n)}}
+	}
+	labels := func(buttons []discordgo.MessageComponent) (out []string) {
+		for _, b := range buttons {
+			out = append(out, b.(discordgo.Button).Label)
+		}
+		return out
+	}
+
+	buttons := repostButtons([]*repostLink{link(1)}, defaultGuildSettings(), discordgo.EnglishUS)
+	if got := labels(buttons); !slices.Equal(got, []string{"Open on Twitter", "Delete"}) || buttons[0].(discordgo.Button).URL != "https://x.com/a/status/1" {
+		t.Errorf("buttons = %+v", buttons)
+	}
+
+	var many []*repostLink
+	for n := range 7 {
+		many = append(many, link(n))
+	}
+	if got := labels(repostButtons(many, defaultGuildSettings(), discordgo.EnglishUS)); len(got) != MAX_BUTTONS_PER_ROW || got[0] != "1: Open on Twitter" || got[len(got)-1] != "Delete" {
+		t.Errorf("buttons for 7 links = %q, want a full row ending in Delete", got)
+	}
+
+	withLinkButtons := defaultGuildSettings()
+	withLinkButtons.LinkButtons = true
+	if got := labels(repostButtons([]*repostLink{link(1)}, withLinkButtons, discordgo.EnglishUS)); !slices.Equal(got, []string{"Delete"}) {
+		t.Errorf("buttons next to link buttons = %q, want only Delete", got)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reposts of this many recent originals are looked up in memory; older ones come from fix_messages
+const REPOST_INDEX_SIZE = 4096
+
+type repostRef struct {
+	channelID string
+	messageID string
+}
+
+// repostEntry is what the index knows about one original's reposts.
+type repostEntry struct {
+	refs      []repostRef
+	signature string // the fixed links the reposts were built from
+}
+
+var (
+	// original message ID -> the bot's reposts of it
+	repostIndex = struct {
+		sync.Mutex
+		m     map[string]*repostEntry
+		order []string // insertion order, oldest first
+	}{m: make(map[string]*repostEntry)}
+
+	// originals the bot deleted itself (DeleteOriginal); their reposts must stay
+	selfDeleted = struct {
+		sync.Mutex
+		m map[string]bool
+	}{m: make(map[string]bool)}
+)
+
+// repostSignature identifies the fixed links a repost was built from, to spot edits that change them.
+func repostSignature(links []*repostLink) string {
+	fixed := make([]string, 0, len(links))
+	for _, l := range links {
+		fixed = append(fixed, l.Fixed)
+	}
+	return strings.Join(fixed, " ")
+}
+
+// indexedReposts returns the index entry for an original, adding an empty one (and dropping
+// the oldest beyond REPOST_INDEX_SIZE) if there's none. The caller holds repostIndex's lock.
+func indexedReposts(originalID string) *repostEntry {
+	if entry, ok := repostIndex.m[originalID]; ok {
+		return entry
+	}
+	entry := &repostEntry{}
+	repostIndex.m[originalID] = entry
+	repostIndex.order = append(repostIndex.order, originalID)
+	for len(repostIndex.order) > REPOST_INDEX_SIZE {
+		delete(repostIndex.m, repostIndex.order[0])
+		repostIndex.order = repostIndex.order[1:]
+	}
+	return entry
+}
+
+// rememberRepost indexes a repost under the message it replaced.
+func rememberRepost(original *discordgo.Message, sent *discordgo.Message, signature string) {
+	repostIndex.Lock()
+	defer repostIndex.Unlock()
+	entry := indexedReposts(original.ID)
+	entry.refs = append(entry.refs, repostRef{channelID: sent.ChannelID, messageID: sent.ID})
+	entry.signature = signature
+}
+
+// deleteRepostedOriginal deletes a message the bot has reposted, without taking the repost down with it.
+func deleteRepostedOriginal(s *discordgo.Session, m *discordgo.Message) error {
+	selfDeleted.Lock()
+	selfDeleted.m[m.ID] = true
+	selfDeleted.Unlock()
+	err := s.ChannelMessageDelete(m.ChannelID, m.ID)
+	if err != nil {
+		selfDeleted.Lock()
+		delete(selfDeleted.m, m.ID)
+		selfDeleted.Unlock()
+	}
+	return err
+}
+
+// suppressOriginalEmbeds hides an original message's link previews. The edit carries only the
+// message's flags: the content is left alone, since a bot can't edit other users' text.
+func suppressOriginalEmbeds(s *discordgo.Session, m *discordgo.Message) error {
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      m.ID,
+		Channel: m.ChannelID,
+		Flags:   m.Flags | discordgo.MessageFlagsSuppressEmbeds,
+	})
+	return err
+}
+
+func (st *sqliteStore) Reposts(originalID int64) ([]repostRef, string, error) {
+	rows, err := st.db.Query("SELECT channel_id, message_id, signature FROM fix_messages WHERE original_id = ? ORDER BY message_id", originalID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	var refs []repostRef
+	var signature string
+	for rows.Next() {
+		var channelID, messageID int64
+		if err := rows.Scan(&channelID, &messageID, &signature); err != nil {
+			continue
+		}
+		refs = append(refs, repostRef{channelID: fmt.Sprint(channelID), messageID: fmt.Sprint(messageID)})
+	}
+	return refs, signature, nil
+}
+
+func (st *sqliteStore) UpdateRepostSignature(originalID int64, signature string) error {
+	_, err := st.db.Exec("UPDATE fix_messages SET signature = ? WHERE original_id = ?", signature, originalID)
+	return err
+}
+
+// repostsOf returns the bot's reposts of an original message and, if known, their signature.
+func repostsOf(st Store, originalID string) ([]repostRef, string) {
+	repostIndex.Lock()
+	entry, ok := repostIndex.m[originalID]
+	repostIndex.Unlock()
+	if ok {
+		return entry.refs, entry.signature
+	}
+
+	origID, err := discordIDStringToInt64(originalID)
+	if err != nil {
+		return nil, ""
+	}
+	refs, signature, err := st.Reposts(origID)
+	if err != nil {
+		logf(LOG_WARN, "Error looking up reposts of %s: %v", originalID, err)
+		return nil, ""
+	}
+	return refs, signature
+}
+
+// forgetReposts drops an original from the in-memory index.
+func forgetReposts(originalID string) {
+	repostIndex.Lock()
+	defer repostIndex.Unlock()
+	if _, ok := repostIndex.m[originalID]; !ok {
+		return
+	}
+	delete(repostIndex.m, originalID)
+	if idx := slices.Index(repostIndex.order, originalID); idx >= 0 {
+		repostIndex.order = slices.Delete(repostIndex.order, idx, idx+1)
+	}
+}
+
+// deleteReposts takes reposts down and stops tracking them. Reposts that are already gone count as deleted.
+func deleteReposts(st Store, s *discordgo.Session, originalID string, refs []repostRef) {
+	forgetReposts(originalID)
+	for _, ref := range refs {
+		err := s.ChannelMessageDelete(ref.channelID, ref.messageID)
+		var restErr *discordgo.RESTError
+		if err != nil && !(errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound) {
+			logf(LOG_WARN, "Warning: failed to delete repost %s of message %s: %v", ref.messageID, originalID, err)
+			continue
+		}
+		msgID, _ := discordIDStringToInt64(ref.messageID)
+		if err := st.DeleteFixMessage(msgID); err != nil {
+			logf(LOG_WARN, "Error forgetting repost %s: %v", ref.messageID, err)
+		}
+	}
+}
+
+// onMessageDelete takes the bot's repost down when the user deletes the message it replaced.
+func onMessageDelete(st Store, s *discordgo.Session, d *discordgo.MessageDelete) {
+	selfDeleted.Lock()
+	self := selfDeleted.m[d.ID]
+	delete(selfDeleted.m, d.ID)
+	selfDeleted.Unlock()
+	if self {
+		return
+	}
+
+	refs, _ := repostsOf(st, d.ID)
+	deleteReposts(st, s, d.ID, refs)
+}
+
+// onMessageUpdate keeps the bot's repost in line with an edited original: the repost is
+// rebuilt when the edit changes the links, and removed when the links are gone.
+func onMessageUpdate(st Store, s *discordgo.Session, u *discordgo.MessageUpdate) {
+	// partial updates (e.g. Discord attaching link previews) carry no author or content
+	if u.Author == nil {
+		return
+	}
+	refs, signature := repostsOf(st, u.ID)
+	if len(refs) == 0 {
+		return
+	}
+	settings, ok := fixableMessage(s, u.Message)
+	// in simulate mode FixEmbed leaves messages alone, its own included
+	if !ok || settings.Simulate {
+		return
+	}
+
+	// compare the links before fetching anything for them: most edits don't touch them
+	content, links := findLinks(u.Message, settings)
+	if len(links) == 0 {
+		deleteReposts(st, s, u.ID, refs)
+		return
+	}
+	newSignature := repostSignature(links)
+	if newSignature == signature {
+		return
+	}
+	if !canSendMessages(s, u.ChannelID) {
+		logf(LOG_DEBUG, "onMessageUpdate: can't post in channel %s, leaving the reposts of %s as they are", u.ChannelID, u.ID)
+		return
+	}
+	sends := buildLinkReposts(s, u.Message, settings, content, links)
+	if len(sends) == 0 {
+		deleteReposts(st, s, u.ID, refs)
+		return
+	}
+
+	if len(sends) == len(refs) && !hasFiles(sends) {
+		for idx, send := range sends {
+			_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+				ID:              refs[idx].messageID,
+				Channel:         refs[idx].channelID,
+				Content:         &send.Content,
+				Embeds:          &send.Embeds,
+				Components:      &send.Components,
+				AllowedMentions: send.AllowedMentions,
+			})
+			if err != nil {
+				recordDeliveryError(st, u.Message, "edit", err)
+			}
+		}
+		repostIndex.Lock()
+		entry := indexedReposts(u.ID)
+		// reposts looked up in fix_messages aren't indexed yet
+		entry.refs = refs
+		entry.signature = newSignature
+		repostIndex.Unlock()
+		if origID, err := discordIDStringToInt64(u.ID); err == nil {
+			if err := st.UpdateRepostSignature(origID, newSignature); err != nil {
+				logf(LOG_WARN, "Error updating the reposts of %s: %v", u.ID, err)
+			}
+		}
+		return
+	}
+
+	// the repost changed shape (or needs new attachments); replace it
+	deleteReposts(st, s, u.ID, refs)
+	for _, send := range sends {
+		sent, err := rateLimitedSendComplex(s, u.ChannelID, send)
+		if err != nil {
+			recordDeliveryError(st, u.Message, "send", err)
+			continue
+		}
+		_ = recordFixMessage(st, sent, u.Message, newSignature)
+		rememberRepost(u.Message, sent, newSignature)
+	}
+}
+
+func hasFiles(sends []*discordgo.MessageSend) bool {
+	for _, send := range sends {
+		if len(send.Files) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDeleteRepost deletes a repost (all of its messages) when its Delete button is pressed
+// by the original author or someone who can manage messages.
+func handleDeleteRepost(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := ""
+	var perms int64
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+		perms = i.Member.Permissions
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	msgID, _ := discordIDStringToInt64(i.Message.ID)
+	originalID, authorID, err := st.FixMessage(msgID)
+	if err != nil {
+		logf(LOG_WARN, "Error looking up repost %s: %v", i.Message.ID, err)
+	}
+
+	if fmt.Sprint(authorID) != userID && perms&discordgo.PermissionManageMessages == 0 {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: T(interactionLocale(i), "repost.delete_not_author"),
+				Flags:   1 << 6, // ephemeral
+			},
+		})
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+	refs := []repostRef{{channelID: i.ChannelID, messageID: i.Message.ID}}
+	if originalID != 0 {
+		// a long repost is split across messages; they all go
+		refs, _ = repostsOf(st, fmt.Sprint(originalID))
+	}
+	deleteReposts(st, s, fmt.Sprint(originalID), refs)
+}
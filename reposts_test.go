@@ -0,0 +1,251 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// postedReposts answers every repost with message 80 in channel 20.
+func postedReposts(r *http.Request) (int, string) {
+	if r.Method == http.MethodPost {
+		return http.StatusOK, `{"id": "80", "channel_id": "20"}`
+	}
+	return http.StatusOK, "{}"
+}
+
+func TestOnMessageDelete(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, postedReposts)
+
+	// the bot deleting the original itself keeps the repost
+	settings := defaultGuildSettings()
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	onMessageDelete(db, s, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "70", ChannelID: "20"}})
+	if slices.Contains(fake.calls(), "DELETE /channels/20/messages/80") {
+		t.Fatal("the repost went down with the original FixEmbed deleted")
+	}
+
+	// the author deleting a kept original takes the repost down
+	settings.DeleteOriginal = false
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	onMessageDelete(db, s, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "70", ChannelID: "20"}})
+	if !slices.Contains(fake.calls(), "DELETE /channels/20/messages/80") {
+		t.Errorf("calls %v do not delete the repost", fake.calls())
+	}
+}
+
+func TestRepostsOfFromDatabase(t *testing.T) {
+	db := newTestDB(t)
+	original := &discordgo.Message{ID: "71", ChannelID: "20", GuildID: "1", Author: &discordgo.User{ID: "5"}}
+	for _, id := range []string{"81", "82"} {
+		if err := recordFixMessage(db, &discordgo.Message{ID: id, ChannelID: "20"}, original, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not in the in-memory index, as after a restart
+	refs, signature := repostsOf(db, "71")
+	if !slices.Equal(refs, []repostRef{{"20", "81"}, {"20", "82"}}) || signature != "" {
+		t.Errorf("repostsOf = %v, %q", refs, signature)
+	}
+}
+
+func TestOnMessageUpdate(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, postedReposts)
+	settings := defaultGuildSettings()
+	settings.DeleteOriginal = false
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	edit := func(content string) {
+		onMessageUpdate(db, s, &discordgo.MessageUpdate{Message: &discordgo.Message{
+			ID: "70", ChannelID: "20", GuildID: "1", Content: content, Author: &discordgo.User{ID: "5", Username: "someone"},
+		}})
+	}
+	countCalls := func(call string) (n int) {
+		for _, c := range fake.calls() {
+			if c == call {
+				n++
+			}
+		}
+		return n
+	}
+
+	// the original's own embed suppression is the only edit so far
+	edit("https://x.com/a/status/1 typo fixed")
+	if n := countCalls("PATCH /channels/20/messages/80"); n != 0 {
+		t.Errorf("an edit keeping the links edited the repost %d times", n)
+	}
+
+	edit("https://x.com/b/status/2")
+	if n := countCalls("PATCH /channels/20/messages/80"); n != 1 {
+		t.Fatalf("an edit changing the link edited the repost %d times", n)
+	}
+	if body := fake.body("PATCH /channels/20/messages/80"); !strings.Contains(body, "fixupx.com/b/status/2") {
+		t.Errorf("edited repost = %s", body)
+	}
+
+	edit("no links any more")
+	if !slices.Contains(fake.calls(), "DELETE /channels/20/messages/80") {
+		t.Errorf("calls %v do not remove the repost", fake.calls())
+	}
+	if refs, _ := repostsOf(db, "70"); len(refs) != 0 {
+		t.Errorf("the removed repost is still tracked: %v", refs)
+	}
+}
+
+func TestOnMessageUpdateLeavesRepostsAlone(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, postedReposts)
+	settings := defaultGuildSettings()
+	settings.DeleteOriginal = false
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	edit := func(content string) {
+		onMessageUpdate(db, s, &discordgo.MessageUpdate{Message: &discordgo.Message{
+			ID: "70", ChannelID: "20", GuildID: "1", Content: content, Author: &discordgo.User{ID: "5", Username: "someone"},
+		}})
+	}
+	touched := func() bool {
+		return slices.ContainsFunc(fake.calls(), func(c string) bool {
+			return strings.HasPrefix(c, "PATCH /channels/20/messages/80") || strings.HasPrefix(c, "DELETE /channels/20/messages/80")
+		})
+	}
+
+	// after a restart the signature comes from fix_messages
+	forgetReposts("70")
+	edit("https://x.com/a/status/1 typo fixed")
+	if touched() {
+		t.Fatalf("an edit keeping the links changed the repost after a restart: %v", fake.calls())
+	}
+
+	settings.Simulate = true
+	edit("https://x.com/b/status/2")
+	edit("no links any more")
+	if touched() {
+		t.Fatalf("simulate mode changed the repost: %v", fake.calls())
+	}
+
+	// channel 20 can be read but not posted in
+	settings.Simulate = false
+	s.State.GuildAdd(&discordgo.Guild{ID: "1", OwnerID: "9", Roles: []*discordgo.Role{{ID: "1", Permissions: discordgo.PermissionViewChannel}}})
+	s.State.ChannelAdd(&discordgo.Channel{ID: "20", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	s.State.MemberAdd(&discordgo.Member{GuildID: "1", User: s.State.User})
+	edit("https://x.com/b/status/2")
+	if touched() {
+		t.Errorf("rebuilt the repost without Send Messages: %v", fake.calls())
+	}
+}
+
+func TestHandleDeleteRepost(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	original := &discordgo.Message{ID: "72", ChannelID: "20", GuildID: "1", Author: &discordgo.User{ID: "5"}}
+	for _, id := range []string{"83", "84"} {
+		if err := recordFixMessage(db, &discordgo.Message{ID: id, ChannelID: "20"}, original, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	click := func(userID string, perms int64) {
+		i := componentClick("delete_repost")
+		i.ChannelID = "20"
+		i.Message = &discordgo.Message{ID: "84", ChannelID: "20"}
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: userID}, Permissions: perms}
+		handleDeleteRepost(db, s, i)
+	}
+
+	click("6", 0)
+	if slices.Contains(fake.calls(), "DELETE /channels/20/messages/84") {
+		t.Fatal("another member deleted the repost")
+	}
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "Only the person who posted the link") {
+		t.Errorf("refusal = %s", body)
+	}
+
+	click("6", discordgo.PermissionManageMessages)
+	for _, call := range []string{"DELETE /channels/20/messages/83", "DELETE /channels/20/messages/84"} {
+		if !slices.Contains(fake.calls(), call) {
+			t.Errorf("a moderator's click did not %s", call)
+		}
+	}
+}
+
+func TestSuppressOriginalEmbeds(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	settings := defaultGuildSettings()
+	settings.DeleteOriginal = false
+
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	if body := fake.body("PATCH /channels/20/messages/70"); !strings.Contains(body, `"flags":4`) || strings.Contains(body, "content") {
+		t.Errorf("suppress edit = %s, want the flags alone", body)
+	}
+
+	// in a channel where FixEmbed lacks Manage Messages the original is left alone, even when it
+	// would be deleted
+	settings.DeleteOriginal = true
+	s.State.GuildAdd(&discordgo.Guild{ID: "1", OwnerID: "9", Roles: []*discordgo.Role{{ID: "1", Permissions: discordgo.PermissionViewChannel | discordgo.PermissionSendMessages}}})
+	s.State.ChannelAdd(&discordgo.Channel{ID: "21", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	s.State.MemberAdd(&discordgo.Member{GuildID: "1", User: s.State.User})
+	onMessageCreate(db, s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID: "71", ChannelID: "21", GuildID: "1", Content: "https://x.com/a/status/1",
+		Author: &discordgo.User{ID: "5", Username: "someone"},
+	}})
+	if !slices.Contains(fake.calls(), "POST /channels/21/messages") {
+		t.Fatalf("calls %v do not repost", fake.calls())
+	}
+	if slices.Contains(fake.calls(), "PATCH /channels/21/messages/71") || slices.Contains(fake.calls(), "DELETE /channels/21/messages/71") {
+		t.Errorf("calls %v touch the original without Manage Messages", fake.calls())
+	}
+}
+
+func TestKeepOriginalWhenNothingSent(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) {
+		if r.Method == http.MethodPost {
+			return http.StatusInternalServerError, `{"message": "boom"}`
+		}
+		return http.StatusOK, "{}"
+	})
+	settings := defaultGuildSettings()
+	settings.DeleteOriginal = true
+
+	postMessage(t, db, s, settings, "https://x.com/a/status/1")
+	if slices.Contains(fake.calls(), "DELETE /channels/20/messages/70") {
+		t.Errorf("calls %v delete the original although no fix was posted", fake.calls())
+	}
+}
+
+func TestRepostIndexOrder(t *testing.T) {
+	repostIndex.Lock()
+	saved, savedOrder := repostIndex.m, repostIndex.order
+	repostIndex.m, repostIndex.order = make(map[string]*repostEntry), nil
+	repostIndex.Unlock()
+	t.Cleanup(func() {
+		repostIndex.Lock()
+		repostIndex.m, repostIndex.order = saved, savedOrder
+		repostIndex.Unlock()
+	})
+
+	sent := &discordgo.Message{ID: "80", ChannelID: "20"}
+	rememberRepost(&discordgo.Message{ID: "1"}, sent, "a")
+	rememberRepost(&discordgo.Message{ID: "2"}, sent, "b")
+	rememberRepost(&discordgo.Message{ID: "1"}, sent, "a")
+	forgetReposts("1")
+	forgetReposts("3") // never indexed
+	if !slices.Equal(repostIndex.order, []string{"2"}) || len(repostIndex.m) != 1 {
+		t.Errorf("order = %v with %d entries, want only 2", repostIndex.order, len(repostIndex.m))
+	}
+
+	for id := range REPOST_INDEX_SIZE + 1 {
+		rememberRepost(&discordgo.Message{ID: strconv.Itoa(100 + id)}, sent, "c")
+	}
+	if len(repostIndex.order) != REPOST_INDEX_SIZE || len(repostIndex.m) != REPOST_INDEX_SIZE {
+		t.Errorf("index holds %d/%d originals, want %d", len(repostIndex.order), len(repostIndex.m), REPOST_INDEX_SIZE)
+	}
+	if _, ok := repostIndex.m["2"]; ok {
+		t.Error("the oldest original is still indexed past the cap")
+	}
+}
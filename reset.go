@@ -0,0 +1,112 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// handleResetCommand asks for confirmation before /reset puts a guild back on the defaults.
+func handleResetCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channels := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "channels" {
+			channels = opt.BoolValue()
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Reset Settings",
+		Description: "This puts every FixEmbed setting on this server back to its default, including channel overrides, the log channel and the digest.",
+		Color:       0xff0000,
+	}
+	confirm := "reset_confirm"
+	if channels {
+		embed.Description += "\nFixEmbed will also be **activated in every channel**."
+		confirm = "reset_confirm_channels"
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Reset", Style: discordgo.DangerButton, CustomID: confirm},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "reset_cancel"},
+				}},
+			},
+			Flags: 1 << 6, // ephemeral
+		},
+	})
+}
+
+func (st *sqliteStore) ResetGuild(guildID int64) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM guild_settings WHERE guild_id = ?", guildID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM channel_settings WHERE guild_id = ?", guildID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// resetGuild drops a guild's stored settings and channel overrides so it falls back to the defaults.
+func resetGuild(st Store, guildID int64) error {
+	if err := st.ResetGuild(guildID); err != nil {
+		return err
+	}
+
+	uncacheGuildSettings(guildID)
+	var channelIDs []int64
+	channelOverrides.RLock()
+	for channelID, o := range channelOverrides.m {
+		if o.GuildID == guildID {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	channelOverrides.RUnlock()
+	for _, channelID := range channelIDs {
+		cacheChannelOverride(channelID, nil)
+	}
+	return nil
+}
+
+func handleResetComponent(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, custom string) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Reset Settings",
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	switch custom {
+	case "reset_cancel":
+		embed.Description = "Nothing was changed."
+	default:
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		err := resetGuild(st, gidInt)
+		if err == nil && custom == "reset_confirm_channels" {
+			var g *discordgo.Guild
+			if g, err = s.State.Guild(i.GuildID); err == nil {
+				_, err = setGuildState(st, g, true, nil)
+			}
+		}
+		if err != nil {
+			logf(LOG_WARN, "Error resetting guild %s: %v", i.GuildID, err)
+			embed.Description = "❌ Could not reset the settings."
+			embed.Color = 0xff0000
+		} else {
+			embed.Description = "✅ Settings are back to their defaults."
+			if custom == "reset_confirm_channels" {
+				embed.Description += "\nFixEmbed is active in every channel."
+			}
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
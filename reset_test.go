@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestReset(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "20", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+		channelOverrides.Lock()
+		channelOverrides.m = make(map[int64]*channelOverride)
+		channelOverrides.Unlock()
+	})
+
+	handleNSFWCommand(db, s, slashCommand("nsfw", option("mode", NSFW_MODE_SKIP)))
+	handleChannelSettingsCommand(db, s, slashCommand("channelsettings", option("set", nil, option("mention_users", false))))
+	setChannelState(db, s, "1", "20", false)
+
+	// cancelling changes nothing
+	onInteractionCreate(db, s, componentClick("reset_cancel"))
+	if getGuildSettings(1).NSFWMode != NSFW_MODE_SKIP {
+		t.Fatal("cancelling the reset reset the settings")
+	}
+
+	onInteractionCreate(db, s, componentClick("reset_confirm_channels"))
+	if got := getGuildSettings(1).NSFWMode; got != NSFW_MODE_FIX {
+		t.Errorf("NSFWMode = %q after the reset, want the default", got)
+	}
+	if got := channelSettings(s, "20", defaultGuildSettings()); !got.MentionUsers {
+		t.Error("the channel override survived the reset")
+	}
+	if enabled, _ := channelState(s, "20"); !enabled {
+		t.Error("the channel wasn't activated")
+	}
+	var n int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM channel_settings").Scan(&n); err != nil || n != 0 {
+		t.Errorf("%d channel overrides stored after the reset, %v", n, err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const REDIRECT_TIMEOUT = 5 * time.Second
+
+// Resolved short links are remembered for this long, up to REDIRECT_CACHE_SIZE entries
+const REDIRECT_CACHE_TTL = 24 * time.Hour
+const REDIRECT_CACHE_SIZE = 1024
+
+var redirectClient = &http.Client{
+	Timeout: REDIRECT_TIMEOUT,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		return nil
+	},
+}
+
+type redirectEntry struct {
+	link     string
+	target   string
+	resolved time.Time
+}
+
+// LRU cache of resolved short links
+var redirectCache = struct {
+	sync.Mutex
+	order *list.List // front = most recently used
+	m     map[string]*list.Element
+}{order: list.New(), m: make(map[string]*list.Element)}
+
+func cachedRedirect(link string) (string, bool) {
+	redirectCache.Lock()
+	defer redirectCache.Unlock()
+	el, ok := redirectCache.m[link]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*redirectEntry)
+	if time.Since(entry.resolved) > REDIRECT_CACHE_TTL {
+		redirectCache.order.Remove(el)
+		delete(redirectCache.m, link)
+		return "", false
+	}
+	redirectCache.order.MoveToFront(el)
+	return entry.target, true
+}
+
+func storeRedirect(link, target string) {
+	redirectCache.Lock()
+	defer redirectCache.Unlock()
+	if el, ok := redirectCache.m[link]; ok {
+		el.Value = &redirectEntry{link: link, target: target, resolved: time.Now()}
+		redirectCache.order.MoveToFront(el)
+		return
+	}
+	redirectCache.m[link] = redirectCache.order.PushFront(&redirectEntry{link: link, target: target, resolved: time.Now()})
+	if redirectCache.order.Len() > REDIRECT_CACHE_SIZE {
+		oldest := redirectCache.order.Back()
+		redirectCache.order.Remove(oldest)
+		delete(redirectCache.m, oldest.Value.(*redirectEntry).link)
+	}
+}
+
+// resolveRedirect follows a short link's redirects and returns where it ends up,
+// without the scheme or a leading www. (the same shape as a link-pattern capture).
+func resolveRedirect(link string) (string, error) {
+	if target, ok := cachedRedirect(link); ok {
+		return target, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://"+link, nil)
+	if err != nil {
+		return "", err
+	}
+	// some shorteners only redirect browsers
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; FixEmbed/"+VERSION+")")
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	final := resp.Request.URL
+	// t.co answers some clients with a meta refresh page instead of a redirect
+	if strings.EqualFold(final.Host, strings.SplitN(link, "/", 2)[0]) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if mm := metaRefreshRe.FindSubmatch(body); len(mm) > 1 {
+			if u, err := url.Parse(html.UnescapeString(string(mm[1]))); err == nil && u.Host != "" {
+				final = u
+			}
+		}
+	}
+	target := trimLinkURL(final)
+	storeRedirect(link, target)
+	return target, nil
+}
+
+var (
+	metaRefreshRe = regexp.MustCompile(`(?i)<meta[^>]+http-equiv=["']?refresh["']?[^>]+content=["'][0-9]*;\s*url=([^"'>\s]+)`)
+
+	// Twitter short links; the leading group keeps <...> surrounded links untouched
+	shortLinkRe = regexp.MustCompile(`(^|[^<])https?://((?:t\.co|pic\.twitter\.com)/[A-Za-z0-9]+)`)
+)
+
+// expandShortLinks replaces the first limit Twitter short links in content with the URLs they
+// point to, so the expanded links go through the normal service matching. Links in code or
+// quotes are never fixed, so they aren't resolved either.
+func expandShortLinks(content string, limit int) string {
+	if limit <= 0 {
+		return content
+	}
+	var expanded strings.Builder
+	last := 0
+	// maskMarkdown keeps offsets, so the matches index into content too
+	for _, loc := range shortLinkRe.FindAllStringSubmatchIndex(maskMarkdown(content), limit) {
+		short := content[loc[4]:loc[5]]
+		resolved, err := resolveRedirect(short)
+		if err != nil {
+			logf(LOG_DEBUG, "expandShortLinks: could not resolve %s: %v", short, err)
+			continue
+		}
+		// loc[3] is where the link starts, after the character in front of it
+		expanded.WriteString(content[last:loc[3]])
+		expanded.WriteString("https://" + resolved)
+		last = loc[1]
+	}
+	expanded.WriteString(content[last:])
+	return expanded.String()
+}
+
+func trimLinkURL(u *url.URL) string {
+	link := strings.TrimPrefix(strings.ToLower(u.Host), "www.") + u.EscapedPath()
+	if u.RawQuery != "" {
+		link += "?" + u.RawQuery
+	}
+	return link
+}
+
+var redditPostRe = regexp.MustCompile(`^(?:old\.)?reddit\.com/r/([A-Za-z0-9_]+)/comments/([A-Za-z0-9_]+)(?:/([A-Za-z0-9_]+))?`)
+
+// canonicalRedditLink reduces a resolved Reddit URL to reddit.com/r/<sub>/comments/<id>/<slug>,
+// dropping share tracking parameters.
+func canonicalRedditLink(link string) (string, bool) {
+	mm := redditPostRe.FindStringSubmatch(link)
+	if len(mm) < 3 {
+		return "", false
+	}
+	canonical := fmt.Sprintf("reddit.com/r/%s/comments/%s", mm[1], mm[2])
+	if mm[3] != "" {
+		canonical += "/" + mm[3]
+	}
+	return canonical, true
+}
+
+// redditGalleryPermalink asks Reddit's API for a gallery post's permalink and returns it in
+// canonical form, so the gallery can go through vxReddit like any other post.
+func redditGalleryPermalink(id string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://www.reddit.com/api/info.json?id=t3_"+url.QueryEscape(id), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "FixEmbed/"+VERSION)
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reddit API returned %s", resp.Status)
+	}
+	var listing struct {
+		Data struct {
+			Children []struct {
+				Data struct {
+					Permalink string `json:"permalink"`
+				} `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return "", err
+	}
+	if len(listing.Data.Children) == 0 {
+		return "", fmt.Errorf("post %s not found", id)
+	}
+	canonical, ok := canonicalRedditLink("reddit.com" + listing.Data.Children[0].Data.Permalink)
+	if !ok {
+		return "", fmt.Errorf("unexpected permalink %q", listing.Data.Children[0].Data.Permalink)
+	}
+	return canonical, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTrimLinkURL(t *testing.T) {
+	tests := map[string]string{
+		"https://www.facebook.com/reel/123":             "facebook.com/reel/123",
+		"https://M.Facebook.com/watch/?v=456":           "m.facebook.com/watch/?v=456",
+		"https://www.facebook.com/user/videos/a%20b/1/": "facebook.com/user/videos/a%20b/1/",
+	}
+	for raw, want := range tests {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := trimLinkURL(u); got != want {
+			t.Errorf("trimLinkURL(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestResolveRedirectCache(t *testing.T) {
+	storeRedirect("redd.it/abc", "reddit.com/r/golang/comments/abc/a_title")
+	// a cached link never reaches the network
+	if got, err := resolveRedirect("redd.it/abc"); err != nil || got != "reddit.com/r/golang/comments/abc/a_title" {
+		t.Errorf("resolveRedirect = %q, %v; want the cached target", got, err)
+	}
+
+	for i := 0; i < REDIRECT_CACHE_SIZE; i++ {
+		storeRedirect(fmt.Sprintf("redd.it/%d", i), "reddit.com")
+	}
+	if _, ok := cachedRedirect("redd.it/abc"); ok {
+		t.Error("the least recently used link should have been evicted")
+	}
+	if _, ok := cachedRedirect(fmt.Sprintf("redd.it/%d", REDIRECT_CACHE_SIZE-1)); !ok {
+		t.Error("the newest link should still be cached")
+	}
+}
+
+func TestCanonicalRedditLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+		ok   bool
+	}{
+		{"reddit.com/r/golang/comments/abc/a_title?share_id=x&utm_source=share", "reddit.com/r/golang/comments/abc/a_title", true},
+		{"old.reddit.com/r/golang/comments/abc", "reddit.com/r/golang/comments/abc", true},
+		{"reddit.com/r/golang", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := canonicalRedditLink(tt.link); got != tt.want || ok != tt.ok {
+			t.Errorf("canonicalRedditLink(%q) = %q, %t; want %q, %t", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExpandShortLinks(t *testing.T) {
+	storeRedirect("t.co/AbC1", "x.com/someone/status/123")
+	tests := map[string]string{
+		"look https://t.co/AbC1":    "look https://x.com/someone/status/123",
+		"https://t.co/AbC1 again":   "https://x.com/someone/status/123 again",
+		"<https://t.co/AbC1>":       "<https://t.co/AbC1>",
+		"https://t.co.example/AbC1": "https://t.co.example/AbC1",
+		// code and quotes are never fixed, and only the first limit links are resolved
+		"`https://t.co/AbC1` https://t.co/AbC1":  "`https://t.co/AbC1` https://x.com/someone/status/123",
+		"> https://t.co/AbC1\nhttps://t.co/AbC1": "> https://t.co/AbC1\nhttps://x.com/someone/status/123",
+		"https://t.co/AbC1 https://t.co/AbC1":    "https://x.com/someone/status/123 https://x.com/someone/status/123",
+	}
+	for content, want := range tests {
+		if got := expandShortLinks(content, 2); got != want {
+			t.Errorf("expandShortLinks(%q) = %q, want %q", content, got, want)
+		}
+	}
+	if got := expandShortLinks("https://t.co/AbC1 https://t.co/AbC1", 1); got != "https://x.com/someone/status/123 https://t.co/AbC1" {
+		t.Errorf("expandShortLinks with a limit of 1 = %q", got)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// serveRedirectClient answers redirectClient's requests with fixed bodies by URL.
+func serveRedirectClient(t *testing.T, bodies map[string]string) {
+	transport := redirectClient.Transport
+	t.Cleanup(func() { redirectClient.Transport = transport })
+	redirectClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, ok := bodies[r.URL.String()]
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+}
+
+func TestRedditGalleryPermalink(t *testing.T) {
+	serveRedirectClient(t, map[string]string{
+		"https://www.reddit.com/api/info.json?id=t3_abc":   `{"data":{"children":[{"data":{"permalink":"/r/golang/comments/abc/a_gallery/"}}]}}`,
+		"https://www.reddit.com/api/info.json?id=t3_empty": `{"data":{"children":[]}}`,
+	})
+	if got, err := redditGalleryPermalink("abc"); err != nil || got != "reddit.com/r/golang/comments/abc/a_gallery" {
+		t.Errorf("redditGalleryPermalink(abc) = %q, %v", got, err)
+	}
+	for _, id := range []string{"empty", "missing"} {
+		if got, err := redditGalleryPermalink(id); err == nil {
+			t.Errorf("redditGalleryPermalink(%s) = %q, want an error", id, got)
+		}
+	}
+}
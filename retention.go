@@ -0,0 +1,217 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How often the sweeper looks for expired fix messages
+const RETENTION_SWEEP_INTERVAL = 1 * time.Hour
+
+// Fix messages in channels without a retention policy are only tracked for this long
+const FIX_MESSAGE_TRACKING = 30 * 24 * time.Hour
+
+var (
+	retentionMinDays float64 = 0
+	retentionMaxDays float64 = 365
+)
+
+// fixMessage is a message the bot posted in place of an original one.
+type fixMessage struct {
+	MessageID  int64
+	ChannelID  int64
+	GuildID    int64
+	OriginalID int64
+	AuthorID   int64
+	Signature  string // see repostSignature; empty for fixes that aren't kept in step with edits
+}
+
+func (st *sqliteStore) RecordFixMessage(f fixMessage) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		_, err := st.db.Exec("INSERT OR REPLACE INTO fix_messages (message_id, channel_id, guild_id, original_id, author_id, signature, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			f.MessageID, f.ChannelID, f.GuildID, f.OriginalID, f.AuthorID, f.Signature, time.Now().Unix())
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+// recordFixMessage remembers a message the bot posted in place of original, built from the
+// links signature stands for.
+func recordFixMessage(st Store, sent *discordgo.Message, original *discordgo.Message, signature string) error {
+	f := fixMessage{Signature: signature}
+	f.MessageID, _ = discordIDStringToInt64(sent.ID)
+	f.ChannelID, _ = discordIDStringToInt64(sent.ChannelID)
+	f.GuildID, _ = discordIDStringToInt64(original.GuildID)
+	f.OriginalID, _ = discordIDStringToInt64(original.ID)
+	if original.Author != nil {
+		f.AuthorID, _ = discordIDStringToInt64(original.Author.ID)
+	}
+	return st.RecordFixMessage(f)
+}
+
+func (st *sqliteStore) FixMessage(messageID int64) (originalID, authorID int64, err error) {
+	err = st.db.QueryRow("SELECT original_id, author_id FROM fix_messages WHERE message_id = ?", messageID).Scan(&originalID, &authorID)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	return originalID, authorID, err
+}
+
+func (st *sqliteStore) DeleteFixMessage(messageID int64) error {
+	_, err := st.db.Exec("DELETE FROM fix_messages WHERE message_id = ?", messageID)
+	return err
+}
+
+func (st *sqliteStore) UpdateChannelRetention(channelID int64, days int) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		var err error
+		if days <= 0 {
+			_, err = st.db.Exec("DELETE FROM channel_retention WHERE channel_id = ?", channelID)
+		} else {
+			_, err = st.db.Exec("INSERT OR REPLACE INTO channel_retention (channel_id, days) VALUES (?, ?)", channelID, days)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func (st *sqliteStore) LoadChannelRetention() (map[int64]int, error) {
+	rows, err := st.db.Query("SELECT channel_id, days FROM channel_retention")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	retention := make(map[int64]int)
+	for rows.Next() {
+		var channelID int64
+		var days int
+		if err := rows.Scan(&channelID, &days); err != nil {
+			continue
+		}
+		retention[channelID] = days
+	}
+	return retention, nil
+}
+
+func handleRetentionCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channelID := i.ChannelID
+	days := 0
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "days":
+			days = int(opt.IntValue())
+		case "channel":
+			channelID = opt.Value.(string)
+		}
+	}
+
+	cidInt, _ := discordIDStringToInt64(channelID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := st.UpdateChannelRetention(cidInt, days); err != nil {
+		logf(LOG_WARN, "Error updating retention for channel %s: %v", channelID, err)
+		embed.Description = T(interactionLocale(i), "retention.failed", "<#"+channelID+">")
+		embed.Color = 0xff0000
+	} else if days <= 0 {
+		embed.Description = T(interactionLocale(i), "retention.forever", "<#"+channelID+">")
+	} else {
+		embed.Description = T(interactionLocale(i), "retention.days", "<#"+channelID+">", days)
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// ExpiredFixMessages returns the fix messages that are older than their channel's retention policy.
+// Only MessageID and ChannelID are filled in.
+func (st *sqliteStore) ExpiredFixMessages(now time.Time) ([]fixMessage, error) {
+	rows, err := st.db.Query(`SELECT f.message_id, f.channel_id FROM fix_messages f JOIN channel_retention r ON f.channel_id = r.channel_id
+		WHERE r.days > 0 AND f.created_at < ? - r.days * 86400`, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var expired []fixMessage
+	for rows.Next() {
+		var f fixMessage
+		if err := rows.Scan(&f.MessageID, &f.ChannelID); err != nil {
+			continue
+		}
+		expired = append(expired, f)
+	}
+	return expired, nil
+}
+
+// ForgetFixMessages stops tracking fix messages older than cutoff in channels that never expire.
+func (st *sqliteStore) ForgetFixMessages(cutoff time.Time) error {
+	_, err := st.db.Exec("DELETE FROM fix_messages WHERE created_at < ? AND channel_id NOT IN (SELECT channel_id FROM channel_retention WHERE days > 0)", cutoff.Unix())
+	return err
+}
+
+// sweepExpiredFixMessages deletes the bot's fix messages that are older than their channel's retention policy.
+func sweepExpiredFixMessages(st Store, s *discordgo.Session) error {
+	expired, err := st.ExpiredFixMessages(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, e := range expired {
+		err := s.ChannelMessageDelete(fmt.Sprint(e.ChannelID), fmt.Sprint(e.MessageID))
+		var restErr *discordgo.RESTError
+		if err != nil && !(errors.As(err, &restErr) && restErr.Response != nil &&
+			(restErr.Response.StatusCode == http.StatusNotFound || restErr.Response.StatusCode == http.StatusForbidden)) {
+			// transient failure: keep the row and retry on the next sweep
+			logf(LOG_WARN, "Warning: failed to delete expired message %d in channel %d: %v", e.MessageID, e.ChannelID, err)
+			continue
+		}
+		_ = st.DeleteFixMessage(e.MessageID)
+	}
+
+	// Stop tracking old messages in channels that never expire
+	return st.ForgetFixMessages(time.Now().Add(-FIX_MESSAGE_TRACKING))
+}
+
+func startRetentionSweeper(st Store, s *discordgo.Session, stop <-chan struct{}) {
+	ticker := time.NewTicker(RETENTION_SWEEP_INTERVAL)
+	for {
+		select {
+		case <-ticker.C:
+			if err := sweepExpiredFixMessages(st, s); err != nil {
+				logf(LOG_WARN, "Error sweeping expired fix messages: %v", err)
+			}
+		case <-stop:
+			ticker.Stop()
+			return
+		}
+	}
+}
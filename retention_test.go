@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSweepExpiredFixMessages(t *testing.T) {
+	db := newTestDB(t)
+	original := &discordgo.Message{ID: "100", GuildID: "1", Author: &discordgo.User{ID: "5"}}
+	day := int64(24 * time.Hour / time.Second)
+	now := time.Now().Unix()
+	// message, channel, age in days
+	for _, m := range []struct {
+		id, channel string
+		age         int64
+	}{
+		{"10", "20", 3},  // past its channel's 2 days
+		{"11", "20", 1},  // within them
+		{"12", "21", 3},  // channel without a policy
+		{"13", "21", 40}, // no policy, beyond FIX_MESSAGE_TRACKING: forgotten, not deleted
+		{"14", "22", 5},  // already gone from Discord
+	} {
+		if err := recordFixMessage(db, &discordgo.Message{ID: m.id, ChannelID: m.channel}, original, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.db.Exec("UPDATE fix_messages SET created_at = ? WHERE message_id = ?", now-m.age*day, m.id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for channel, days := range map[int64]int{20: 2, 22: 1, 23: 7} {
+		if err := db.UpdateChannelRetention(channel, days); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// setting 0 days removes the policy
+	if err := db.UpdateChannelRetention(23, 0); err != nil {
+		t.Fatal(err)
+	}
+	var policies int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM channel_retention").Scan(&policies); err != nil || policies != 2 {
+		t.Fatalf("channel_retention has %d rows (%v), want 2", policies, err)
+	}
+
+	s, fake := newTestSession(t, func(r *http.Request) (int, string) {
+		if strings.Contains(r.URL.Path, "/messages/14") {
+			return http.StatusNotFound, `{"message": "Unknown Message", "code": 10008}`
+		}
+		return http.StatusNoContent, ""
+	})
+	if err := sweepExpiredFixMessages(db, s); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.calls()
+	slices.Sort(calls)
+	if want := []string{"DELETE /channels/20/messages/10", "DELETE /channels/22/messages/14"}; !slices.Equal(calls, want) {
+		t.Errorf("deleted %v, want %v", calls, want)
+	}
+	var remaining []string
+	rows, err := db.db.Query("SELECT message_id FROM fix_messages ORDER BY message_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		_ = rows.Scan(&id)
+		remaining = append(remaining, id)
+	}
+	if want := []string{"11", "12"}; !slices.Equal(remaining, want) {
+		t.Errorf("still tracking %v, want %v", remaining, want)
+	}
+}
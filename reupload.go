@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord allows at most this many attachments per message
+const MAX_ATTACHMENTS = 10
+
+// Upload limits by server boost tier
+const (
+	UPLOAD_LIMIT_DEFAULT = 10 << 20
+	UPLOAD_LIMIT_TIER_2  = 50 << 20
+	UPLOAD_LIMIT_TIER_3  = 100 << 20
+)
+
+// videos take longer than a redirect check
+var mediaClient = &http.Client{Timeout: 30 * time.Second}
+
+// guildUploadLimit returns how many bytes the bot may attach to a message in the guild.
+func guildUploadLimit(s *discordgo.Session, guildID string) int64 {
+	g, err := s.State.Guild(guildID)
+	if err != nil {
+		return UPLOAD_LIMIT_DEFAULT
+	}
+	switch g.PremiumTier {
+	case discordgo.PremiumTier2:
+		return UPLOAD_LIMIT_TIER_2
+	case discordgo.PremiumTier3:
+		return UPLOAD_LIMIT_TIER_3
+	}
+	return UPLOAD_LIMIT_DEFAULT
+}
+
+// downloadMedia fetches a post's media as attachments, at most maxFiles of them. ok=false means
+// the media could not all be fetched within limit bytes and the caller should fall back to
+// posting the link; otherwise size is how many bytes were downloaded.
+func downloadMedia(urls []string, maxFiles int, limit int64) (files []*discordgo.File, size int64, ok bool) {
+	if len(urls) == 0 || len(urls) > maxFiles {
+		return nil, 0, false
+	}
+	files = make([]*discordgo.File, 0, len(urls))
+	var total int64
+	for idx, u := range urls {
+		data, contentType, err := fetchMedia(u, limit-total)
+		if err != nil {
+			logf(LOG_DEBUG, "downloadMedia: %s: %v", u, err)
+			return nil, 0, false
+		}
+		total += int64(len(data))
+		files = append(files, &discordgo.File{
+			Name:        mediaFileName(u, contentType, idx),
+			ContentType: contentType,
+			Reader:      bytes.NewReader(data),
+		})
+	}
+	return files, total, true
+}
+
+// fetchMedia downloads u, failing once more than limit bytes have been read.
+func fetchMedia(u string, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", "FixEmbed/"+VERSION)
+	resp, err := mediaClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("returned %s", resp.Status)
+	}
+	if resp.ContentLength > limit {
+		return nil, "", fmt.Errorf("%d bytes exceeds the upload limit", resp.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("exceeds the upload limit")
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// mediaFileName picks an attachment name from the URL, or from the content type when the URL has no extension.
+func mediaFileName(u, contentType string, idx int) string {
+	name := path.Base(strings.SplitN(u, "?", 2)[0])
+	if path.Ext(name) != "" {
+		return name
+	}
+	ext := ".bin"
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("media%d%s", idx+1, ext)
+}
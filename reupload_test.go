@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDownloadMedia(t *testing.T) {
+	media := map[string]string{
+		"https://pbs.twimg.com/media/a.jpg?name=orig": "jpeg data",
+		"https://video.twimg.com/v":                   "png data",
+	}
+	transport := mediaClient.Transport
+	t.Cleanup(func() { mediaClient.Transport = transport })
+	mediaClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, ok := media[r.URL.String()]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody, Request: r}, nil
+		}
+		header := http.Header{"Content-Type": {"image/png"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, ContentLength: -1, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+
+	files, size, ok := downloadMedia([]string{"https://pbs.twimg.com/media/a.jpg?name=orig", "https://video.twimg.com/v"}, MAX_ATTACHMENTS, 100)
+	if !ok || len(files) != 2 || size != 17 {
+		t.Fatalf("downloadMedia = %v, %d, %t", files, size, ok)
+	}
+	if files[0].Name != "a.jpg" || files[1].Name != "media2.png" {
+		t.Errorf("file names %q, %q", files[0].Name, files[1].Name)
+	}
+
+	// over the size limit or the attachment count, so the link is posted instead
+	if _, _, ok := downloadMedia([]string{"https://pbs.twimg.com/media/a.jpg?name=orig", "https://video.twimg.com/v"}, MAX_ATTACHMENTS, 12); ok {
+		t.Error("media over the upload limit was attached")
+	}
+	if _, _, ok := downloadMedia([]string{"https://pbs.twimg.com/media/a.jpg?name=orig", "https://video.twimg.com/v"}, 1, 100); ok {
+		t.Error("more media than attachments left was attached")
+	}
+	if _, _, ok := downloadMedia([]string{"https://video.twimg.com/missing"}, MAX_ATTACHMENTS, 100); ok {
+		t.Error("missing media was attached")
+	}
+	if _, _, ok := downloadMedia(nil, MAX_ATTACHMENTS, 100); ok {
+		t.Error("a post without media was attached")
+	}
+}
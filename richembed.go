@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PostMetadata is what a fixer's JSON API tells us about a post, enough to build an embed.
+type PostMetadata struct {
+	URL        string
+	Author     string
+	AuthorURL  string
+	AuthorIcon string
+	Title      string
+	Text       string
+	Images     []string // shown in embeds; video thumbnails stand in for videos
+	Media      []string // the photos and videos themselves, for re-uploading
+	Likes      int
+	Reposts    int
+	Timestamp  time.Time
+}
+
+// getJSON fetches a fixer API endpoint and decodes the response into v.
+func getJSON(endpoint string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "FixEmbed/"+VERSION)
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{URL: endpoint, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// httpStatusError is returned by getJSON for responses other than 200 OK.
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s returned %s", e.URL, e.Status)
+}
+
+var twitterAPIRe = regexp.MustCompile(`^(?:twitter|x)\.com/([A-Za-z0-9_]+)/status/([0-9]+)`)
+
+// fetchTwitterMetadata looks a post up through the FxTwitter API.
+func fetchTwitterMetadata(link string) (*PostMetadata, error) {
+	mm := twitterAPIRe.FindStringSubmatch(link)
+	if len(mm) < 3 {
+		return nil, fmt.Errorf("not a status link: %s", link)
+	}
+	var data struct {
+		Tweet struct {
+			URL    string `json:"url"`
+			Text   string `json:"text"`
+			Author struct {
+				Name       string `json:"name"`
+				ScreenName string `json:"screen_name"`
+				AvatarURL  string `json:"avatar_url"`
+			} `json:"author"`
+			Likes            int   `json:"likes"`
+			Retweets         int   `json:"retweets"`
+			CreatedTimestamp int64 `json:"created_timestamp"`
+			Media            struct {
+				Photos []struct {
+					URL string `json:"url"`
+				} `json:"photos"`
+				Videos []struct {
+					URL          string `json:"url"`
+					ThumbnailURL string `json:"thumbnail_url"`
+				} `json:"videos"`
+			} `json:"media"`
+		} `json:"tweet"`
+	}
+	if err := getJSON(fmt.Sprintf("https://api.fxtwitter.com/%s/status/%s", mm[1], mm[2]), &data); err != nil {
+		return nil, err
+	}
+	t := data.Tweet
+	meta := &PostMetadata{
+		URL:        t.URL,
+		Author:     fmt.Sprintf("%s (@%s)", t.Author.Name, t.Author.ScreenName),
+		AuthorURL:  "https://x.com/" + t.Author.ScreenName,
+		AuthorIcon: t.Author.AvatarURL,
+		Text:       t.Text,
+		Likes:      t.Likes,
+		Reposts:    t.Retweets,
+		Timestamp:  time.Unix(t.CreatedTimestamp, 0),
+	}
+	for _, p := range t.Media.Photos {
+		meta.Images = append(meta.Images, p.URL)
+		meta.Media = append(meta.Media, p.URL)
+	}
+	// Discord can't play videos in a bot embed; the thumbnail stands in
+	for _, v := range t.Media.Videos {
+		meta.Images = append(meta.Images, v.ThumbnailURL)
+		meta.Media = append(meta.Media, v.URL)
+	}
+	return meta, nil
+}
+
+var pixivAPIRe = regexp.MustCompile(`^pixiv\.net/(?:en/)?artworks/([0-9]+)`)
+
+// fetchPixivMetadata looks an artwork up through the phixiv API.
+func fetchPixivMetadata(link string) (*PostMetadata, error) {
+	mm := pixivAPIRe.FindStringSubmatch(link)
+	if len(mm) < 2 {
+		return nil, fmt.Errorf("not an artwork link: %s", link)
+	}
+	var data struct {
+		URL             string   `json:"url"`
+		Title           string   `json:"title"`
+		Description     string   `json:"description"`
+		AuthorName      string   `json:"author_name"`
+		AuthorID        string   `json:"author_id"`
+		ProfileImageURL string   `json:"profile_image_url"`
+		ImageProxyURLs  []string `json:"image_proxy_urls"`
+	}
+	if err := getJSON("https://phixiv.net/api/info?language=en&id="+url.QueryEscape(mm[1]), &data); err != nil {
+		return nil, err
+	}
+	return &PostMetadata{
+		URL:        data.URL,
+		Author:     data.AuthorName,
+		AuthorURL:  "https://www.pixiv.net/users/" + data.AuthorID,
+		AuthorIcon: data.ProfileImageURL,
+		Title:      data.Title,
+		Text:       data.Description,
+		Images:     data.ImageProxyURLs,
+		Media:      data.ImageProxyURLs,
+	}, nil
+}
+
+// richEmbed renders post metadata as a Discord embed.
+func richEmbed(svc *Service, meta *PostMetadata, originalLink string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		URL:         meta.URL,
+		Title:       meta.Title,
+		Description: meta.Text,
+		Color:       0x5865F2,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    meta.Author,
+			URL:     meta.AuthorURL,
+			IconURL: meta.AuthorIcon,
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: svc.label()},
+	}
+	if embed.URL == "" {
+		embed.URL = "https://" + originalLink
+	}
+	// embed descriptions are capped at 4096 characters
+	if runes := []rune(embed.Description); len(runes) > 4000 {
+		embed.Description = string(runes[:4000]) + "…"
+	}
+	if len(meta.Images) > 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: meta.Images[0]}
+	}
+	if meta.Likes > 0 || meta.Reposts > 0 {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Likes", Value: fmt.Sprintf("%d", meta.Likes), Inline: true},
+			{Name: "Reposts", Value: fmt.Sprintf("%d", meta.Reposts), Inline: true},
+		}
+	}
+	if !meta.Timestamp.IsZero() {
+		embed.Timestamp = meta.Timestamp.Format(time.RFC3339)
+	}
+	return embed
+}
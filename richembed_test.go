@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchTwitterMetadata(t *testing.T) {
+	serveRedirectClient(t, map[string]string{
+		"https://api.fxtwitter.com/someone/status/123": `{"tweet": {"url": "https://x.com/someone/status/123", "text": "hello",
+			"author": {"name": "Some One", "screen_name": "someone", "avatar_url": "https://pbs.twimg.com/a.jpg"},
+			"likes": 5, "retweets": 2, "created_timestamp": 1700000000,
+			"media": {"photos": [{"url": "https://pbs.twimg.com/1.jpg"}], "videos": [{"thumbnail_url": "https://pbs.twimg.com/2.jpg"}]}}}`,
+	})
+	meta, err := fetchTwitterMetadata("x.com/someone/status/123/photo/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Author != "Some One (@someone)" || meta.AuthorURL != "https://x.com/someone" || meta.Likes != 5 || meta.Reposts != 2 ||
+		len(meta.Images) != 2 || meta.Images[1] != "https://pbs.twimg.com/2.jpg" {
+		t.Errorf("metadata = %+v", meta)
+	}
+	if _, err := fetchTwitterMetadata("x.com/someone/status/456"); err == nil {
+		t.Error("a post the API does not know should fail")
+	}
+	if _, err := fetchTwitterMetadata("x.com/someone"); err == nil {
+		t.Error("a profile link should fail")
+	}
+
+	embed := richEmbed(findService("Twitter"), meta, "x.com/someone/status/123")
+	posted, _ := time.Parse(time.RFC3339, embed.Timestamp)
+	if embed.URL != meta.URL || embed.Image.URL != meta.Images[0] || len(embed.Fields) != 2 || posted.Unix() != 1700000000 {
+		t.Errorf("embed = %+v", embed)
+	}
+}
+
+func TestRichEmbedLimits(t *testing.T) {
+	meta := &PostMetadata{Author: "artist", Text: strings.Repeat("あ", 5000)}
+	embed := richEmbed(findService("Pixiv"), meta, "pixiv.net/en/artworks/123")
+	if embed.URL != "https://pixiv.net/en/artworks/123" {
+		t.Errorf("URL = %q, want the original link", embed.URL)
+	}
+	if n := len([]rune(embed.Description)); n != 4001 {
+		t.Errorf("description has %d runes, want 4000 and an ellipsis", n)
+	}
+	if embed.Image != nil || embed.Fields != nil || embed.Timestamp != "" {
+		t.Errorf("empty metadata filled in %+v", embed)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleServiceCommand enables or disables one service, as a scriptable alternative to the services menu.
+func handleServiceCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0]
+	name := ""
+	for _, opt := range sub.Options {
+		if opt.Name == "service" {
+			name = opt.StringValue()
+		}
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	updated := *getGuildSettings(gidInt)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	respond := func() {
+		createFooter(embed, s)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+			},
+		})
+	}
+
+	svc := lookupService(name)
+	if svc == nil {
+		embed.Description = fmt.Sprintf("❌ There's no service called `%s`.", name)
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	enable := sub.Name == "enable"
+	if enable && svc.Available != nil && !svc.Available(&updated) {
+		embed.Description = fmt.Sprintf("❌ %s needs setting up first (see /help).", svc.label())
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	if enable == slices.Contains(updated.EnabledServices, svc.Name) {
+		state := "enabled"
+		if !enable {
+			state = "disabled"
+		}
+		embed.Description = fmt.Sprintf("%s is already %s.", svc.label(), state)
+		respond()
+		return
+	}
+
+	// a fresh slice, so the cached settings aren't changed before they're saved
+	services := slices.DeleteFunc(slices.Clone(updated.EnabledServices), func(n string) bool { return n == svc.Name })
+	if enable {
+		services = append(services, svc.Name)
+	}
+	if err := st.UpdateSetting(gidInt, services, updated.MentionUsers, updated.DeleteOriginal); err != nil {
+		logf(LOG_WARN, "Error updating services for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the services."
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	updated.EnabledServices = services
+	cacheGuildSettings(gidInt, &updated)
+
+	if enable {
+		embed.Description = fmt.Sprintf("✅ %s links will be fixed.", svc.label())
+	} else {
+		embed.Description = fmt.Sprintf("❌ %s links will be left alone.", svc.label())
+		embed.Color = 0xff0000
+	}
+	respond()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServiceCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+	run := func(sub, service string) string {
+		handleServiceCommand(db, s, slashCommand("service", option(sub, nil, option("service", service))))
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	run("disable", "twitter")
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	if services := getGuildSettings(1).EnabledServices; slices.Contains(services, "Twitter") || !slices.Contains(services, "Reddit") {
+		t.Errorf("services after disabling Twitter = %v", services)
+	}
+	if body := run("disable", "Twitter"); !strings.Contains(body, "already disabled") {
+		t.Errorf("disabling again = %s", body)
+	}
+	run("enable", "Twitter")
+	if !slices.Contains(getGuildSettings(1).EnabledServices, "Twitter") {
+		t.Error("Twitter wasn't enabled again")
+	}
+	if body := run("enable", "myspace"); !strings.Contains(body, "no service called") {
+		t.Errorf("unknown service = %s", body)
+	}
+}
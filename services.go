@@ -0,0 +1,588 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Service describes one supported site: how its links are recognised, normalized and
+// rewritten to a fixer frontend. The registry below drives link matching, the per-guild
+// service toggles and the settings UI, so adding a site is a single entry.
+type Service struct {
+	Name  string // toggle name, stored in guild_settings.enabled_services
+	Label string // shown before " • " in the display text (defaults to Name)
+	Fixer string // display name of the frontend links are rewritten to
+
+	// Display is a template for the display text with {service} and {user} placeholders;
+	// empty means "{service} • {user}".
+	Display string
+
+	// Domains are the hosts (lowercase, without www.) whose links belong to the service.
+	Domains []string
+	// ExtraDomains adds hosts that are only known at runtime (configured instances).
+	ExtraDomains func(settings *GuildSettings) []string
+
+	// Patterns are link-pattern alternatives matching post URLs without the scheme.
+	// "{domains}" expands to an alternation of all of the service's domains.
+	Patterns []string
+
+	// Canonicalize normalizes a matched link (following redirects if needed) and returns
+	// the user/community shown in the display text. ok=false skips the link.
+	// When nil, the link is used as-is and the first path segment is shown.
+	Canonicalize func(link, domain string) (canonical, user string, ok bool)
+	// Rewrite maps a canonical link onto the fixer frontend.
+	Rewrite func(link string) string
+	// Frontends are alternatives to Fixer/Rewrite that a guild can pick instead.
+	Frontends []Frontend
+	// Fallbacks are fixer hosts tried in order when the chosen frontend is down.
+	Fallbacks []string
+	// Translate adds a translation to the given language to a fixed link, if the fixer supports it.
+	Translate func(fixed, language string) string
+	// Metadata looks a canonical link up through the fixer's JSON API, for rich embeds.
+	Metadata func(canonical string) (*PostMetadata, error)
+
+	// Available reports whether a guild can use the service at all; nil means always.
+	Available func(settings *GuildSettings) bool
+}
+
+// Frontend is an alternative fixer for a service.
+type Frontend struct {
+	Name    string
+	Rewrite func(link string) string
+}
+
+// FixedLink is the outcome of running one matched link through its service.
+type FixedLink struct {
+	Service     *Service
+	Original    string // canonical original link, without scheme
+	Fixed       string // rewritten link, without scheme
+	Fixer       string // name of the frontend Fixed points at
+	User        string
+	DisplayText string
+}
+
+// Patterns the Canonicalize functions take a post's user or ID from, compiled once rather than per link
+var (
+	blueskyPostRe     = regexp.MustCompile(`bsky\.app/profile/([^/]+)/post/`)
+	bilibiliVideoRe   = regexp.MustCompile(`bilibili\.com/video/(BV[A-Za-z0-9]+)`)
+	weiboMobileRe     = regexp.MustCompile(`m\.weibo\.cn/(?:status|detail)/([A-Za-z0-9]+)`)
+	weiboUserRe       = regexp.MustCompile(`weibo\.com/([0-9]+)/`)
+	newgroundsArtRe   = regexp.MustCompile(`newgrounds\.com/art/view/([A-Za-z0-9_-]+)/`)
+	douyinVideoRe     = regexp.MustCompile(`douyin\.com/(?:share/)?video/([0-9]+)`)
+	redditSubredditRe = regexp.MustCompile(`reddit\.com/r/([A-Za-z0-9_]+)`)
+)
+
+var services = []*Service{
+	{
+		Name:    "Twitter",
+		Fixer:   "FxTwitter",
+		Domains: []string{"twitter.com", "x.com", "mobile.twitter.com", "mobile.x.com"},
+		// Nitter mirrors Twitter's /user/status/id paths
+		ExtraDomains: func(*GuildSettings) []string {
+			runtimeConfig.RLock()
+			defer runtimeConfig.RUnlock()
+			return nitterDomains
+		},
+		Patterns: []string{
+			`{domains}/[A-Za-z0-9_]+/status/[0-9]+(?:/(?:photo|video)/[0-9]+)?`,
+			`(?:mobile\.)?(?:twitter|x)\.com/i/web/status/[0-9]+`,
+		},
+		Canonicalize: canonicalTwitterLink,
+		// any /photo/N or /video/N suffix is kept so FxTwitter embeds that media item
+		Rewrite: rewriteHost(map[string]string{"twitter.com": "fxtwitter.com", "x.com": "fixupx.com"}),
+		Frontends: []Frontend{
+			{Name: "vxTwitter", Rewrite: rewriteHost(map[string]string{"twitter.com": "vxtwitter.com", "x.com": "fixvx.com"})},
+		},
+		Fallbacks: []string{"fixupx.com", "vxtwitter.com", "fixvx.com"},
+		Translate: translateTwitterLink,
+		Metadata:  fetchTwitterMetadata,
+	},
+	{
+		Name:    "Instagram",
+		Fixer:   "InstaFix",
+		Domains: []string{"instagram.com"},
+		Patterns: []string{
+			`instagram\.com/(?:[A-Za-z0-9_.]+/)?(?:p|reels?|tv)/[A-Za-z0-9_-]+`,
+			`instagram\.com/share/(?:p/|reels?/)?[A-Za-z0-9_-]+`,
+			`instagram\.com/stories/[A-Za-z0-9_.]+/[0-9]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if strings.HasPrefix(link, "instagram.com/share/") {
+				// share links redirect to the real post/reel
+				resolved, ok := resolveOrLog(link)
+				if !ok {
+					return "", "", false
+				}
+				link = resolved
+			}
+			return canonicalInstagramLink(link)
+		},
+		Rewrite: rewriteHost(map[string]string{"instagram.com": "instafix.ldez.top"}),
+		Frontends: []Frontend{
+			{Name: "ddinstagram", Rewrite: rewriteHost(map[string]string{"instagram.com": "ddinstagram.com"})},
+			{Name: "kkinstagram", Rewrite: rewriteHost(map[string]string{"instagram.com": "kkinstagram.com"})},
+		},
+		Fallbacks: []string{"ddinstagram.com", "kkinstagram.com"},
+	},
+	{
+		Name:    "Reddit",
+		Fixer:   "vxReddit",
+		Domains: []string{"reddit.com", "old.reddit.com", "redd.it", "i.redd.it"},
+		Patterns: []string{
+			`reddit\.com/r/[A-Za-z0-9_]+/s/[A-Za-z0-9_]+`,
+			`redd\.it/[A-Za-z0-9]+`,
+			`reddit\.com/gallery/[A-Za-z0-9]+`,
+			`i\.redd\.it/[A-Za-z0-9_-]+\.[A-Za-z0-9]+`,
+			`reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+`,
+			`old\.reddit\.com/r/[A-Za-z0-9_]+/comments/[A-Za-z0-9_]+/[A-Za-z0-9_]+`,
+		},
+		Canonicalize: canonicalRedditMatch,
+		// direct media already embeds; it is reposted as-is
+		Rewrite: rewriteHost(map[string]string{"old.reddit.com": "old.rxddit.com", "reddit.com": "vxreddit.ldez.workers.dev", "i.redd.it": "i.redd.it"}),
+		Frontends: []Frontend{
+			{Name: "rxddit", Rewrite: rewriteHost(map[string]string{"old.reddit.com": "old.rxddit.com", "reddit.com": "rxddit.com", "i.redd.it": "i.redd.it"})},
+		},
+		Fallbacks: []string{"rxddit.com"},
+	},
+	{
+		Name:    "Threads",
+		Fixer:   "FixThreads",
+		Domains: []string{"threads.net", "threads.com"},
+		Patterns: []string{
+			`{domains}/@[^/]+/post/[A-Za-z0-9_-]+`,
+			`{domains}/t/[A-Za-z0-9_-]+`,
+		},
+		Canonicalize: canonicalThreadsLink,
+		Rewrite:      rewriteHost(map[string]string{"threads.net": "fixthreads.net", "threads.com": "fixthreads.net"}),
+		Frontends: []Frontend{
+			{Name: "vxThreads", Rewrite: rewriteHost(map[string]string{"threads.net": "vxthreads.net", "threads.com": "vxthreads.net"})},
+		},
+		Fallbacks: []string{"vxthreads.net"},
+	},
+	{
+		Name:         "Pixiv",
+		Fixer:        "phixiv",
+		Domains:      []string{"pixiv.net"},
+		Patterns:     []string{`pixiv\.net/(?:en/)?artworks/[0-9]+(?:#[0-9]+)?`},
+		Canonicalize: captureUser(regexp.MustCompile(`pixiv\.net/(?:en/)?artworks/([0-9]+)`)),
+		Rewrite: func(link string) string {
+			link = rewriteHost(map[string]string{"pixiv.net": "phixiv.net"})(link)
+			// artworks/123#3 points at the third page; phixiv takes the page as a path segment
+			if base, page, ok := strings.Cut(link, "#"); ok {
+				link = base + "/" + page
+			}
+			return link
+		},
+		Metadata: fetchPixivMetadata,
+	},
+	{
+		Name:  "Bluesky",
+		Fixer: "FxBluesky",
+		// other AppView frontends mirror bsky.app's paths, so they are treated as bsky.app links
+		Domains:  []string{"bsky.app", "deer.social", "main.bsky.dev", "staging.bsky.app"},
+		Patterns: []string{`{domains}/profile/[^/]+/post/[A-Za-z0-9_-]+`},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			link = "bsky.app" + link[len(domain):]
+			return captureUser(blueskyPostRe)(link, "bsky.app")
+		},
+		Rewrite: rewriteHost(map[string]string{"bsky.app": "fxbsky.app"}),
+		Frontends: []Frontend{
+			{Name: "VixBluesky", Rewrite: rewriteHost(map[string]string{"bsky.app": "bskx.app"})},
+		},
+		Fallbacks: []string{"bskx.app"},
+	},
+	{
+		Name:    "Facebook",
+		Fixer:   "facebed",
+		Domains: []string{"facebook.com", "m.facebook.com", "fb.watch"},
+		Patterns: []string{
+			`(?:m\.)?facebook\.com/(?:share/r|reel)/[A-Za-z0-9_-]+`,
+			`fb\.watch/[A-Za-z0-9_-]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if domain == "fb.watch" {
+				// fb.watch short links only redirect to the real video page
+				resolved, ok := resolveOrLog(link)
+				if !ok || !strings.HasPrefix(resolved, "facebook.com/") {
+					return "", "", false
+				}
+				return resolved, "Video", true
+			}
+			return strings.TrimPrefix(link, "m."), "Reel", true
+		},
+		Rewrite: rewriteHost(map[string]string{"facebook.com": "facebed.com"}),
+	},
+	{
+		Name:         "DeviantArt",
+		Fixer:        "fixDeviantArt",
+		Domains:      []string{"deviantart.com"},
+		Patterns:     []string{`deviantart\.com/[A-Za-z0-9_-]+/art/[A-Za-z0-9_-]+`},
+		Canonicalize: captureUser(regexp.MustCompile(`deviantart\.com/([A-Za-z0-9_-]+)/art/`)),
+		Rewrite:      rewriteHost(map[string]string{"deviantart.com": "fixdeviantart.com"}),
+	},
+	{
+		Name:    "Bilibili",
+		Label:   "bilibili",
+		Fixer:   "vxbilibili",
+		Domains: []string{"bilibili.com", "m.bilibili.com", "b23.tv"},
+		Patterns: []string{
+			`(?:m\.)?bilibili\.com/video/BV[A-Za-z0-9]+`,
+			`b23\.tv/[A-Za-z0-9]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if domain == "b23.tv" {
+				// b23.tv short links have to be expanded before they can be rewritten
+				resolved, ok := resolveOrLog(link)
+				if !ok {
+					return "", "", false
+				}
+				link = resolved
+			}
+			mm := bilibiliVideoRe.FindStringSubmatch(link)
+			if len(mm) < 2 {
+				return "", "", false
+			}
+			return "bilibili.com/video/" + mm[1], mm[1], true
+		},
+		Rewrite: rewriteHost(map[string]string{"bilibili.com": "vxbilibili.com"}),
+	},
+	{
+		Name:    "Weibo",
+		Fixer:   "FxWeibo",
+		Domains: []string{"weibo.com", "m.weibo.cn"},
+		Patterns: []string{
+			`weibo\.com/[0-9]+/[A-Za-z0-9]+`,
+			`m\.weibo\.cn/(?:status|detail)/[A-Za-z0-9]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if domain == "m.weibo.cn" {
+				// mobile status links only carry the post ID; use the desktop detail page
+				mm := weiboMobileRe.FindStringSubmatch(link)
+				if len(mm) < 2 {
+					return "", "", false
+				}
+				return "weibo.com/detail/" + mm[1], "Post", true
+			}
+			return captureUser(weiboUserRe)(link, domain)
+		},
+		Rewrite: rewriteHost(map[string]string{"weibo.com": "fxweibo.com"}),
+	},
+	{
+		Name:     "iFunny",
+		Fixer:    "iFunnyFix",
+		Domains:  []string{"ifunny.co"},
+		Patterns: []string{`ifunny\.co/(?:picture|video)/[A-Za-z0-9_-]+`},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if strings.HasPrefix(link, "ifunny.co/video/") {
+				return link, "Video", true
+			}
+			return link, "Picture", true
+		},
+		Rewrite: rewriteHost(map[string]string{"ifunny.co": "ifunnyfix.com"}),
+	},
+	{
+		Name:    "RedNote",
+		Fixer:   "xhsfix",
+		Domains: []string{"xiaohongshu.com", "xhslink.com"},
+		Patterns: []string{
+			`xiaohongshu\.com/(?:explore|discovery/item)/[0-9a-f]+(?:\?[^\s>]*)?`,
+			`xhslink\.com/(?:a/)?[A-Za-z0-9]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if domain == "xhslink.com" {
+				resolved, ok := resolveOrLog(link)
+				if !ok {
+					return "", "", false
+				}
+				link = resolved
+			}
+			link, ok := canonicalXiaohongshuLink(link)
+			if !ok {
+				return "", "", false
+			}
+			return link, fetchXiaohongshuAuthor(link), true
+		},
+		Rewrite: rewriteHost(map[string]string{"xiaohongshu.com": "xhsfix.com"}),
+	},
+	{
+		Name:    "Newgrounds",
+		Fixer:   "FixNewgrounds",
+		Domains: []string{"newgrounds.com"},
+		Patterns: []string{
+			`newgrounds\.com/art/view/[A-Za-z0-9_-]+/[A-Za-z0-9_-]+`,
+			`newgrounds\.com/audio/listen/[0-9]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if strings.HasPrefix(link, "newgrounds.com/audio/") {
+				return link, "Audio", true
+			}
+			return captureUser(newgroundsArtRe)(link, domain)
+		},
+		Rewrite: rewriteHost(map[string]string{"newgrounds.com": "fixnewgrounds.com"}),
+	},
+	{
+		Name:    "Douyin",
+		Fixer:   "vxdouyin",
+		Domains: []string{"douyin.com", "v.douyin.com"},
+		Patterns: []string{
+			`douyin\.com/video/[0-9]+`,
+			`v\.douyin\.com/[A-Za-z0-9_-]+`,
+		},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			if domain == "v.douyin.com" {
+				// short links land on iesdouyin.com/share/video/<id>
+				resolved, ok := resolveOrLog(link)
+				if !ok {
+					return "", "", false
+				}
+				link = resolved
+			}
+			mm := douyinVideoRe.FindStringSubmatch(link)
+			if len(mm) < 2 {
+				return "", "", false
+			}
+			return "douyin.com/video/" + mm[1], "Video", true
+		},
+		Rewrite: rewriteHost(map[string]string{"douyin.com": "vxdouyin.com"}),
+	},
+	{
+		Name:         "Mastodon",
+		Fixer:        "FxMastodon",
+		ExtraDomains: func(settings *GuildSettings) []string { return settings.MastodonInstances },
+		Patterns:     []string{`{domains}/@[A-Za-z0-9_]+(?:@[A-Za-z0-9.-]+)?/[0-9]+`},
+		Canonicalize: func(link, domain string) (string, string, bool) {
+			mm := mastodonPostRe.FindStringSubmatch(link)
+			if len(mm) < 2 {
+				return "", "", false
+			}
+			return link, "@" + mm[1], true
+		},
+		// the frontend proxies any instance: <fixer>/<instance>/@user/<id>
+		Rewrite: func(link string) string {
+			runtimeConfig.RLock()
+			defer runtimeConfig.RUnlock()
+			return fmt.Sprintf("%s/%s", mastodonFixerDomain, link)
+		},
+		// only offered once the guild has registered an instance
+		Available: func(settings *GuildSettings) bool { return len(settings.MastodonInstances) > 0 },
+	},
+}
+
+func findService(name string) *Service {
+	for _, svc := range services {
+		if svc.Name == name {
+			return svc
+		}
+	}
+	return nil
+}
+
+func (svc *Service) label() string {
+	if svc.Label != "" {
+		return svc.Label
+	}
+	return svc.Name
+}
+
+func (svc *Service) domainsFor(settings *GuildSettings) []string {
+	domains := svc.Domains
+	if svc.ExtraDomains != nil {
+		domains = append(append([]string{}, domains...), svc.ExtraDomains(settings)...)
+	}
+	return domains
+}
+
+// servicePattern joins the link patterns of every service available to the guild.
+func servicePattern(settings *GuildSettings) string {
+	var alternatives []string
+	for _, svc := range services {
+		if svc.Available != nil && !svc.Available(settings) {
+			continue
+		}
+		domains := svc.domainsFor(settings)
+		quoted := make([]string, 0, len(domains))
+		for _, d := range domains {
+			quoted = append(quoted, regexp.QuoteMeta(d))
+		}
+		for _, p := range svc.Patterns {
+			if strings.Contains(p, "{domains}") {
+				if len(quoted) == 0 {
+					continue
+				}
+				p = strings.ReplaceAll(p, "{domains}", "(?:"+strings.Join(quoted, "|")+")")
+			}
+			alternatives = append(alternatives, p)
+		}
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// serviceForDomain finds the service that handles links on domain.
+func serviceForDomain(domain string, settings *GuildSettings) *Service {
+	for _, svc := range services {
+		if svc.Available != nil && !svc.Available(settings) {
+			continue
+		}
+		for _, d := range svc.domainsFor(settings) {
+			if strings.EqualFold(d, domain) {
+				return svc
+			}
+		}
+	}
+	return nil
+}
+
+// frontend returns the fixer the guild picked for the service, falling back to the default.
+func (svc *Service) frontend(settings *GuildSettings) Frontend {
+	if name, ok := settings.Frontends[svc.Name]; ok {
+		for _, fe := range svc.Frontends {
+			if fe.Name == name {
+				return fe
+			}
+		}
+	}
+	return Frontend{Name: svc.Fixer, Rewrite: svc.Rewrite}
+}
+
+// fix canonicalizes and rewrites a matched link. ok=false means the link should be skipped.
+func (svc *Service) fix(link, domain string, settings *GuildSettings) (*FixedLink, bool) {
+	canonical, user := link, ""
+	if svc.Canonicalize != nil {
+		var ok bool
+		canonical, user, ok = svc.Canonicalize(link, domain)
+		if !ok {
+			return nil, false
+		}
+	}
+	if user == "" {
+		user = "Unknown"
+		if parts := strings.Split(canonical, "/"); len(parts) > 1 {
+			user = parts[1]
+		}
+	}
+	display := fmt.Sprintf("%s • %s", svc.label(), escapeMarkdown(user))
+	if svc.Display != "" {
+		display = strings.NewReplacer("{service}", svc.label(), "{user}", escapeMarkdown(user)).Replace(svc.Display)
+	}
+	fe := svc.frontend(settings)
+	fixed := fe.Rewrite(canonical)
+	fixer := fe.Name
+	if link, host, ok := svc.withFallback(fixed, canonical); ok {
+		fixed, fixer = link, host
+	}
+	if settings.TranslateLanguage != "" && svc.Translate != nil {
+		fixed = svc.Translate(fixed, settings.TranslateLanguage)
+	}
+	if settings.DirectMedia {
+		fixed = directMediaLink(fixed)
+	}
+	return &FixedLink{
+		Service:     svc,
+		Original:    canonical,
+		Fixed:       fixed,
+		Fixer:       fixer,
+		User:        user,
+		DisplayText: display,
+	}, true
+}
+
+// rewriteHost swaps a link's leading host for its fixer host.
+func rewriteHost(hosts map[string]string) func(string) string {
+	return func(link string) string {
+		host, rest, _ := strings.Cut(link, "/")
+		if fixer, ok := hosts[strings.ToLower(host)]; ok {
+			return fixer + "/" + rest
+		}
+		return link
+	}
+}
+
+// captureUser builds a Canonicalize func that keeps the link and takes the user from re's first group.
+func captureUser(re *regexp.Regexp) func(link, domain string) (string, string, bool) {
+	return func(link, domain string) (string, string, bool) {
+		if mm := re.FindStringSubmatch(link); len(mm) > 1 {
+			return link, mm[1], true
+		}
+		return link, "Unknown", true
+	}
+}
+
+// resolveOrLog expands a short link, logging (and reporting false) when that fails.
+func resolveOrLog(link string) (string, bool) {
+	resolved, err := resolveRedirect(link)
+	if err != nil {
+		logf(LOG_DEBUG, "resolveRedirect: could not resolve %s: %v", link, err)
+		return "", false
+	}
+	return resolved, true
+}
+
+var twitterStatusRe = regexp.MustCompile(`(?:twitter\.com|x\.com)/([A-Za-z0-9_]+)/status/[0-9]+`)
+
+func canonicalTwitterLink(link, domain string) (string, string, bool) {
+	host := link[:len(domain)]
+	switch {
+	case isNitterDomain(domain):
+		link = "twitter.com" + link[len(host):]
+	case strings.HasPrefix(domain, "mobile."):
+		// links copied from the mobile web app
+		link = link[len("mobile."):]
+	}
+	// twitter.com/i/web/status/<id> has no author; FxTwitter accepts /i/status/<id>
+	link = strings.Replace(link, "/i/web/status/", "/i/status/", 1)
+	mm := twitterStatusRe.FindStringSubmatch(link)
+	switch {
+	case len(mm) > 1 && mm[1] == "i":
+		return link, "Post", true
+	case len(mm) > 1:
+		return link, mm[1], true
+	}
+	return link, "Unknown", true
+}
+
+func canonicalRedditMatch(link, domain string) (string, string, bool) {
+	switch {
+	case domain == "i.redd.it":
+		return link, "Media", true
+	case domain == "redd.it" || strings.Contains(link, "/s/"):
+		// share links only redirect to the post; swapping their domain breaks them
+		resolved, ok := resolveOrLog(link)
+		if !ok {
+			return "", "", false
+		}
+		canonical, ok := canonicalRedditLink(resolved)
+		if !ok {
+			logf(LOG_DEBUG, "canonicalRedditMatch: %s resolved to non-post URL %s", link, resolved)
+			return "", "", false
+		}
+		link = canonical
+	case strings.HasPrefix(link, "reddit.com/gallery/"):
+		// galleries have no subreddit in the URL; look the post up to get its permalink
+		canonical, err := redditGalleryPermalink(strings.TrimPrefix(link, "reddit.com/gallery/"))
+		if err != nil {
+			logf(LOG_DEBUG, "canonicalRedditMatch: could not look up gallery %s: %v", link, err)
+			return "", "", false
+		}
+		link = canonical
+	}
+	return captureUser(redditSubredditRe)(link, domain)
+}
+
+var threadsPostRe = regexp.MustCompile(`threads\.(?:net|com)/@([^/]+)/post/[A-Za-z0-9_-]+`)
+
+func canonicalThreadsLink(link, domain string) (string, string, bool) {
+	if strings.HasPrefix(link[len(domain):], "/t/") {
+		// share links (threads.net/t/<code>) omit the author; try to resolve the canonical post
+		resolved, err := resolveRedirect(link)
+		if err != nil || !strings.Contains(resolved, "/post/") {
+			// fixthreads understands the share form too, so pass it through
+			return link, "Post", true
+		}
+		link = resolved
+	}
+	if mm := threadsPostRe.FindStringSubmatch(link); len(mm) > 1 {
+		return link, "@" + mm[1], true
+	}
+	return link, "Unknown", true
+}
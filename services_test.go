@@ -0,0 +1,114 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceFix(t *testing.T) {
+	tests := []struct {
+		service   string
+		link      string
+		domain    string
+		frontends map[string]string
+		original  string
+		fixed     string
+		user      string
+	}{
+		{service: "Twitter", link: "x.com/someone/status/123", domain: "x.com",
+			original: "x.com/someone/status/123", fixed: "fixupx.com/someone/status/123", user: "someone"},
+		{service: "Twitter", link: "mobile.twitter.com/someone/status/123/photo/2", domain: "mobile.twitter.com",
+			original: "twitter.com/someone/status/123/photo/2", fixed: "fxtwitter.com/someone/status/123/photo/2", user: "someone"},
+		{service: "Twitter", link: "twitter.com/i/web/status/123", domain: "twitter.com",
+			original: "twitter.com/i/status/123", fixed: "fxtwitter.com/i/status/123", user: "Post"},
+		{service: "Twitter", link: "x.com/someone/status/123", domain: "x.com", frontends: map[string]string{"Twitter": "vxTwitter"},
+			original: "x.com/someone/status/123", fixed: "fixvx.com/someone/status/123", user: "someone"},
+		{service: "Instagram", link: "instagram.com/someone/reels/AbC-1", domain: "instagram.com",
+			original: "instagram.com/reel/AbC-1", fixed: "instafix.ldez.top/reel/AbC-1", user: "AbC-1"},
+		{service: "Reddit", link: "reddit.com/r/golang/comments/abc/a_title", domain: "reddit.com",
+			original: "reddit.com/r/golang/comments/abc/a_title", fixed: "vxreddit.ldez.workers.dev/r/golang/comments/abc/a_title", user: "golang"},
+		{service: "Reddit", link: "i.redd.it/abc.png", domain: "i.redd.it",
+			original: "i.redd.it/abc.png", fixed: "i.redd.it/abc.png", user: "Media"},
+		{service: "Bluesky", link: "deer.social/profile/someone.bsky.social/post/3abc", domain: "deer.social",
+			original: "bsky.app/profile/someone.bsky.social/post/3abc", fixed: "fxbsky.app/profile/someone.bsky.social/post/3abc", user: "someone.bsky.social"},
+		{service: "Pixiv", link: "pixiv.net/en/artworks/123#2", domain: "pixiv.net",
+			original: "pixiv.net/en/artworks/123#2", fixed: "phixiv.net/en/artworks/123/2", user: "123"},
+		{service: "Facebook", link: "m.facebook.com/reel/123", domain: "m.facebook.com",
+			original: "facebook.com/reel/123", fixed: "facebed.com/reel/123", user: "Reel"},
+		{service: "Bilibili", link: "m.bilibili.com/video/BV1ab", domain: "m.bilibili.com",
+			original: "bilibili.com/video/BV1ab", fixed: "vxbilibili.com/video/BV1ab", user: "BV1ab"},
+		{service: "Weibo", link: "m.weibo.cn/status/AbC", domain: "m.weibo.cn",
+			original: "weibo.com/detail/AbC", fixed: "fxweibo.com/detail/AbC", user: "Post"},
+		{service: "Newgrounds", link: "newgrounds.com/art/view/artist/a-piece", domain: "newgrounds.com",
+			original: "newgrounds.com/art/view/artist/a-piece", fixed: "fixnewgrounds.com/art/view/artist/a-piece", user: "artist"},
+		{service: "Douyin", link: "douyin.com/video/123", domain: "douyin.com",
+			original: "douyin.com/video/123", fixed: "vxdouyin.com/video/123", user: "Video"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			svc := findService(tt.service)
+			settings := defaultGuildSettings()
+			settings.Frontends = tt.frontends
+			// the fixer counts as up, so the health check stays off the network
+			host, _, _ := strings.Cut(tt.fixed, "/")
+			fixerHealthCache.Lock()
+			fixerHealthCache.m[host] = fixerHealth{up: true, checked: time.Now()}
+			fixerHealthCache.Unlock()
+
+			fixed, ok := svc.fix(tt.link, tt.domain, settings)
+			if !ok {
+				t.Fatalf("fix(%q) skipped the link", tt.link)
+			}
+			if fixed.Original != tt.original || fixed.Fixed != tt.fixed || fixed.User != tt.user {
+				t.Errorf("fix(%q) = %s -> %s by %s, want %s -> %s by %s", tt.link, fixed.Original, fixed.Fixed, fixed.User, tt.original, tt.fixed, tt.user)
+			}
+			if want := svc.label() + " • " + escapeMarkdown(tt.user); fixed.DisplayText != want {
+				t.Errorf("fix(%q) display text = %q, want %q", tt.link, fixed.DisplayText, want)
+			}
+		})
+	}
+}
+
+func TestServiceFixSkips(t *testing.T) {
+	tests := []struct {
+		service string
+		link    string
+		domain  string
+	}{
+		{service: "Bilibili", link: "bilibili.com/video/av123", domain: "bilibili.com"},
+		{service: "Douyin", link: "douyin.com/user/123", domain: "douyin.com"},
+		{service: "Instagram", link: "instagram.com/someone", domain: "instagram.com"},
+	}
+	for _, tt := range tests {
+		if fixed, ok := findService(tt.service).fix(tt.link, tt.domain, defaultGuildSettings()); ok {
+			t.Errorf("fix(%q) = %s, want the link skipped", tt.link, fixed.Fixed)
+		}
+	}
+}
+
+func TestServiceMatching(t *testing.T) {
+	settings := defaultGuildSettings()
+	settings.MastodonInstances = []string{"mastodon.social"}
+	re := regexp.MustCompile(`(?i)https?://(?:www\.)?(` + servicePattern(settings) + `)`)
+	for link, service := range map[string]string{
+		"https://x.com/someone/status/123/photo/2":          "Twitter",
+		"https://www.instagram.com/stories/someone/123":     "Instagram",
+		"https://mastodon.social/@someone/123":              "Mastodon",
+		"https://www.deviantart.com/artist/art/a-piece-123": "DeviantArt",
+	} {
+		mm := re.FindStringSubmatch(link)
+		if len(mm) < 2 {
+			t.Errorf("%s did not match", link)
+			continue
+		}
+		domain, _, _ := strings.Cut(mm[1], "/")
+		if svc := serviceForDomain(domain, settings); svc == nil || svc.Name != service {
+			t.Errorf("%s went to %v, want %s", link, svc, service)
+		}
+	}
+	if svc := serviceForDomain("mastodon.social", defaultGuildSettings()); svc != nil {
+		t.Errorf("mastodon.social without the instance configured went to %s", svc.Name)
+	}
+}
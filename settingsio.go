@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Version of the /settings export format; bump it when fields change meaning
+const SETTINGS_SCHEMA_VERSION = 1
+
+// Largest settings file /settings import reads
+const MAX_SETTINGS_FILE_SIZE = 64 << 10
+
+var settingsFileClient = &http.Client{Timeout: 10 * time.Second}
+
+// settingsFile is the exported configuration. Channel-specific settings (activation, overrides,
+// the log and digest channels) are left out, so a file can be imported on another server.
+type settingsFile struct {
+	Version           int               `json:"version"`
+	Services          []string          `json:"services"`
+	MentionUsers      bool              `json:"mention_users"`
+	DeleteOriginal    bool              `json:"delete_original"`
+	LinkButtons       bool              `json:"link_buttons"`
+	DirectMedia       bool              `json:"direct_media"`
+	RichEmbeds        bool              `json:"rich_embeds"`
+	ReuploadMedia     bool              `json:"reupload_media"`
+	PreserveText      bool              `json:"preserve_text"`
+	ProcessWebhooks   bool              `json:"process_webhooks"`
+	Simulate          bool              `json:"simulate"`
+	Leaderboard       bool              `json:"leaderboard"`
+	DMFallback        bool              `json:"dm_fallback"`
+	TranslateLanguage string            `json:"translate_language"`
+	LinkLimit         int               `json:"link_limit"`
+	OptOutKeyword     string            `json:"optout_keyword"`
+	NSFWMode          string            `json:"nsfw_mode"`
+	Locale            string            `json:"locale"`
+	RepostTemplate    string            `json:"repost_template"`
+	EmbedColor        string            `json:"embed_color"`
+	Attribution       string            `json:"attribution"`
+	MastodonInstances []string          `json:"mastodon_instances"`
+	Frontends         map[string]string `json:"frontends"`
+}
+
+func newSettingsFile(settings *GuildSettings) *settingsFile {
+	return &settingsFile{
+		Version:           SETTINGS_SCHEMA_VERSION,
+		Services:          settings.EnabledServices,
+		MentionUsers:      settings.MentionUsers,
+		DeleteOriginal:    settings.DeleteOriginal,
+		LinkButtons:       settings.LinkButtons,
+		DirectMedia:       settings.DirectMedia,
+		RichEmbeds:        settings.RichEmbeds,
+		ReuploadMedia:     settings.ReuploadMedia,
+		PreserveText:      settings.PreserveText,
+		ProcessWebhooks:   settings.ProcessWebhooks,
+		Simulate:          settings.Simulate,
+		Leaderboard:       settings.Leaderboard,
+		DMFallback:        settings.DMFallback,
+		TranslateLanguage: settings.TranslateLanguage,
+		LinkLimit:         settings.LinkLimit,
+		OptOutKeyword:     settings.OptOutKeyword,
+		NSFWMode:          settings.NSFWMode,
+		Locale:            settings.Locale,
+		RepostTemplate:    settings.RepostTemplate,
+		EmbedColor:        exportColor(settings.EmbedColor),
+		Attribution:       settings.Attribution,
+		MastodonInstances: settings.MastodonInstances,
+		Frontends:         settings.Frontends,
+	}
+}
+
+// apply validates the file and copies it over settings. It returns the first problem found.
+// exportColor writes a guild's embed color, leaving it empty for the defaults.
+func exportColor(color int) string {
+	if color == 0 {
+		return ""
+	}
+	return formatColor(color)
+}
+
+func (f *settingsFile) apply(settings *GuildSettings, guildID string) error {
+	if f.Version < 1 || f.Version > SETTINGS_SCHEMA_VERSION {
+		return fmt.Errorf("unsupported version %d (this FixEmbed reads up to version %d)", f.Version, SETTINGS_SCHEMA_VERSION)
+	}
+	for _, name := range f.Services {
+		if findService(name) == nil {
+			return fmt.Errorf("unknown service %q", name)
+		}
+	}
+	if f.LinkLimit < int(linkLimitMin) || f.LinkLimit > int(linkLimitMax) {
+		return fmt.Errorf("link_limit must be between %d and %d", int(linkLimitMin), int(linkLimitMax))
+	}
+	if f.OptOutKeyword == "" || strings.ContainsAny(f.OptOutKeyword, " \n\t") {
+		return fmt.Errorf("optout_keyword must be a single word")
+	}
+	if _, ok := nsfwModeDescriptions[f.NSFWMode]; !ok {
+		return fmt.Errorf("unknown nsfw_mode %q", f.NSFWMode)
+	}
+	if _, ok := localeNames[discordgo.Locale(f.Locale)]; f.Locale != "" && !ok {
+		return fmt.Errorf("unsupported locale %q", f.Locale)
+	}
+	if f.RepostTemplate != "" && (!strings.Contains(f.RepostTemplate, "{link}") || len(f.RepostTemplate) > MAX_TEMPLATE_LENGTH) {
+		return fmt.Errorf("repost_template must contain {link} and be at most %d characters", MAX_TEMPLATE_LENGTH)
+	}
+	if f.Attribution != "" && f.Attribution != ATTRIBUTION_NONE && f.Attribution != ATTRIBUTION_SILENT {
+		return fmt.Errorf("attribution must be empty, %q or %q", ATTRIBUTION_NONE, ATTRIBUTION_SILENT)
+	}
+	embedColor := 0
+	if f.EmbedColor != "" {
+		var ok bool
+		if embedColor, ok = parseColor(f.EmbedColor); !ok {
+			return fmt.Errorf("embed_color must be a hex color like #5865F2")
+		}
+	}
+	if f.TranslateLanguage != "" && !languageCodeRe.MatchString(f.TranslateLanguage) {
+		return fmt.Errorf("translate_language must be a two-letter language code")
+	}
+	instances := make([]string, 0, len(f.MastodonInstances))
+	for _, input := range f.MastodonInstances {
+		domain, ok := normalizeInstanceDomain(input)
+		if !ok {
+			return fmt.Errorf("%q is not a Mastodon instance domain", input)
+		}
+		instances = append(instances, domain)
+	}
+	for service, frontend := range f.Frontends {
+		svc := findService(service)
+		if svc == nil {
+			return fmt.Errorf("unknown service %q in frontends", service)
+		}
+		known := frontend == svc.Fixer
+		for _, fe := range svc.Frontends {
+			known = known || fe.Name == frontend
+		}
+		if !known {
+			return fmt.Errorf("%s has no frontend called %q", service, frontend)
+		}
+	}
+	if !isPremium(guildID) && (f.ProcessWebhooks || f.LinkLimit > DEFAULT_LINK_LIMIT || f.RepostTemplate != "") {
+		return fmt.Errorf("the file uses premium features (process_webhooks, a link_limit over %d or a repost_template)", DEFAULT_LINK_LIMIT)
+	}
+
+	settings.EnabledServices = f.Services
+	settings.MentionUsers = f.MentionUsers
+	settings.DeleteOriginal = f.DeleteOriginal
+	settings.LinkButtons = f.LinkButtons
+	settings.DirectMedia = f.DirectMedia
+	settings.RichEmbeds = f.RichEmbeds
+	settings.ReuploadMedia = f.ReuploadMedia
+	settings.PreserveText = f.PreserveText
+	settings.ProcessWebhooks = f.ProcessWebhooks
+	settings.Simulate = f.Simulate
+	settings.Leaderboard = f.Leaderboard
+	settings.DMFallback = f.DMFallback
+	settings.TranslateLanguage = f.TranslateLanguage
+	settings.LinkLimit = f.LinkLimit
+	settings.OptOutKeyword = f.OptOutKeyword
+	settings.NSFWMode = f.NSFWMode
+	settings.Locale = f.Locale
+	settings.RepostTemplate = f.RepostTemplate
+	settings.EmbedColor = embedColor
+	settings.Attribution = f.Attribution
+	settings.MastodonInstances = instances
+	settings.Frontends = f.Frontends
+	if settings.Frontends == nil {
+		settings.Frontends = make(map[string]string)
+	}
+	return nil
+}
+
+// SaveGuildSettings stores every column a settings file covers in one upsert.
+func (st *sqliteStore) SaveGuildSettings(guildID int64, settings *GuildSettings) error {
+	_, err := st.db.Exec(`INSERT INTO guild_settings (guild_id, enabled_services, mention_users, delete_original, link_buttons, direct_media, rich_embeds, reupload_media, preserve_text, process_webhooks, simulate, leaderboard, dm_fallback, translate_language, link_limit, optout_keyword, nsfw_mode, locale, repost_template, embed_color, attribution, mastodon_instances, frontends)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled_services = excluded.enabled_services, mention_users = excluded.mention_users, delete_original = excluded.delete_original,
+			link_buttons = excluded.link_buttons, direct_media = excluded.direct_media, rich_embeds = excluded.rich_embeds, reupload_media = excluded.reupload_media,
+			preserve_text = excluded.preserve_text, process_webhooks = excluded.process_webhooks, simulate = excluded.simulate, leaderboard = excluded.leaderboard, dm_fallback = excluded.dm_fallback,
+			translate_language = excluded.translate_language, link_limit = excluded.link_limit, optout_keyword = excluded.optout_keyword, nsfw_mode = excluded.nsfw_mode, locale = excluded.locale, repost_template = excluded.repost_template, embed_color = excluded.embed_color, attribution = excluded.attribution,
+			mastodon_instances = excluded.mastodon_instances, frontends = excluded.frontends`,
+		guildID, formatStoredList(settings.EnabledServices), settings.MentionUsers, settings.DeleteOriginal,
+		settings.LinkButtons, settings.DirectMedia, settings.RichEmbeds, settings.ReuploadMedia,
+		settings.PreserveText, settings.ProcessWebhooks, settings.Simulate, settings.Leaderboard, settings.DMFallback,
+		settings.TranslateLanguage, settings.LinkLimit, settings.OptOutKeyword, settings.NSFWMode, settings.Locale, settings.RepostTemplate, settings.EmbedColor, settings.Attribution,
+		formatStoredList(settings.MastodonInstances), formatFrontends(settings.Frontends))
+	return err
+}
+
+func handleSettingsExport(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	data, err := json.MarshalIndent(newSettingsFile(getGuildSettings(gidInt)), "", "  ")
+	if err != nil {
+		logf(LOG_WARN, "Error exporting settings for guild %s: %v", i.GuildID, err)
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "📦 Here are this server's settings. Use `/settings import` to load them here or on another server.",
+			Files: []*discordgo.File{{
+				Name:        fmt.Sprintf("fixembed-settings-%s.json", i.GuildID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(data),
+			}},
+			Flags: 1 << 6, // ephemeral
+		},
+	})
+}
+
+func handleSettingsImport(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var attachment *discordgo.MessageAttachment
+	for _, opt := range data.Options[0].Options {
+		if opt.Name == "file" && data.Resolved != nil {
+			attachment = data.Resolved.Attachments[opt.Value.(string)]
+		}
+	}
+	// downloading the file can take a moment
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: 1 << 6}, // ephemeral
+	})
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Import Settings",
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	defer func() {
+		createFooter(embed, s)
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds: &[]*discordgo.MessageEmbed{embed},
+		})
+	}()
+	fail := func(format string, args ...interface{}) {
+		embed.Description = "❌ " + fmt.Sprintf(format, args...)
+		embed.Color = 0xff0000
+	}
+
+	if attachment == nil {
+		fail("Attach a settings file made with `/settings export`.")
+		return
+	}
+	if attachment.Size > MAX_SETTINGS_FILE_SIZE {
+		fail("That file is too large to be a settings file.")
+		return
+	}
+	resp, err := settingsFileClient.Get(attachment.URL)
+	if err != nil {
+		fail("Could not download the file.")
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fail("Could not download the file.")
+		return
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	// start from the defaults, so fields missing from the file are reset rather than kept
+	file := newSettingsFile(defaultGuildSettings())
+	file.Version = 0
+	if err := json.NewDecoder(io.LimitReader(resp.Body, MAX_SETTINGS_FILE_SIZE)).Decode(file); err != nil {
+		fail("That isn't a valid settings file: %v", err)
+		return
+	}
+	updated := *getGuildSettings(gidInt)
+	if err := file.apply(&updated, i.GuildID); err != nil {
+		fail("That settings file can't be imported: %v.", err)
+		return
+	}
+	if err := st.SaveGuildSettings(gidInt, &updated); err != nil {
+		logf(LOG_WARN, "Error importing settings for guild %s: %v", i.GuildID, err)
+		fail("Could not save the settings.")
+		return
+	}
+	cacheGuildSettings(gidInt, &updated)
+
+	embed.Description = fmt.Sprintf("✅ Imported settings: %d service(s) enabled.", len(updated.EnabledServices))
+	if slices.ContainsFunc(updated.EnabledServices, func(name string) bool {
+		svc := findService(name)
+		return svc != nil && svc.Available != nil && !svc.Available(&updated)
+	}) {
+		embed.Description += "\nSome services won't be used until they're set up on this server."
+	}
+}
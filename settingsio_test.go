@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSettingsFileApply(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		change func(*settingsFile)
+		err    string
+	}{
+		{"valid", func(*settingsFile) {}, ""},
+		{"future version", func(f *settingsFile) { f.Version = SETTINGS_SCHEMA_VERSION + 1 }, "unsupported version"},
+		{"unknown service", func(f *settingsFile) { f.Services = []string{"Twitter", "MySpace"} }, `unknown service "MySpace"`},
+		{"keyword with spaces", func(f *settingsFile) { f.OptOutKeyword = "no fix" }, "single word"},
+		{"unknown frontend", func(f *settingsFile) { f.Frontends = map[string]string{"Twitter": "nope.example"} }, "no frontend"},
+	} {
+		f := newSettingsFile(defaultGuildSettings())
+		tt.change(f)
+		err := f.apply(defaultGuildSettings(), "1")
+		if (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: apply = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestSettingsExportImport(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	exported := defaultGuildSettings()
+	exported.EnabledServices = []string{"Twitter", "Reddit"}
+	exported.LinkButtons = true
+	exported.NSFWMode = NSFW_MODE_DIRECT
+	data, err := json.Marshal(newSettingsFile(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := settingsFileClient.Transport
+	t.Cleanup(func() { settingsFileClient.Transport = transport })
+	settingsFileClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(data)))}, nil
+	})
+
+	file := option("file", "300")
+	file.Type = discordgo.ApplicationCommandOptionAttachment
+	i := slashCommand("settings", option("import", nil, file))
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "settings",
+		Options: i.ApplicationCommandData().Options,
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{Attachments: map[string]*discordgo.MessageAttachment{
+			"300": {ID: "300", URL: "https://cdn.discordapp.com/settings.json", Size: len(data)},
+		}},
+	}
+	handleSettingsImport(db, s, i)
+
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	gs := getGuildSettings(1)
+	if !slices.Equal(gs.EnabledServices, exported.EnabledServices) || !gs.LinkButtons || gs.NSFWMode != NSFW_MODE_DIRECT {
+		t.Errorf("imported settings = %+v", gs)
+	}
+
+	handleSettingsExport(db, s, slashCommand("settings", option("export", nil)))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, `"nsfw_mode": "direct"`) {
+		t.Errorf("export = %s", body)
+	}
+}
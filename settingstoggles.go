@@ -0,0 +1,200 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// settingsEntry is one choice of the /settings select menu. Entries with a Field are on/off
+// toggles, which share one panel and one toggle_* handler; the others open their own panel.
+type settingsEntry struct {
+	Label       string
+	Description string
+	Emoji       string // shown whatever the state, for entries without On/Off
+	On, Off     string // emoji shown while the setting is on or off
+
+	// toggles only
+	Field    func(*GuildSettings) *bool
+	Column   string // guild_settings column; empty for the settings stored by updateDelivery
+	CustomID string
+	Title    string
+	Help     string // panel description
+	Toggled  string // panel description after a toggle
+}
+
+// the /settings select menu, in the order it is shown
+var settingsEntries = []settingsEntry{
+	{Label: "FixEmbed", Description: "Activate or deactivate the bot in all channels", On: "🟢", Off: "🔴"},
+	{Label: "Mention Users", Description: "Toggle mentioning users in messages", On: "🔔", Off: "🔕",
+		Field:    func(gs *GuildSettings) *bool { return &gs.MentionUsers },
+		CustomID: "toggle_mention", Title: "Mention Users Settings",
+		Help: "Toggle mentioning users in messages.", Toggled: "Toggled mention users."},
+	{Label: "Delivery Method", Description: "Toggle original message deletion", On: "📬", Off: "📪",
+		Field:    func(gs *GuildSettings) *bool { return &gs.DeleteOriginal },
+		CustomID: "toggle_delete", Title: "Delivery Method Settings",
+		Help: "Toggle original message deletion.", Toggled: "Toggled original message deletion."},
+	{Label: "Link Style", Description: "Toggle link buttons instead of masked links", On: "🔘", Off: "🔗",
+		Field: func(gs *GuildSettings) *bool { return &gs.LinkButtons }, Column: "link_buttons",
+		CustomID: "toggle_link_buttons", Title: "Link Style Settings",
+		Help: "Toggle posting link buttons instead of masked markdown links.", Toggled: "Toggled link buttons."},
+	{Label: "Direct Media", Description: "Toggle posting only the raw media without the text card", On: "🖼️", Off: "🃏",
+		Field: func(gs *GuildSettings) *bool { return &gs.DirectMedia }, Column: "direct_media",
+		CustomID: "toggle_direct_media", Title: "Direct Media Settings",
+		Help: "Toggle posting only the raw video/image without the text card.", Toggled: "Toggled direct media."},
+	{Label: "Rich Embeds", Description: "Toggle building embeds from the fixers' APIs", On: "🪄", Off: "📄",
+		Field: func(gs *GuildSettings) *bool { return &gs.RichEmbeds }, Column: "rich_embeds",
+		CustomID: "toggle_rich_embeds", Title: "Rich Embed Settings",
+		Help:    "Toggle building embeds from the fixers' APIs (Twitter and Pixiv) instead of relying on their link previews.",
+		Toggled: "Toggled rich embeds."},
+	{Label: "Re-upload Media", Description: "Toggle attaching media directly to the repost", On: "📎", Off: "🌐",
+		Field: func(gs *GuildSettings) *bool { return &gs.ReuploadMedia }, Column: "reupload_media",
+		CustomID: "toggle_reupload_media", Title: "Re-upload Media Settings",
+		Help:    "Toggle attaching Twitter and Pixiv media to the repost (too-large media is still linked).",
+		Toggled: "Toggled media re-upload."},
+	{Label: "Keep Message Text", Description: "Toggle keeping the rest of the message in the repost", On: "💬", Off: "✂️",
+		Field: func(gs *GuildSettings) *bool { return &gs.PreserveText }, Column: "preserve_text",
+		CustomID: "toggle_preserve_text", Title: "Keep Message Text Settings",
+		Help:    "Toggle including the original message text, with the link swapped for the fixed one, in the repost.",
+		Toggled: "Toggled keeping message text."},
+	{Label: "Webhooks", Description: "Toggle fixing links in webhook messages (bridges, feeds)", On: "🪝", Off: "🚫",
+		Field: func(gs *GuildSettings) *bool { return &gs.ProcessWebhooks }, Column: "process_webhooks",
+		CustomID: "toggle_process_webhooks", Title: "Webhook Settings",
+		Help:    "Toggle fixing links posted by webhooks such as bridges and RSS feeds. PluralKit messages are always handled.",
+		Toggled: "Toggled webhook processing."},
+	{Label: "Channels", Description: "Activate or deactivate individual channels", Emoji: "📺"},
+	{Label: "Simulate Mode", Description: "Only report what would be fixed, without posting or deleting", On: "🧪", Off: "🚀",
+		Field: func(gs *GuildSettings) *bool { return &gs.Simulate }, Column: "simulate",
+		CustomID: "toggle_simulate", Title: "Simulate Mode",
+		Help:    "Toggle simulate mode. While it is on, FixEmbed only reports what it would have done (in the log channel, if one is set) and leaves messages alone.",
+		Toggled: "Toggled simulate mode."},
+	{Label: "Leaderboard", Description: "Toggle the /leaderboard of most-fixed members", On: "🏆", Off: "🚫",
+		Field: func(gs *GuildSettings) *bool { return &gs.Leaderboard }, Column: "leaderboard",
+		CustomID: "toggle_leaderboard", Title: "Leaderboard",
+		Help: "Toggle /leaderboard, which ranks the members whose links get fixed most often.", Toggled: "Toggled the leaderboard."},
+	{Label: "Repost Template", Description: "Change how reposts are laid out", Emoji: "📝"},
+	{Label: "Embed Color", Description: "Match FixEmbed's embeds to your server's colors", Emoji: "🎨"},
+	{Label: "DM Fallback", Description: "Toggle DMing the fix when FixEmbed cannot post in a channel", On: "📨", Off: "🚫",
+		Field: func(gs *GuildSettings) *bool { return &gs.DMFallback }, Column: "dm_fallback",
+		CustomID: "toggle_dm_fallback", Title: "DM Fallback",
+		Help:    "Toggle sending the fixed link to its poster by DM when FixEmbed is not allowed to post in the channel.",
+		Toggled: "Toggled the DM fallback."},
+	{Label: "Service Settings", Description: "Configure which services are activated", Emoji: "⚙️"},
+	{Label: "Fixer Frontends", Description: "Choose which fixer each service uses", Emoji: "🔀"},
+	{Label: "Debug", Description: "Show current debug information", Emoji: "🐞"},
+}
+
+// settingsSelectMenu builds the /settings select menu; activated is the FixEmbed entry's state,
+// which comes from the guild's channels rather than its settings.
+func settingsSelectMenu(locale discordgo.Locale, gs *GuildSettings, activated bool) *discordgo.SelectMenu {
+	opts := make([]discordgo.SelectMenuOption, 0, len(settingsEntries))
+	for _, e := range settingsEntries {
+		emoji := e.Emoji
+		if e.On != "" {
+			on := activated
+			if e.Field != nil {
+				on = *e.Field(gs)
+			}
+			emoji = e.Off
+			if on {
+				emoji = e.On
+			}
+		}
+		description := e.Description
+		if text, ok := catalogFor(locale)["menu."+e.Label]; ok {
+			description = text
+		}
+		opts = append(opts, discordgo.SelectMenuOption{Label: e.Label, Value: e.Label, Description: description, Emoji: &discordgo.ComponentEmoji{Name: emoji}})
+	}
+	minVal := 1
+	return &discordgo.SelectMenu{
+		CustomID:    "settings_select",
+		Placeholder: T(locale, "settings.placeholder"),
+		MinValues:   &minVal,
+		MaxValues:   1,
+		Options:     opts,
+	}
+}
+
+// settingsToggle finds the toggle shown as label in the select menu, or the one behind a toggle_* button.
+func settingsToggle(label, customID string) *settingsEntry {
+	for i, e := range settingsEntries {
+		if e.Field != nil && (e.Label == label || e.CustomID == customID) {
+			return &settingsEntries[i]
+		}
+	}
+	return nil
+}
+
+// toggleButton is the Activated/Deactivated button of a toggle panel.
+func toggleButton(customID string, on bool) discordgo.MessageComponent {
+	btn := &discordgo.Button{CustomID: customID, Label: "Activated", Style: discordgo.SuccessButton}
+	if !on {
+		btn.Label = "Deactivated"
+		btn.Style = discordgo.DangerButton
+	}
+	return &discordgo.ActionsRow{Components: []discordgo.MessageComponent{btn}}
+}
+
+// respondTogglePanel replaces the settings panel with a toggle's button.
+func respondTogglePanel(s *discordgo.Session, i *discordgo.InteractionCreate, t *settingsEntry, gs *GuildSettings, description string) {
+	embed := &discordgo.MessageEmbed{Title: t.Title, Description: description, Color: accentColor(i.GuildID, 0x00ff00)}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{toggleButton(t.CustomID, *t.Field(gs))},
+		},
+	})
+}
+
+// handleSettingsToggle flips a toggle for the guild, stores it and redraws its panel.
+func handleSettingsToggle(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, t *settingsEntry) {
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	if gidInt == 0 {
+		embed := &discordgo.MessageEmbed{Title: t.Title, Description: t.Toggled, Color: accentColor(i.GuildID, 0x00ff00)}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+			},
+		})
+		return
+	}
+	updated := *getGuildSettings(gidInt)
+	field := t.Field(&updated)
+	*field = !*field
+	var err error
+	if t.Column == "" {
+		err = updateDelivery(st, gidInt, func(gs *GuildSettings) { *t.Field(gs) = *field })
+	} else if err = st.UpdateGuildColumn(gidInt, t.Column, *field); err == nil {
+		cacheGuildSettings(gidInt, &updated)
+	}
+	if err != nil {
+		logf(LOG_WARN, "Error toggling %s for guild %s: %v", t.Label, i.GuildID, err)
+	}
+	respondTogglePanel(s, i, t, getGuildSettings(gidInt), t.Toggled)
+}
+
+// channelsActivated reports whether FixEmbed is activated in every channel of a guild it tracks.
+func channelsActivated(g *discordgo.Guild) bool {
+	channelStates.RLock()
+	defer channelStates.RUnlock()
+	for _, ch := range g.Channels {
+		if isTrackedChannel(ch) {
+			cidInt, _ := discordIDStringToInt64(ch.ID)
+			if v, ok := channelStates.m[cidInt]; !ok || !v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// guildActivated is channelsActivated for a guild in the session state; unknown guilds count as activated.
+func guildActivated(s *discordgo.Session, guildID string) bool {
+	if guildID == "" || s.State == nil {
+		return true
+	}
+	g, err := s.State.Guild(guildID)
+	if err != nil {
+		return true
+	}
+	return channelsActivated(g)
+}
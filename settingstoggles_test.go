@@ -0,0 +1,140 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// componentClick is a click on the settings component customID in guild 1 by a server manager.
+func componentClick(customID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "900",
+		Token:   "token",
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "5"}, Permissions: discordgo.PermissionManageGuild},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: customID},
+	}}
+}
+
+func TestHandleSettingsToggle(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+
+	tests := []struct {
+		customID string
+		want     func(*GuildSettings) bool
+		button   string
+	}{
+		{"toggle_link_buttons", func(gs *GuildSettings) bool { return gs.LinkButtons }, "Activated"},
+		{"toggle_mention", func(gs *GuildSettings) bool { return !gs.MentionUsers && gs.LinkButtons }, "Deactivated"},
+		{"toggle_link_buttons", func(gs *GuildSettings) bool { return !gs.LinkButtons && !gs.MentionUsers }, "Deactivated"},
+		{"toggle_delete", func(gs *GuildSettings) bool { return !gs.DeleteOriginal && !gs.MentionUsers }, "Deactivated"},
+		{"toggle_direct_media", func(gs *GuildSettings) bool { return gs.DirectMedia && !gs.DeleteOriginal }, "Activated"},
+		{"toggle_rich_embeds", func(gs *GuildSettings) bool { return gs.RichEmbeds && gs.DirectMedia }, "Activated"},
+		{"toggle_reupload_media", func(gs *GuildSettings) bool { return gs.ReuploadMedia && gs.RichEmbeds }, "Activated"},
+		{"toggle_preserve_text", func(gs *GuildSettings) bool { return gs.PreserveText && gs.ReuploadMedia }, "Activated"},
+		{"toggle_process_webhooks", func(gs *GuildSettings) bool { return gs.ProcessWebhooks && gs.PreserveText }, "Activated"},
+		{"toggle_simulate", func(gs *GuildSettings) bool { return gs.Simulate && gs.ProcessWebhooks }, "Activated"},
+		{"toggle_leaderboard", func(gs *GuildSettings) bool { return gs.Leaderboard && gs.Simulate }, "Activated"},
+		{"toggle_dm_fallback", func(gs *GuildSettings) bool { return !gs.DMFallback && gs.Leaderboard }, "Deactivated"},
+	}
+	for _, tt := range tests {
+		toggle := settingsToggle("", tt.customID)
+		if toggle == nil {
+			t.Fatalf("no toggle behind %s", tt.customID)
+		}
+		handleSettingsToggle(db, s, componentClick(tt.customID), toggle)
+
+		stored, err := db.GuildSettings(1)
+		if err != nil || stored == nil {
+			t.Fatalf("after %s: stored settings %v, %v", tt.customID, stored, err)
+		}
+		if !tt.want(stored) || !tt.want(getGuildSettings(1)) {
+			t.Errorf("after %s: stored %+v, cached %+v", tt.customID, stored, getGuildSettings(1))
+		}
+		calls := fake.calls()
+		body := fake.body(calls[len(calls)-1])
+		if !strings.Contains(body, tt.customID) || !strings.Contains(body, `"label":"`+tt.button+`"`) {
+			t.Errorf("after %s: panel %s, want a %s button", tt.customID, body, tt.button)
+		}
+	}
+}
+
+func TestSettingsSelectMenu(t *testing.T) {
+	gs := defaultGuildSettings()
+	gs.LinkButtons = true
+	menu := settingsSelectMenu(FALLBACK_LOCALE, gs, false)
+	emoji := make(map[string]string)
+	var labels []string
+	for _, opt := range menu.Options {
+		emoji[opt.Value] = opt.Emoji.Name
+		labels = append(labels, opt.Label)
+	}
+	for label, want := range map[string]string{"FixEmbed": "🔴", "Mention Users": "🔔", "Link Style": "🔘", "Debug": "🐞"} {
+		if emoji[label] != want {
+			t.Errorf("%s shows %q, want %q", label, emoji[label], want)
+		}
+	}
+	// every toggle in the menu can be found again from its panel's button
+	for _, label := range labels {
+		if toggle := settingsToggle(label, ""); toggle != nil && settingsToggle("", toggle.CustomID) != toggle {
+			t.Errorf("%s's button %s leads to another toggle", label, toggle.CustomID)
+		}
+	}
+	if !slices.Contains(labels, "Service Settings") {
+		t.Errorf("menu %v lost Service Settings", labels)
+	}
+}
+
+func TestScanGuildSettings(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.db.Exec(`INSERT INTO guild_settings (guild_id, enabled_services, mention_users) VALUES (1, '[''Twitter'', "Pixiv"]', 0), (2, '', NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	gs, err := db.GuildSettings(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(gs.EnabledServices, []string{"Twitter", "Pixiv"}) || gs.MentionUsers || !gs.DeleteOriginal || gs.LinkButtons {
+		t.Errorf("guild 1 = %+v", gs)
+	}
+	gs, err = db.GuildSettings(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(gs.EnabledServices, defaultServices()) || !gs.MentionUsers {
+		t.Errorf("guild 2 = %+v, want the defaults", gs)
+	}
+	if gs, err := db.GuildSettings(3); gs != nil || err != nil {
+		t.Errorf("unknown guild = %+v, %v; want nil, nil", gs, err)
+	}
+}
+
+func TestChannelsActivated(t *testing.T) {
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	g := &discordgo.Guild{ID: "1", Channels: []*discordgo.Channel{
+		{ID: "20", Type: discordgo.ChannelTypeGuildText},
+		{ID: "21", Type: discordgo.ChannelTypeGuildNews},
+		{ID: "22", Type: discordgo.ChannelTypeGuildVoice},
+	}}
+	channelStates.Lock()
+	channelStates.m = map[int64]bool{20: true}
+	channelStates.Unlock()
+	if channelsActivated(g) {
+		t.Error("activated with the announcement channel off")
+	}
+	channelStates.Lock()
+	channelStates.m[21] = true
+	channelStates.Unlock()
+	if !channelsActivated(g) {
+		t.Error("not activated with every text and announcement channel on")
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Options a select menu can hold
+const MAX_SELECT_OPTIONS = 25
+
+// setupSession holds the /setup answers given so far; nothing is written until the last step.
+type setupSession struct {
+	services       []string
+	deleteOriginal bool
+	mentionUsers   bool
+}
+
+// guild ID + user ID -> the wizard that user is going through
+var setupSessions = struct {
+	sync.Mutex
+	m map[string]*setupSession
+}{m: make(map[string]*setupSession)}
+
+func setupKey(i *discordgo.InteractionCreate) string {
+	return i.GuildID + ":" + interactionUserID(i)
+}
+
+// setupStep builds one wizard page; each row is a component row.
+func setupStep(step int, title, description string, rows ...[]discordgo.MessageComponent) *discordgo.InteractionResponseData {
+	components := make([]discordgo.MessageComponent, 0, len(rows))
+	for _, row := range rows {
+		components = append(components, discordgo.ActionsRow{Components: row})
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Setup (%d/4): %s", step, title),
+		Description: description,
+		Color:       0x78b159,
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+		Flags:      1 << 6, // ephemeral
+	}
+}
+
+func setupChoice(customID, label string, style discordgo.ButtonStyle) discordgo.Button {
+	return discordgo.Button{CustomID: customID, Label: label, Style: style}
+}
+
+func handleSetupCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	settings := getGuildSettings(gidInt)
+	setupSessions.Lock()
+	setupSessions.m[setupKey(i)] = &setupSession{
+		services:       settings.EnabledServices,
+		deleteOriginal: settings.DeleteOriginal,
+		mentionUsers:   settings.MentionUsers,
+	}
+	setupSessions.Unlock()
+
+	available := availableServices(settings)
+	if len(available) > MAX_SELECT_OPTIONS {
+		available = available[:MAX_SELECT_OPTIONS]
+	}
+	options := make([]discordgo.SelectMenuOption, 0, len(available))
+	for _, name := range available {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   findService(name).label(),
+			Value:   name,
+			Default: slices.Contains(settings.EnabledServices, name),
+		})
+	}
+	minValues := 0
+	menu := discordgo.SelectMenu{
+		CustomID:    "setup_services",
+		Placeholder: "Pick the services to fix",
+		MinValues:   &minValues,
+		MaxValues:   len(options),
+		Options:     options,
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: setupStep(1, "Services", "Which sites should I fix links for? Links from the others are left alone.",
+			[]discordgo.MessageComponent{menu}),
+	})
+}
+
+// handleSetupComponent moves the /setup wizard on by one step.
+func handleSetupComponent(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, custom string) {
+	setupSessions.Lock()
+	session := setupSessions.m[setupKey(i)]
+	setupSessions.Unlock()
+	if session == nil {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    "This setup has expired. Run `/setup` again.",
+				Embeds:     []*discordgo.MessageEmbed{},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		return
+	}
+
+	var next *discordgo.InteractionResponseData
+	switch custom {
+	case "setup_services":
+		session.services = i.MessageComponentData().Values
+		next = setupStep(2, "Delivery", "Should I delete the original message, or keep it and just hide its broken preview?",
+			[]discordgo.MessageComponent{
+				setupChoice("setup_delete", "Delete the original", discordgo.PrimaryButton),
+				setupChoice("setup_suppress", "Keep the original", discordgo.SecondaryButton),
+			})
+	case "setup_delete", "setup_suppress":
+		session.deleteOriginal = custom == "setup_delete"
+		next = setupStep(3, "Mentions", "Should reposts mention the person who posted the link?",
+			[]discordgo.MessageComponent{
+				setupChoice("setup_mention", "Mention them", discordgo.PrimaryButton),
+				setupChoice("setup_no_mention", "Just show their name", discordgo.SecondaryButton),
+			})
+	case "setup_mention", "setup_no_mention":
+		session.mentionUsers = custom == "setup_mention"
+		// a button and a select can't share a row
+		next = setupStep(4, "Channels", "Should I work in every channel, or only the ones you pick?",
+			[]discordgo.MessageComponent{setupChoice("setup_all_channels", "Every channel", discordgo.PrimaryButton)},
+			[]discordgo.MessageComponent{discordgo.SelectMenu{
+				MenuType:     discordgo.ChannelSelectMenu,
+				CustomID:     "setup_channels",
+				Placeholder:  "Only these channels",
+				MaxValues:    MAX_SELECT_OPTIONS,
+				ChannelTypes: trackedChannelTypes,
+			}})
+	case "setup_all_channels", "setup_channels":
+		setupSessions.Lock()
+		delete(setupSessions.m, setupKey(i))
+		setupSessions.Unlock()
+		next = finishSetup(st, s, i, session, i.MessageComponentData().Values)
+	default:
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: next,
+	})
+}
+
+// finishSetup writes the wizard's answers; channels, when given, are the only ones left active.
+func finishSetup(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, session *setupSession, channels []string) *discordgo.InteractionResponseData {
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{Title: "Setup complete", Color: 0x78b159}
+	done := &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{},
+	}
+
+	if err := st.UpdateSetting(gidInt, session.services, session.mentionUsers, session.deleteOriginal); err != nil {
+		logf(LOG_WARN, "Error saving setup for guild %s: %v", i.GuildID, err)
+		embed.Title = "Setup failed"
+		embed.Description = "❌ Could not save the settings. Please try again."
+		embed.Color = 0xff0000
+		return done
+	}
+	updated := *getGuildSettings(gidInt)
+	updated.EnabledServices = session.services
+	updated.MentionUsers = session.mentionUsers
+	updated.DeleteOriginal = session.deleteOriginal
+	cacheGuildSettings(gidInt, &updated)
+
+	where := "every channel"
+	if g, err := s.State.Guild(i.GuildID); err == nil {
+		if len(channels) == 0 {
+			_, err = setGuildState(st, g, true, nil)
+		} else {
+			picked := make(map[string]bool, len(channels))
+			for _, id := range channels {
+				picked[id] = true
+			}
+			// everything else goes off, then the picks come on
+			_, err = setGuildState(st, g, false, picked)
+			mentions := make([]string, 0, len(channels))
+			for _, id := range channels {
+				setChannelState(st, s, i.GuildID, id, true)
+				mentions = append(mentions, "<#"+id+">")
+			}
+			where = strings.Join(mentions, ", ")
+		}
+		if err != nil {
+			logf(LOG_WARN, "Error saving setup channels for guild %s: %v", i.GuildID, err)
+		}
+	}
+
+	services := "None"
+	if len(session.services) > 0 {
+		services = strings.Join(session.services, ", ")
+	}
+	delivery := "Keep the original and hide its preview"
+	if session.deleteOriginal {
+		delivery = "Delete the original"
+	}
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Services", Value: services},
+		{Name: "Delivery", Value: delivery},
+		{Name: "Mentions", Value: fmt.Sprintf("%t", session.mentionUsers)},
+		{Name: "Channels", Value: where},
+	}
+	embed.Description = "You can change any of this later with `/settings panel`."
+	return done
+}
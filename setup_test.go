@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSetupWizard(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "30", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "31", GuildID: "1", Type: discordgo.ChannelTypeGuildText})
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	click := func(customID string, values ...string) {
+		i := componentClick(customID)
+		i.Data = discordgo.MessageComponentInteractionData{CustomID: customID, Values: values}
+		onInteractionCreate(db, s, i)
+	}
+
+	// clicking an old wizard's buttons changes nothing
+	click("setup_delete")
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "expired") {
+		t.Fatalf("an expired wizard got %s", body)
+	}
+
+	handleSetupCommand(db, s, slashCommand("setup"))
+	click("setup_services", "Twitter", "Reddit")
+	click("setup_suppress")
+	click("setup_no_mention")
+	if gs, _ := db.GuildSettings(1); gs != nil {
+		t.Fatalf("settings were saved before the last step: %+v", gs)
+	}
+	click("setup_channels", "31")
+
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	gs := getGuildSettings(1)
+	if !slices.Equal(gs.EnabledServices, []string{"Twitter", "Reddit"}) || gs.DeleteOriginal || gs.MentionUsers {
+		t.Errorf("saved settings = %+v", gs)
+	}
+	for id, want := range map[string]bool{"30": false, "31": true} {
+		if enabled, _ := channelState(s, id); enabled != want {
+			t.Errorf("channel %s active = %t, want %t", id, enabled, want)
+		}
+	}
+}
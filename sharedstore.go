@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// instance is one running FixEmbed process, as recorded in the instances table.
+type instance struct {
+	ID         string
+	ShardID    int
+	ShardCount int
+	Primary    bool
+	SeenAt     time.Time
+}
+
+func (st *sqliteStore) Heartbeat(inst instance) error {
+	_, err := st.db.Exec(`INSERT INTO instances (id, shard_id, shard_count, is_primary, seen_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET shard_id = excluded.shard_id, shard_count = excluded.shard_count, is_primary = excluded.is_primary, seen_at = excluded.seen_at`,
+		inst.ID, inst.ShardID, inst.ShardCount, boolToInt(inst.Primary), inst.SeenAt.Unix())
+	return err
+}
+
+func (st *sqliteStore) LiveInstances(since time.Time) ([]instance, error) {
+	rows, err := st.db.Query("SELECT id, shard_id, shard_count, is_primary, seen_at FROM instances WHERE seen_at >= ? ORDER BY id", since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var live []instance
+	for rows.Next() {
+		var inst instance
+		var seenAt int64
+		if err := rows.Scan(&inst.ID, &inst.ShardID, &inst.ShardCount, &inst.Primary, &seenAt); err != nil {
+			return nil, err
+		}
+		inst.SeenAt = time.Unix(seenAt, 0)
+		live = append(live, inst)
+	}
+	return live, rows.Err()
+}
+
+// heartbeat records this instance in the store, so the others know it's running.
+func heartbeat(st Store) {
+	err := st.Heartbeat(instance{ID: instanceID, ShardID: cfg.ShardID, ShardCount: cfg.ShardCount, Primary: cfg.Primary, SeenAt: time.Now()})
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not record this instance in the store: %v", err)
+	}
+}
+
+// primaryProblem describes what's wrong with the instances running on the store, if anything:
+// exactly one of them should be primary, or the scheduled jobs run never or more than once.
+func primaryProblem(st Store, now time.Time) string {
+	// an instance that missed a few syncs has stopped
+	live, err := st.LiveInstances(now.Add(-3 * cfg.StoreSyncInterval))
+	if err != nil {
+		logf(LOG_WARN, "Warning: could not list the running instances: %v", err)
+		return ""
+	}
+	primaries := 0
+	for _, inst := range live {
+		if inst.Primary {
+			primaries++
+		}
+	}
+	switch {
+	case primaries == 0 && (cfg.ShardCount > 1 || len(live) > 1):
+		return fmt.Sprintf("Warning: none of the %d running instances is primary, so digests, retention and top.gg stats are off; set PRIMARY_INSTANCE=true in exactly one of them", len(live))
+	case primaries > 1:
+		return fmt.Sprintf("Warning: %d running instances are primary, so the scheduled jobs run more than once; set PRIMARY_INSTANCE=true in exactly one of them", primaries)
+	}
+	return ""
+}
+
+// reloadCaches reads everything FixEmbed keeps in memory back from the database.
+func reloadCaches(st Store, s *discordgo.Session) {
+	if err := loadChannelStates(st, s); err != nil {
+		logf(LOG_WARN, "Error loading channel states: %v", err)
+	}
+	if err := loadSettings(st); err != nil {
+		logf(LOG_WARN, "Error loading settings: %v", err)
+	}
+	if err := loadOptedOutUsers(st); err != nil {
+		logf(LOG_WARN, "Error loading opted-out users: %v", err)
+	}
+	if err := loadUserPreferences(st); err != nil {
+		logf(LOG_WARN, "Error loading user preferences: %v", err)
+	}
+	if err := loadIgnoredUsers(st); err != nil {
+		logf(LOG_WARN, "Error loading ignored users: %v", err)
+	}
+	if err := loadChannelOverrides(st); err != nil {
+		logf(LOG_WARN, "Error loading channel overrides: %v", err)
+	}
+}
+
+// loadCaches fills the caches on startup: the Redis-backed ones from Redis, falling back to
+// the database for any Redis doesn't have, and the rest (with no Redis, all) from the database.
+func loadCaches(st Store, s *discordgo.Session) {
+	if redisClient == nil {
+		reloadCaches(st, s)
+		return
+	}
+	loadRedisState(st, s)
+	if err := loadUserPreferences(st); err != nil {
+		logf(LOG_WARN, "Error loading user preferences: %v", err)
+	}
+}
+
+// startStoreSync keeps the caches in step with other instances sharing the database: every
+// sync interval it checks store_version and reloads the caches when something changed. It also
+// records this instance, and warns when the running instances don't have exactly one primary.
+func startStoreSync(st Store, s *discordgo.Session, stop <-chan struct{}) {
+	if cfg.StoreSyncInterval <= 0 {
+		return
+	}
+	last, err := st.Version()
+	if err != nil {
+		logf(LOG_WARN, "Warning: store sync is off, could not read the store version: %v", err)
+		return
+	}
+	heartbeat(st)
+	ticker := time.NewTicker(cfg.StoreSyncInterval)
+	defer ticker.Stop()
+	warned := ""
+	for {
+		select {
+		case now := <-ticker.C:
+			heartbeat(st)
+			// once per problem rather than on every tick
+			if problem := primaryProblem(st, now); problem != warned {
+				if problem != "" {
+					logf(LOG_WARN, "%s", problem)
+				}
+				warned = problem
+			}
+			version, err := st.Version()
+			if err != nil {
+				logf(LOG_WARN, "Warning: could not read the store version: %v", err)
+				continue
+			}
+			if version != last {
+				last = version
+				if redisClient == nil {
+					reloadCaches(st, s)
+				} else if err := loadUserPreferences(st); err != nil {
+					// Redis already carries every other change
+					logf(LOG_WARN, "Error loading user preferences: %v", err)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
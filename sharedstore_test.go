@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreSync(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	saved := cfg.StoreSyncInterval
+	stop := make(chan struct{})
+	t.Cleanup(func() {
+		close(stop)
+		cfg.StoreSyncInterval = saved
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	before, err := db.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateGuildColumn(1, "link_buttons", false); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := db.Version(); after == before {
+		t.Fatal("writing guild settings left the store version alone")
+	}
+
+	cfg.StoreSyncInterval = 10 * time.Millisecond
+	go startStoreSync(db, s, stop)
+	time.Sleep(20 * time.Millisecond)
+	// another instance turns link buttons on
+	if _, err := db.db.Exec("UPDATE guild_settings SET link_buttons = 1 WHERE guild_id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		botSettings.RLock()
+		gs := botSettings.m[1]
+		botSettings.RUnlock()
+		if gs != nil && gs.LinkButtons {
+			return
+		}
+	}
+	t.Error("the cache never picked up the other instance's change")
+}
+
+func TestPrimaryProblem(t *testing.T) {
+	db := newTestDB(t)
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+	cfg.StoreSyncInterval = time.Minute
+	now := time.Now()
+	beat := func(id string, primary bool, seen time.Time) {
+		if err := db.Heartbeat(instance{ID: id, ShardCount: 2, Primary: primary, SeenAt: seen}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg.ShardCount = 2
+	beat("a", false, now)
+	if problem := primaryProblem(db, now); !strings.Contains(problem, "none of the 1 running instances") {
+		t.Errorf("a lone non-primary shard: %q", problem)
+	}
+	beat("b", true, now)
+	// c was primary, but stopped
+	beat("c", true, now.Add(-time.Hour))
+	if problem := primaryProblem(db, now); problem != "" {
+		t.Errorf("one primary: %q", problem)
+	}
+	beat("c", true, now)
+	if problem := primaryProblem(db, now); !strings.Contains(problem, "2 running instances are primary") {
+		t.Errorf("two primaries: %q", problem)
+	}
+
+	// a single unsharded instance needs no primary to share with
+	cfg.ShardCount = 1
+	if live, err := db.LiveInstances(now); err != nil || len(live) != 3 {
+		t.Fatalf("LiveInstances = %v, %v", live, err)
+	}
+	if _, err := db.db.Exec("DELETE FROM instances"); err != nil {
+		t.Fatal(err)
+	}
+	beat("d", false, now)
+	if problem := primaryProblem(db, now); problem != "" {
+		t.Errorf("a lone unsharded instance: %q", problem)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// keyCount is one row of a ranked count, e.g. a service and how many of its links were fixed.
+type keyCount struct {
+	Key   string
+	Count int
+}
+
+// linkFilter narrows the fixed links that are counted; zero fields don't filter.
+type linkFilter struct {
+	GuildID int64
+	Since   time.Time
+	Service string
+}
+
+// columns fixed links can be grouped by
+var linkCountColumns = map[string]string{
+	"service": "service",
+	"channel": "CAST(channel_id AS TEXT)",
+	"user":    "CAST(user_id AS TEXT)",
+}
+
+func (f linkFilter) where() (string, []interface{}) {
+	where := "guild_id = ?"
+	args := []interface{}{f.GuildID}
+	if !f.Since.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, f.Since.Unix())
+	}
+	if f.Service != "" {
+		where += " AND service = ?"
+		args = append(args, f.Service)
+	}
+	return where, args
+}
+
+// keyCounts runs a "key, count" query.
+func (st *sqliteStore) keyCounts(query string, args ...interface{}) ([]keyCount, error) {
+	rows, err := st.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []keyCount
+	for rows.Next() {
+		var c keyCount
+		if err := rows.Scan(&c.Key, &c.Count); err != nil {
+			continue
+		}
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+func (st *sqliteStore) LinkTotal(filter linkFilter) (int, error) {
+	where, args := filter.where()
+	var total int
+	err := st.db.QueryRow("SELECT COUNT(*) FROM link_stats WHERE "+where, args...).Scan(&total)
+	return total, err
+}
+
+func (st *sqliteStore) LinkCounts(filter linkFilter, by string, limit int) ([]keyCount, error) {
+	column, ok := linkCountColumns[by]
+	if !ok {
+		return nil, fmt.Errorf("can't count links by %q", by)
+	}
+	where, args := filter.where()
+	if by == "user" {
+		// links posted by webhooks have no user
+		where += " AND user_id != 0"
+	}
+	query := "SELECT " + column + ", COUNT(*) AS n FROM link_stats WHERE " + where + " GROUP BY 1 ORDER BY n DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return st.keyCounts(query, args...)
+}
+
+func (st *sqliteStore) FixedLinks(sinceDay string) (int, error) {
+	var total int
+	err := st.db.QueryRow("SELECT COALESCE(SUM(links), 0) FROM daily_stats WHERE day >= ?", sinceDay).Scan(&total)
+	return total, err
+}
+
+func (st *sqliteStore) FixedLinksByService(sinceDay string) ([]keyCount, error) {
+	return st.keyCounts("SELECT service, SUM(links) AS n FROM daily_stats WHERE day >= ? GROUP BY service ORDER BY n DESC", sinceDay)
+}
+
+func (st *sqliteStore) FixedLinksByDay(days int) ([]keyCount, error) {
+	return st.keyCounts("SELECT day, SUM(links) FROM daily_stats GROUP BY day ORDER BY day DESC LIMIT ?", days)
+}
+
+func (st *sqliteStore) RecordLinkFix(guildID, channelID, userID int64, service string, at time.Time) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		_, err := st.db.Exec("INSERT INTO link_stats (guild_id, channel_id, user_id, service, created_at) VALUES (?, ?, ?, ?, ?)",
+			guildID, channelID, userID, service, at.Unix())
+		if err == nil {
+			_, err = st.db.Exec(`INSERT INTO daily_stats (day, service, links) VALUES (?, ?, 1)
+				ON CONFLICT(day, service) DO UPDATE SET links = links + 1`, at.UTC().Format(time.DateOnly), service)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+// recordLinkFix counts one successfully fixed link.
+func recordLinkFix(st Store, m *discordgo.Message, service string) error {
+	gidInt, _ := discordIDStringToInt64(m.GuildID)
+	cidInt, _ := discordIDStringToInt64(m.ChannelID)
+	var userID int64
+	if m.Author != nil {
+		userID, _ = discordIDStringToInt64(m.Author.ID)
+	}
+	return st.RecordLinkFix(gidInt, cidInt, userID, service, time.Now())
+}
+
+func (st *sqliteStore) RecordBotEvent(guildID, channelID int64, kind, detail string) error {
+	_, err := st.db.Exec("INSERT INTO bot_events (guild_id, channel_id, kind, detail, created_at) VALUES (?, ?, ?, ?, ?)",
+		guildID, channelID, kind, detail, time.Now().Unix())
+	return err
+}
+
+// recordBotEvent stores a problem the bot ran into so admins can be told about it later.
+func recordBotEvent(st Store, guildID, channelID string, kind, detail string) error {
+	gidInt, _ := discordIDStringToInt64(guildID)
+	cidInt, _ := discordIDStringToInt64(channelID)
+	return st.RecordBotEvent(gidInt, cidInt, kind, detail)
+}
+
+// recordDeliveryError logs a failed send/delete/suppress and keeps it for the digest.
+// Permission failures are recorded separately so they stand out; it reports whether it was one.
+func recordDeliveryError(st Store, m *discordgo.Message, action string, err error) bool {
+	logf(LOG_WARN, "Warning: %s failed in channel %s: %v", action, m.ChannelID, err)
+	kind := "error"
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusForbidden {
+		kind = "permission"
+	}
+	detail := action + ": " + err.Error()
+	if restErr != nil && restErr.Message != nil && restErr.Message.Message != "" {
+		detail = action + ": " + restErr.Message.Message
+	}
+	if err := recordBotEvent(st, m.GuildID, m.ChannelID, kind, detail); err != nil {
+		logf(LOG_WARN, "Error recording bot event: %v", err)
+	}
+	return kind == "permission"
+}
+
+func handleStatsCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	days := 0
+	service := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "days":
+			days = int(opt.IntValue())
+		case "service":
+			service = opt.StringValue()
+		}
+	}
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	filter := linkFilter{GuildID: gidInt}
+	period := "All time"
+	if days > 0 {
+		filter.Since = time.Now().AddDate(0, 0, -days)
+		period = fmt.Sprintf("Last %d day(s)", days)
+	}
+	if service != "" {
+		svc := lookupService(service)
+		if svc == nil {
+			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ There's no service called `%s`.", service),
+					Flags:   1 << 6, // ephemeral
+				},
+			})
+			return
+		}
+		filter.Service = svc.Name
+		period += " · " + svc.label()
+	}
+
+	total, err := st.LinkTotal(filter)
+	if err != nil {
+		logf(LOG_WARN, "Error reading stats for guild %s: %v", i.GuildID, err)
+	}
+	services, err := st.LinkCounts(filter, "service", 0)
+	byService := topCounts(services, err, func(k string, n int) string { return fmt.Sprintf("%s: %d", k, n) })
+	channels, err := st.LinkCounts(filter, "channel", 5)
+	byChannel := topCounts(channels, err, func(k string, n int) string { return fmt.Sprintf("<#%s>: %d", k, n) })
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Statistics",
+		Description: fmt.Sprintf("%s: **%d** link(s) fixed", period, total),
+		Color:       0x5865F2,
+	}
+	if total > 0 {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "By Service", Value: byService, Inline: true},
+			{Name: "Top Channels", Value: byChannel, Inline: true},
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// Days of history the owner stats cover
+const OWNER_STATS_DAYS = 30
+
+// handleOwnerStats shows bot-wide usage, to see which services are worth maintaining.
+func handleOwnerStats(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	since := time.Now().UTC().AddDate(0, 0, -OWNER_STATS_DAYS).Format(time.DateOnly)
+	total, _ := st.FixedLinks("")
+	recent, _ := st.FixedLinks(since)
+	services, err := st.FixedLinksByService(since)
+	byService := topCounts(services, err, func(k string, n int) string { return fmt.Sprintf("%s: %d", k, n) })
+	days, err := st.FixedLinksByDay(7)
+	byDay := topCounts(days, err, func(k string, n int) string { return fmt.Sprintf("%s: %d", k, n) })
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Bot Statistics",
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Guilds", Value: fmt.Sprint(len(s.State.Guilds)), Inline: true},
+			{Name: "Links Fixed", Value: fmt.Sprint(total), Inline: true},
+			{Name: fmt.Sprintf("Last %d Days", OWNER_STATS_DAYS), Value: fmt.Sprint(recent), Inline: true},
+		},
+	}
+	if byService != "" {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: fmt.Sprintf("By Service (%d days)", OWNER_STATS_DAYS), Value: byService, Inline: true},
+			&discordgo.MessageEmbedField{Name: "By Day", Value: byDay, Inline: true},
+		)
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  1 << 6, // ephemeral
+		},
+	})
+}
+
+// Members shown on /leaderboard
+const LEADERBOARD_SIZE = 10
+
+func handleLeaderboardCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: "Leaderboard",
+		Color: 0x5865F2,
+	}
+	data := &discordgo.InteractionResponseData{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if !getGuildSettings(gidInt).Leaderboard {
+		embed.Description = "The leaderboard is off in this server. An admin can turn it on in `/settings panel`."
+		data.Flags = 1 << 6 // ephemeral
+	} else {
+		rank := 0
+		users, err := st.LinkCounts(linkFilter{GuildID: gidInt}, "user", LEADERBOARD_SIZE)
+		ranking := topCounts(users, err, func(k string, n int) string {
+			rank++
+			return fmt.Sprintf("**%d.** <@%s>: %d link(s)", rank, k, n)
+		})
+		embed.Description = ranking
+		if ranking == "" {
+			embed.Description = "No links have been fixed yet."
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
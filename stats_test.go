@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestStatsCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	for _, fix := range []struct {
+		channel, service string
+	}{{"20", "Twitter"}, {"20", "Twitter"}, {"21", "Pixiv"}} {
+		if err := recordLinkFix(db, &discordgo.Message{GuildID: "1", ChannelID: fix.channel, Author: &discordgo.User{ID: "5"}}, fix.service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// one old fix, and one in another guild
+	old := time.Now().AddDate(0, 0, -10).Unix()
+	if _, err := db.db.Exec("INSERT INTO link_stats (guild_id, channel_id, user_id, service, created_at) VALUES (1, 21, 5, 'Pixiv', ?), (2, 30, 5, 'Reddit', ?)", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	handleStatsCommand(db, s, slashCommand("stats", option("days", float64(7))))
+	body := fake.body("POST /interactions/900/token/callback")
+	for _, want := range []string{"Last 7 day(s): **3** link(s) fixed", "Twitter: 2\\nPixiv: 1", "\\u003c#20\\u003e: 2"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stats %s are missing %q", body, want)
+		}
+	}
+
+	handleStatsCommand(db, s, slashCommand("stats"))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "All time: **4** link(s)") {
+		t.Errorf("all-time stats = %s", body)
+	}
+}
+
+func TestOwnerStats(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	for _, service := range []string{"Twitter", "Twitter", "Reddit"} {
+		if err := recordLinkFix(db, &discordgo.Message{GuildID: "1", ChannelID: "20"}, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// daily stats outlive the link_stats rows they count
+	if _, err := db.db.Exec("DELETE FROM link_stats"); err != nil {
+		t.Fatal(err)
+	}
+
+	handleOwnerStats(db, s, slashCommand("owner", option("stats", nil)))
+	body := fake.body("POST /interactions/900/token/callback")
+	today := time.Now().UTC().Format(time.DateOnly)
+	for _, want := range []string{`"name":"Links Fixed","value":"3"`, "Twitter: 2\\nReddit: 1", today + ": 3"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("owner stats %s are missing %q", body, want)
+		}
+	}
+}
+
+func TestLeaderboardCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+	for _, user := range []string{"5", "6", "6"} {
+		if err := recordLinkFix(db, &discordgo.Message{GuildID: "1", ChannelID: "20", Author: &discordgo.User{ID: user}}, "Twitter"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handleLeaderboardCommand(db, s, slashCommand("leaderboard"))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "leaderboard is off") {
+		t.Errorf("leaderboard while off = %s", body)
+	}
+
+	settings := defaultGuildSettings()
+	settings.Leaderboard = true
+	botSettings.Lock()
+	botSettings.m[1] = settings
+	botSettings.Unlock()
+	handleLeaderboardCommand(db, s, slashCommand("leaderboard"))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "**1.** \\u003c@6\\u003e: 2 link(s)\\n**2.** \\u003c@5\\u003e: 1 link(s)") {
+		t.Errorf("leaderboard = %s", body)
+	}
+}
+
+func TestStatsCommandByService(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	for _, service := range []string{"Twitter", "Twitter", "Pixiv"} {
+		if err := recordLinkFix(db, &discordgo.Message{GuildID: "1", ChannelID: "20"}, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handleStatsCommand(db, s, slashCommand("stats", option("service", "twitter")))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "**2** link(s)") || strings.Contains(body, "Pixiv") {
+		t.Errorf("Twitter stats = %s", body)
+	}
+	handleStatsCommand(db, s, slashCommand("stats", option("service", "myspace")))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "no service called") {
+		t.Errorf("unknown service = %s", body)
+	}
+}
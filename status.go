@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Channels listed per /status page
+const STATUS_PAGE_SIZE = 20
+
+// statusPage builds one page of a guild's channel activation overview.
+func statusPage(st Store, s *discordgo.Session, guildID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	embed := &discordgo.MessageEmbed{
+		Title: "FixEmbed Status",
+		Color: accentColor(guildID, 0x78b159),
+	}
+	g, err := s.State.Guild(guildID)
+	if err != nil {
+		embed.Description = "❌ This server isn't available right now."
+		embed.Color = 0xff0000
+		return embed, nil
+	}
+
+	channels := make([]*discordgo.Channel, 0, len(g.Channels))
+	for _, ch := range g.Channels {
+		if isTrackedChannel(ch) {
+			channels = append(channels, ch)
+		}
+	}
+	sort.Slice(channels, func(a, b int) bool { return channels[a].Position < channels[b].Position })
+
+	pages := (len(channels) + STATUS_PAGE_SIZE - 1) / STATUS_PAGE_SIZE
+	if pages == 0 {
+		pages = 1
+	}
+	page = max(0, min(page, pages-1))
+
+	active := 0
+	lines := make([]string, 0, STATUS_PAGE_SIZE)
+	for idx, ch := range channels {
+		// channels without a stored state are active
+		enabled, ok := channelState(s, ch.ID)
+		enabled = enabled || !ok
+		if enabled {
+			active++
+		}
+		if idx/STATUS_PAGE_SIZE != page {
+			continue
+		}
+		mark := "✅"
+		if !enabled {
+			mark = "❌"
+		}
+		lines = append(lines, fmt.Sprintf("%s <#%s>", mark, ch.ID))
+	}
+	embed.Description = strings.Join(lines, "\n")
+	if embed.Description == "" {
+		embed.Description = "No text channels."
+	}
+
+	gidInt, _ := discordIDStringToInt64(guildID)
+	services := getGuildSettings(gidInt).EnabledServices
+	enabledServices := "None"
+	if len(services) > 0 {
+		enabledServices = strings.Join(services, ", ")
+	}
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Active Channels", Value: fmt.Sprintf("%d of %d", active, len(channels))},
+		{Name: "Enabled Services", Value: enabledServices},
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d of %d", page+1, pages)}
+
+	if pages == 1 {
+		return embed, nil
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				CustomID: fmt.Sprintf("status_page:%d", page-1),
+				Label:    "Previous",
+				Style:    discordgo.SecondaryButton,
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				CustomID: fmt.Sprintf("status_page:%d", page+1),
+				Label:    "Next",
+				Style:    discordgo.SecondaryButton,
+				Disabled: page == pages-1,
+			},
+		}},
+	}
+}
+
+func handleStatusCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed, components := statusPage(st, s, i.GuildID, 0)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      1 << 6, // ephemeral
+		},
+	})
+}
+
+// handleStatusPage flips a /status message to another page.
+func handleStatusPage(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, page string) {
+	n, _ := strconv.Atoi(page)
+	embed, components := statusPage(st, s, i.GuildID, n)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestStatusPage(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	_ = s.State.GuildAdd(&discordgo.Guild{ID: "1"})
+	for n := 0; n < STATUS_PAGE_SIZE+5; n++ {
+		_ = s.State.ChannelAdd(&discordgo.Channel{ID: fmt.Sprint(100 + n), GuildID: "1", Type: discordgo.ChannelTypeGuildText, Position: n})
+	}
+	_ = s.State.ChannelAdd(&discordgo.Channel{ID: "99", GuildID: "1", Type: discordgo.ChannelTypeGuildVoice})
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	setChannelState(db, s, "1", "121", false)
+
+	embed, components := statusPage(db, s, "1", 0)
+	if got := strings.Count(embed.Description, "\n") + 1; got != STATUS_PAGE_SIZE {
+		t.Errorf("first page lists %d channels, want %d", got, STATUS_PAGE_SIZE)
+	}
+	if embed.Fields[0].Value != fmt.Sprintf("24 of %d", STATUS_PAGE_SIZE+5) || len(components) != 1 {
+		t.Errorf("active = %q, %d component rows", embed.Fields[0].Value, len(components))
+	}
+
+	// out-of-range pages clamp to the last one
+	embed, _ = statusPage(db, s, "1", 7)
+	if !strings.Contains(embed.Description, "❌ <#121>") || strings.Contains(embed.Description, "<#100>") || embed.Footer.Text != "Page 2 of 2" {
+		t.Errorf("last page = %q, %q", embed.Description, embed.Footer.Text)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Store persists everything FixEmbed keeps between restarts. The in-memory caches sit in front
+// of it; SQLite (sqliteStore) is the default implementation.
+type Store interface {
+	// LoadGuildSettings returns every guild's stored settings.
+	LoadGuildSettings() (map[int64]*GuildSettings, error)
+	// GuildSettings returns one guild's stored settings, or nil when it has none.
+	GuildSettings(guildID int64) (*GuildSettings, error)
+	UpdateSetting(guildID int64, enabledServices []string, mentionUsers bool, deleteOriginal bool) error
+	// UpdateGuildColumn sets a single setting; column must be a fixed column name, never user input.
+	UpdateGuildColumn(guildID int64, column string, value interface{}) error
+
+	// LoadChannelStates returns every channel's stored state.
+	LoadChannelStates() (map[int64]bool, error)
+	UpdateChannelState(channelID int64, state bool) error
+	UpdateChannelStates(channelIDs []int64, state bool) error
+
+	LoadChannelOverrides() (map[int64]*channelOverride, error)
+	UpdateChannelOverride(channelID int64, o *channelOverride) error
+	// SaveGuildSettings stores a whole imported settings file.
+	SaveGuildSettings(guildID int64, settings *GuildSettings) error
+	// ResetGuild deletes a guild's settings and channel overrides.
+	ResetGuild(guildID int64) error
+	RecordSettingsChange(guildID int64, change settingsChange) error
+	// SettingsHistory returns a guild's latest settings changes, newest first.
+	SettingsHistory(guildID int64, limit int) ([]settingsChange, error)
+
+	LoadOptedOutUsers() (map[int64]bool, error)
+	UpdateUserOptOut(userID int64, optOut bool) error
+	LoadMentionPreferences() (map[int64]bool, error)
+	UpdateMentionPreference(userID int64, mention *bool) error
+	// LoadIgnoredUsers returns guild ID -> ignored user IDs.
+	LoadIgnoredUsers() (map[int64]map[int64]bool, error)
+	UpdateIgnoredUser(guildID, userID int64, ignore bool) error
+
+	RecordFixMessage(f fixMessage) error
+	// FixMessage returns the original message and author of a fix message; both are 0 when it isn't tracked.
+	FixMessage(messageID int64) (originalID, authorID int64, err error)
+	// Reposts returns the fix messages posted for an original message, in order, and the
+	// signature they were built from.
+	Reposts(originalID int64) ([]repostRef, string, error)
+	UpdateRepostSignature(originalID int64, signature string) error
+	DeleteFixMessage(messageID int64) error
+	// UpdateChannelRetention sets how many days fix messages are kept in a channel; 0 keeps them forever.
+	UpdateChannelRetention(channelID int64, days int) error
+	// LoadChannelRetention returns channel ID -> days for the channels that have a retention policy.
+	LoadChannelRetention() (map[int64]int, error)
+	ExpiredFixMessages(now time.Time) ([]fixMessage, error)
+	ForgetFixMessages(cutoff time.Time) error
+
+	RecordLinkFix(guildID, channelID, userID int64, service string, at time.Time) error
+	RecordBotEvent(guildID, channelID int64, kind, detail string) error
+	LinkTotal(filter linkFilter) (int, error)
+	// LinkCounts counts fixed links by "service", "channel" or "user", most first; limit 0 means all.
+	LinkCounts(filter linkFilter, by string, limit int) ([]keyCount, error)
+	BotEventCounts(guildID int64, since time.Time, limit int) ([]keyCount, error)
+	// FixedLinks counts the links fixed in every guild since a day (YYYY-MM-DD, UTC); "" counts all.
+	FixedLinks(sinceDay string) (int, error)
+	FixedLinksByService(sinceDay string) ([]keyCount, error)
+	// FixedLinksByDay returns the totals of the latest days, newest first.
+	FixedLinksByDay(days int) ([]keyCount, error)
+
+	UpdateDigestChannel(guildID int64, channelID int64) error
+	// DigestChannel returns the channel a guild's weekly digest goes to, 0 when it has none.
+	DigestChannel(guildID int64) (int64, error)
+	DigestChannels() ([]digestChannel, error)
+	MarkDigestPosted(guildID int64, at time.Time) error
+
+	// Version changes whenever the stored settings or states do, including from other instances.
+	Version() (int64, error)
+	// Heartbeat records that an instance is running; LiveInstances lists those seen since a time.
+	Heartbeat(inst instance) error
+	LiveInstances(since time.Time) ([]instance, error)
+	Close() error
+}
+
+// the store the bot runs on, set up in main
+var store Store
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := initDB(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (st *sqliteStore) Version() (int64, error) {
+	var version int64
+	err := st.db.QueryRow("SELECT version FROM store_version WHERE id = 1").Scan(&version)
+	return version, err
+}
+
+func (st *sqliteStore) Close() error {
+	return st.db.Close()
+}
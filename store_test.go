@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStoreSettings(t *testing.T) {
+	st := newTestDB(t)
+
+	if gs, err := st.GuildSettings(1); err != nil || gs != nil {
+		t.Fatalf("GuildSettings of an unknown guild = %+v, %v; want nil", gs, err)
+	}
+	if err := st.UpdateSetting(1, []string{"Twitter"}, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateGuildColumn(1, "link_buttons", true); err != nil {
+		t.Fatal(err)
+	}
+	gs, err := st.GuildSettings(1)
+	if err != nil || gs == nil {
+		t.Fatalf("GuildSettings = %v, %v", gs, err)
+	}
+	if !reflect.DeepEqual(gs.EnabledServices, []string{"Twitter"}) || gs.MentionUsers || !gs.DeleteOriginal || !gs.LinkButtons {
+		t.Errorf("GuildSettings = %+v, want both writes", gs)
+	}
+	if all, err := st.LoadGuildSettings(); err != nil || len(all) != 1 || !all[1].LinkButtons {
+		t.Errorf("LoadGuildSettings = %v, %v", all, err)
+	}
+
+	if err := st.UpdateChannelStates([]int64{20, 21}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateChannelState(21, true); err != nil {
+		t.Fatal(err)
+	}
+	if states, err := st.LoadChannelStates(); err != nil || !reflect.DeepEqual(states, map[int64]bool{20: false, 21: true}) {
+		t.Errorf("LoadChannelStates = %v, %v", states, err)
+	}
+
+	if err := st.ResetGuild(1); err != nil {
+		t.Fatal(err)
+	}
+	if gs, _ := st.GuildSettings(1); gs != nil {
+		t.Errorf("GuildSettings after a reset = %+v", gs)
+	}
+}
+
+func TestSQLiteStoreFixMessages(t *testing.T) {
+	st := newTestDB(t)
+
+	for _, id := range []int64{101, 102} {
+		if err := st.RecordFixMessage(fixMessage{MessageID: id, ChannelID: 20, GuildID: 1, OriginalID: 100, AuthorID: 5, Signature: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refs, signature, err := st.Reposts(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []repostRef{{"20", "101"}, {"20", "102"}}; !reflect.DeepEqual(refs, want) || signature != "a" {
+		t.Errorf("Reposts = %v, %q; want %v, \"a\"", refs, signature, want)
+	}
+	if err := st.UpdateRepostSignature(100, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, signature, _ := st.Reposts(100); signature != "b" {
+		t.Errorf("signature after an update = %q, want \"b\"", signature)
+	}
+	if original, author, err := st.FixMessage(102); err != nil || original != 100 || author != 5 {
+		t.Errorf("FixMessage = %d, %d, %v; want 100, 5", original, author, err)
+	}
+
+	if err := st.DeleteFixMessage(101); err != nil {
+		t.Fatal(err)
+	}
+	if original, _, _ := st.FixMessage(101); original != 0 {
+		t.Errorf("FixMessage of a deleted repost = %d", original)
+	}
+	if err := st.ForgetFixMessages(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _, _ := st.Reposts(100); len(refs) != 0 {
+		t.Errorf("Reposts after forgetting = %v", refs)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Repost format used unless a guild sets its own
+const DEFAULT_REPOST_TEMPLATE = "{link} | {sent_by}"
+
+// Longest template a guild can store
+const MAX_TEMPLATE_LENGTH = 300
+
+// renderRepost fills a repost template. {link} is every fixed link (or the whole message with
+// them swapped in, when the text is kept); {author_mention} only pings when mentions are on,
+// and {sent_by} is empty when attribution is off.
+func renderRepost(template, link string, links []*repostLink, author *discordgo.User, sentBy string) string {
+	if template == "" {
+		template = DEFAULT_REPOST_TEMPLATE
+		if sentBy == "" {
+			template = "{link}"
+		}
+	}
+	labels := make([]string, 0, len(links))
+	for _, l := range links {
+		if label := l.Service.label(); !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return strings.NewReplacer(
+		"{link}", link,
+		"{service}", strings.Join(labels, ", "),
+		"{user}", escapeMarkdown(author.Username),
+		"{author_mention}", "<@"+author.ID+">",
+		"{sent_by}", sentBy,
+	).Replace(template)
+}
+
+// repostTemplateModal asks for the guild's template, starting from the current one.
+func repostTemplateModal(settings *GuildSettings) *discordgo.InteractionResponseData {
+	current := settings.RepostTemplate
+	if current == "" {
+		current = DEFAULT_REPOST_TEMPLATE
+	}
+	return &discordgo.InteractionResponseData{
+		CustomID: "repost_template",
+		Title:    "Repost Template",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "template",
+					Label:       "Template (placeholders are listed in /help)",
+					Style:       discordgo.TextInputParagraph,
+					Placeholder: DEFAULT_REPOST_TEMPLATE,
+					Value:       current,
+					MaxLength:   MAX_TEMPLATE_LENGTH,
+				},
+			}},
+		},
+	}
+}
+
+// handleRepostTemplateModal stores the submitted template; an empty one restores the default.
+func handleRepostTemplateModal(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	template := ""
+	for _, row := range i.ModalSubmitData().Components {
+		for _, c := range row.(*discordgo.ActionsRow).Components {
+			if input, ok := c.(*discordgo.TextInput); ok && input.CustomID == "template" {
+				template = strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	if template == DEFAULT_REPOST_TEMPLATE {
+		template = ""
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Repost Template",
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if template != "" && !strings.Contains(template, "{link}") {
+		embed.Description = "❌ The template needs a `{link}` placeholder, or there's nothing to repost."
+		embed.Color = 0xff0000
+	} else {
+		gidInt, _ := discordIDStringToInt64(i.GuildID)
+		if err := st.UpdateGuildColumn(gidInt, "repost_template", template); err != nil {
+			logf(LOG_WARN, "Error updating repost template for guild %s: %v", i.GuildID, err)
+			embed.Description = "❌ Could not update the template."
+			embed.Color = 0xff0000
+		} else {
+			updated := *getGuildSettings(gidInt)
+			updated.RepostTemplate = template
+			cacheGuildSettings(gidInt, &updated)
+			if template == "" {
+				template = DEFAULT_REPOST_TEMPLATE
+			}
+			embed.Description = "📝 Reposts will look like:\n" + template
+		}
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  1 << 6, // ephemeral
+		},
+	})
+}
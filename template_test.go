@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRenderRepost(t *testing.T) {
+	links := []*repostLink{
+		{FixedLink: &FixedLink{Service: findService("Twitter")}},
+		{FixedLink: &FixedLink{Service: findService("Twitter")}},
+		{FixedLink: &FixedLink{Service: findService("Pixiv")}},
+	}
+	author := &discordgo.User{ID: "5", Username: "a_b"}
+	for _, tt := range []struct {
+		template string
+		want     string
+	}{
+		{"", "LINK | Sent by <@5>"},
+		{"{service} from {user} ({author_mention}): {link}", `Twitter, Pixiv from a\_b (<@5>): LINK`},
+	} {
+		if got := renderRepost(tt.template, "LINK", links, author, "Sent by <@5>"); got != tt.want {
+			t.Errorf("renderRepost(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+// templateSubmit is a submission of the repost template modal.
+func templateSubmit(template string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "900",
+		Token:   "token",
+		Type:    discordgo.InteractionModalSubmit,
+		GuildID: "1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "5"}, Permissions: discordgo.PermissionManageGuild},
+		Data: discordgo.ModalSubmitInteractionData{CustomID: "repost_template", Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: "template", Value: template}}},
+		}},
+	}}
+}
+
+func TestRepostTemplateModal(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	onInteractionCreate(db, s, templateSubmit("{sent_by} only"))
+	if body := fake.body("POST /interactions/900/token/callback"); !strings.Contains(body, "{link}") {
+		t.Errorf("a template without {link} = %s", body)
+	}
+	if got := getGuildSettings(1).RepostTemplate; got != "" {
+		t.Errorf("stored template = %q after a rejected one", got)
+	}
+
+	onInteractionCreate(db, s, templateSubmit("  {link} via {user}  "))
+	if got := getGuildSettings(1).RepostTemplate; got != "{link} via {user}" {
+		t.Errorf("stored template = %q", got)
+	}
+
+	// submitting the default clears the setting
+	onInteractionCreate(db, s, templateSubmit(DEFAULT_REPOST_TEMPLATE))
+	if got := getGuildSettings(1).RepostTemplate; got != "" {
+		t.Errorf("stored template = %q, want the default", got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleTestCommand shows what FixEmbed would do with a link here, and what would stop it.
+func handleTestCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	url := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "url" {
+			url = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	// fixing may check on fixers over the network
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: 1 << 6}, // ephemeral
+	})
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	settings := channelSettings(s, i.ChannelID, getGuildSettings(gidInt))
+	embed := &discordgo.MessageEmbed{
+		Title: "Link Test",
+		Color: 0x78b159,
+	}
+	defer func() {
+		createFooter(embed, s)
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds: &[]*discordgo.MessageEmbed{embed},
+		})
+	}()
+
+	content := expandShortLinks(url, 1)
+	pattern := servicePattern(settings)
+	match := regexp.MustCompile(`https?://(?:www\.)?(` + pattern + `)`).FindStringSubmatch(content)
+	if match == nil || match[1] == "" {
+		embed.Description = "❌ No service recognises this link."
+		embed.Color = 0xff0000
+		return
+	}
+	domain := strings.ToLower(strings.SplitN(match[1], "/", 2)[0])
+	svc := serviceForDomain(domain, settings)
+	if svc == nil {
+		embed.Description = "❌ No service recognises this link."
+		embed.Color = 0xff0000
+		return
+	}
+
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Service", Value: svc.label(), Inline: true})
+	if fixed, ok := svc.fix(match[1], domain, settings); ok {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Fixer", Value: fixed.Fixer, Inline: true},
+			&discordgo.MessageEmbedField{Name: "Fixed Link", Value: fixed.Fixed},
+			&discordgo.MessageEmbedField{Name: "Display Text", Value: fixed.DisplayText},
+		)
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Fixed Link", Value: "❌ The link matched, but isn't a post this service can fix."})
+	}
+
+	enabled, known := channelState(s, i.ChannelID)
+	userID := interactionUserID(i)
+	checks := []struct {
+		ok   bool
+		text string
+	}{
+		{slices.Contains(settings.EnabledServices, svc.Name), svc.label() + " is enabled on this server"},
+		{!known || enabled, "FixEmbed is active in this channel"},
+		{settings.NSFWMode != NSFW_MODE_SKIP || !isNSFWChannel(s, i.ChannelID), "NSFW channels aren't skipped"},
+		{!isOptedOut(userID), "You haven't opted out with /optout"},
+		{!isIgnored(i.GuildID, userID), "You aren't on the server's ignore list"},
+		{!regexp.MustCompile(`<https?://(?:www\.)?(` + pattern + `)>`).MatchString(content), "The link isn't wrapped in <>"},
+	}
+	lines := make([]string, 0, len(checks)+1)
+	blocked := false
+	for _, c := range checks {
+		mark := "✅"
+		if !c.ok {
+			mark = "❌"
+			blocked = true
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", mark, c.text))
+	}
+	lines = append(lines, fmt.Sprintf("ℹ️ Messages starting with `%s` are skipped", settings.OptOutKeyword))
+	if !canManageMessages(s, i.ChannelID) {
+		lines = append(lines, "⚠️ FixEmbed lacks Manage Messages here, so the original message will be left as it is")
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Checks", Value: strings.Join(lines, "\n")})
+	if blocked {
+		embed.Description = "This link would **not** be fixed if you posted it here."
+		embed.Color = 0xff0000
+	} else {
+		embed.Description = "This link would be fixed if you posted it here."
+	}
+}
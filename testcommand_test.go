@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTestCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	fixerHealthCache.Lock()
+	fixerHealthCache.m["fixupx.com"] = fixerHealth{up: true, checked: time.Now()}
+	fixerHealthCache.Unlock()
+	t.Cleanup(func() {
+		channelStates.Lock()
+		channelStates.m = make(map[int64]bool)
+		channelStates.Unlock()
+	})
+	channelStates.Lock()
+	channelStates.m[20] = false
+	channelStates.Unlock()
+
+	handleTestCommand(db, s, slashCommand("test", option("url", "https://x.com/a/status/1")))
+	var edit string
+	for _, call := range fake.calls() {
+		if strings.HasPrefix(call, "PATCH /webhooks/") {
+			edit = fake.body(call)
+		}
+	}
+	for _, want := range []string{"fixupx.com/a/status/1", "❌ FixEmbed is active in this channel", "✅ Twitter is enabled", "would **not** be fixed"} {
+		if !strings.Contains(edit, want) {
+			t.Errorf("test result %s is missing %q", edit, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// updateDelivery stores a guild's MentionUsers and DeleteOriginal settings and refreshes the cache.
+func updateDelivery(st Store, guildID int64, apply func(*GuildSettings)) error {
+	updated := *getGuildSettings(guildID)
+	apply(&updated)
+	if err := st.UpdateSetting(guildID, updated.EnabledServices, updated.MentionUsers, updated.DeleteOriginal); err != nil {
+		return err
+	}
+	cacheGuildSettings(guildID, &updated)
+	return nil
+}
+
+func handleMentionCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	enabled := i.ApplicationCommandData().Options[0].BoolValue()
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := updateDelivery(st, gidInt, func(gs *GuildSettings) { gs.MentionUsers = enabled }); err != nil {
+		logf(LOG_WARN, "Error updating mention setting for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the setting."
+		embed.Color = 0xff0000
+	} else if enabled {
+		embed.Description = "🔔 Reposts will mention whoever posted the link."
+	} else {
+		embed.Description = "🔕 Reposts will no longer mention whoever posted the link."
+		embed.Color = 0xff0000
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+func handleDeliveryCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	deleteOriginal := i.ApplicationCommandData().Options[0].BoolValue()
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	if err := updateDelivery(st, gidInt, func(gs *GuildSettings) { gs.DeleteOriginal = deleteOriginal }); err != nil {
+		logf(LOG_WARN, "Error updating delivery setting for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update the setting."
+		embed.Color = 0xff0000
+	} else if deleteOriginal {
+		embed.Description = "🗑️ Original messages will be deleted after the fixed link is posted."
+	} else {
+		embed.Description = "💬 Original messages will be kept, with their embeds suppressed."
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestMentionAndDeliveryCommands(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	t.Cleanup(func() {
+		botSettings.Lock()
+		delete(botSettings.m, 1)
+		botSettings.Unlock()
+	})
+
+	handleMentionCommand(db, s, slashCommand("mention", option("enabled", false)))
+	handleDeliveryCommand(db, s, slashCommand("delivery", option("delete_original", false)))
+	botSettings.Lock()
+	delete(botSettings.m, 1)
+	botSettings.Unlock()
+	if gs := getGuildSettings(1); gs.MentionUsers || gs.DeleteOriginal {
+		t.Errorf("settings = %+v, want mentions and deletion off", gs)
+	}
+
+	// changing one leaves the other alone
+	handleMentionCommand(db, s, slashCommand("mention", option("enabled", true)))
+	if gs := getGuildSettings(1); !gs.MentionUsers || gs.DeleteOriginal {
+		t.Errorf("settings = %+v, want only mentions on", gs)
+	}
+}
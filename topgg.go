@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How often the server count is posted to top.gg
+const TOPGG_POST_INTERVAL = 30 * time.Minute
+
+// top.gg API token (TOPGG_TOKEN); the integration is off without one
+var topggToken string
+
+var topggClient = &http.Client{Timeout: 10 * time.Second}
+
+// postTopggStats sends the current server count to top.gg.
+func postTopggStats(s *discordgo.Session) error {
+	body, err := json.Marshal(map[string]int{
+		"server_count": len(s.State.Guilds),
+		"shard_count":  max(s.ShardCount, 1),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://top.gg/api/bots/%s/stats", s.State.User.ID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", topggToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := topggClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("top.gg returned %s", resp.Status)
+	}
+	return nil
+}
+
+func startTopggPoster(s *discordgo.Session, stop <-chan struct{}) {
+	if topggToken == "" {
+		return
+	}
+	ticker := time.NewTicker(TOPGG_POST_INTERVAL)
+	for {
+		select {
+		case <-ticker.C:
+			if err := postTopggStats(s); err != nil {
+				logf(LOG_WARN, "Error posting stats to top.gg: %v", err)
+			}
+		case <-stop:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func handleVoteCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Vote for " + s.State.User.Username,
+		Description: "Votes help other servers find FixEmbed. Thank you!",
+		Color:       0xff3366,
+	}
+	createFooter(embed, s)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label: "Vote on top.gg",
+						Style: discordgo.LinkButton,
+						URL:   fmt.Sprintf("https://top.gg/bot/%s/vote", s.State.User.ID),
+					},
+				}},
+			},
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPostTopggStats(t *testing.T) {
+	s, _ := newTestSession(t, nil)
+	topggToken = "secret"
+	transport := topggClient.Transport
+	t.Cleanup(func() {
+		topggToken = ""
+		topggClient.Transport = transport
+	})
+	var got *http.Request
+	var body string
+	status := http.StatusOK
+	topggClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	if err := postTopggStats(s); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.String() != "https://top.gg/api/bots/1/stats" || got.Header.Get("Authorization") != "secret" || body != `{"server_count":0,"shard_count":1}` {
+		t.Errorf("posted %s %s with %q", got.URL, body, got.Header.Get("Authorization"))
+	}
+
+	status = http.StatusUnauthorized
+	if err := postTopggStats(s); err == nil {
+		t.Error("a rejected post reported no error")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// length of a language code, as a variable so the command option can point at it
+var languageCodeLength = 2
+
+// ISO 639-1 codes as accepted by FxTwitter's translation path
+var languageCodeRe = regexp.MustCompile(`^[a-z]{2}$`)
+
+// FxTwitter translates a post when a language code follows the status ID
+var fxTwitterStatusRe = regexp.MustCompile(`^(?:d\.)?(?:fxtwitter|fixupx)\.com/[A-Za-z0-9_]+/status/[0-9]+$`)
+
+// translateTwitterLink appends the translation path to FxTwitter links. Other frontends and
+// links pointing at a single media item are left alone.
+func translateTwitterLink(fixed, language string) string {
+	if !fxTwitterStatusRe.MatchString(fixed) {
+		return fixed
+	}
+	return fixed + "/" + language
+}
+
+func handleTranslateCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	enabled := false
+	language := "en"
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "language":
+			language = strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: s.State.User.Username,
+		Color: accentColor(i.GuildID, 0x78b159),
+	}
+	respond := func() {
+		createFooter(embed, s)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+			},
+		})
+	}
+
+	if enabled && !languageCodeRe.MatchString(language) {
+		embed.Description = fmt.Sprintf("❌ `%s` is not a two-letter language code (e.g. `en`, `de`, `ja`).", language)
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	if !enabled {
+		language = ""
+	}
+
+	gidInt, _ := discordIDStringToInt64(i.GuildID)
+	if err := st.UpdateGuildColumn(gidInt, "translate_language", language); err != nil {
+		logf(LOG_WARN, "Error updating translation language for guild %s: %v", i.GuildID, err)
+		embed.Description = "❌ Could not update tweet translation."
+		embed.Color = 0xff0000
+		respond()
+		return
+	}
+	updated := *getGuildSettings(gidInt)
+	updated.TranslateLanguage = language
+	cacheGuildSettings(gidInt, &updated)
+
+	if enabled {
+		embed.Description = fmt.Sprintf("🌐 Tweets will be translated to `%s`.", language)
+	} else {
+		embed.Description = "🌐 Tweet translation is now disabled."
+		embed.Color = 0xff0000
+	}
+	respond()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTranslateTwitterLink(t *testing.T) {
+	tests := map[string]string{
+		"fxtwitter.com/someone/status/123":         "fxtwitter.com/someone/status/123/de",
+		"d.fixupx.com/someone/status/123":          "d.fixupx.com/someone/status/123/de",
+		"fxtwitter.com/someone/status/123/photo/2": "fxtwitter.com/someone/status/123/photo/2",
+		"fixvx.com/someone/status/123":             "fixvx.com/someone/status/123",
+	}
+	for fixed, want := range tests {
+		if got := translateTwitterLink(fixed, "de"); got != want {
+			t.Errorf("translateTwitterLink(%q) = %q, want %q", fixed, got, want)
+		}
+	}
+}
+
+func TestHandleTranslateCommand(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	run := func(enabled bool, language string) string {
+		options := []*discordgo.ApplicationCommandInteractionDataOption{option("enabled", enabled)}
+		if language != "" {
+			options = append(options, option("language", language))
+		}
+		handleTranslateCommand(db, s, slashCommand("translate", options...))
+		return fake.body("POST /interactions/900/token/callback")
+	}
+
+	run(true, " DE ")
+	if gs, _ := db.GuildSettings(1); gs.TranslateLanguage != "de" || getGuildSettings(1).TranslateLanguage != "de" {
+		t.Errorf("translate language = %q, want de", gs.TranslateLanguage)
+	}
+	if reply := run(true, "german"); !strings.Contains(reply, "not a two-letter language code") {
+		t.Errorf("invalid language answered with %s", reply)
+	}
+	if gs, _ := db.GuildSettings(1); gs.TranslateLanguage != "de" {
+		t.Errorf("an invalid language replaced %q", gs.TranslateLanguage)
+	}
+	run(false, "")
+	if gs, _ := db.GuildSettings(1); gs.TranslateLanguage != "" {
+		t.Errorf("translate language = %q after disabling", gs.TranslateLanguage)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// users who don't want FixEmbed touching their messages, in any guild
+var optedOutUsers = struct {
+	sync.RWMutex
+	m map[int64]bool
+}{m: make(map[int64]bool)}
+
+func (st *sqliteStore) LoadOptedOutUsers() (map[int64]bool, error) {
+	rows, err := st.db.Query("SELECT user_id FROM opted_out_users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	optedOut := make(map[int64]bool)
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			continue
+		}
+		optedOut[userID] = true
+	}
+	return optedOut, nil
+}
+
+func loadOptedOutUsers(st Store) error {
+	optedOut, err := st.LoadOptedOutUsers()
+	if err != nil {
+		return err
+	}
+	optedOutUsers.Lock()
+	optedOutUsers.m = optedOut
+	optedOutUsers.Unlock()
+	return nil
+}
+
+func isOptedOut(userID string) bool {
+	uid, err := discordIDStringToInt64(userID)
+	if err != nil {
+		return false
+	}
+	optedOutUsers.RLock()
+	defer optedOutUsers.RUnlock()
+	return optedOutUsers.m[uid]
+}
+
+func (st *sqliteStore) UpdateUserOptOut(userID int64, optOut bool) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		var err error
+		if optOut {
+			_, err = st.db.Exec("INSERT OR REPLACE INTO opted_out_users (user_id, created_at) VALUES (?, ?)", userID, time.Now().Unix())
+		} else {
+			_, err = st.db.Exec("DELETE FROM opted_out_users WHERE user_id = ?", userID)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func updateUserOptOut(st Store, userID int64, optOut bool) error {
+	if err := st.UpdateUserOptOut(userID, optOut); err != nil {
+		return err
+	}
+	cacheOptOut(userID, optOut)
+	return nil
+}
+
+// interactionUserID returns who invoked an interaction, in a guild or a DM.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// handleOptOutCommand serves both /optout and /optin.
+func handleOptOutCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate, optOut bool) {
+	uid, _ := discordIDStringToInt64(interactionUserID(i))
+	content := T(interactionLocale(i), "optout.out")
+	if !optOut {
+		content = T(interactionLocale(i), "optout.in")
+	}
+	if err := updateUserOptOut(st, uid, optOut); err != nil {
+		logf(LOG_WARN, "Error updating opt-out for user %d: %v", uid, err)
+		content = T(interactionLocale(i), "error.preference")
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+}
+
+// per-user override of the guild's MentionUsers setting; absent means follow the guild
+var mentionPreferences = struct {
+	sync.RWMutex
+	m map[int64]bool
+}{m: make(map[int64]bool)}
+
+func (st *sqliteStore) LoadMentionPreferences() (map[int64]bool, error) {
+	rows, err := st.db.Query("SELECT user_id, mention FROM user_preferences WHERE mention IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := make(map[int64]bool)
+	for rows.Next() {
+		var userID int64
+		var mention bool
+		if err := rows.Scan(&userID, &mention); err != nil {
+			continue
+		}
+		preferences[userID] = mention
+	}
+	return preferences, nil
+}
+
+func loadUserPreferences(st Store) error {
+	preferences, err := st.LoadMentionPreferences()
+	if err != nil {
+		return err
+	}
+	mentionPreferences.Lock()
+	mentionPreferences.m = preferences
+	mentionPreferences.Unlock()
+	return nil
+}
+
+// shouldMention decides whether the "Sent by" line pings the author.
+func shouldMention(userID string, settings *GuildSettings) bool {
+	uid, err := discordIDStringToInt64(userID)
+	if err != nil {
+		return settings.MentionUsers
+	}
+	mentionPreferences.RLock()
+	defer mentionPreferences.RUnlock()
+	if mention, ok := mentionPreferences.m[uid]; ok {
+		return mention
+	}
+	return settings.MentionUsers
+}
+
+// UpdateMentionPreference stores a user's mention preference; nil goes back to the guild's setting.
+func (st *sqliteStore) UpdateMentionPreference(userID int64, mention *bool) error {
+	var lastErr error
+	for i := 0; i < 5; i++ {
+		_, err := st.db.Exec(`INSERT INTO user_preferences (user_id, mention) VALUES (?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET mention = excluded.mention`, userID, mention)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if strings.Contains(err.Error(), "database is locked") {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func updateMentionPreference(st Store, userID int64, mention *bool) error {
+	if err := st.UpdateMentionPreference(userID, mention); err != nil {
+		return err
+	}
+	mentionPreferences.Lock()
+	if mention == nil {
+		delete(mentionPreferences.m, userID)
+	} else {
+		mentionPreferences.m[userID] = *mention
+	}
+	mentionPreferences.Unlock()
+	return nil
+}
+
+func handlePingMeCommand(st Store, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	mode := "default"
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "mode" {
+			mode = opt.StringValue()
+		}
+	}
+
+	var mention *bool
+	content := T(interactionLocale(i), "pingme.default")
+	switch mode {
+	case "always":
+		mention = new(bool)
+		*mention = true
+		content = T(interactionLocale(i), "pingme.always")
+	case "never":
+		mention = new(bool)
+		content = T(interactionLocale(i), "pingme.never")
+	}
+
+	uid, _ := discordIDStringToInt64(interactionUserID(i))
+	if err := updateMentionPreference(st, uid, mention); err != nil {
+		logf(LOG_WARN, "Error updating mention preference for user %d: %v", uid, err)
+		content = T(interactionLocale(i), "error.preference")
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   1 << 6, // ephemeral
+		},
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// forgetOptedOutUsers clears the in-memory opt-outs, as a restart would.
+func forgetOptedOutUsers() {
+	optedOutUsers.Lock()
+	optedOutUsers.m = make(map[int64]bool)
+	optedOutUsers.Unlock()
+}
+
+func TestOptOut(t *testing.T) {
+	db := newTestDB(t)
+	s, fake := newTestSession(t, nil)
+	t.Cleanup(forgetOptedOutUsers)
+
+	handleOptOutCommand(db, s, slashCommand("optout"), true)
+	if !isOptedOut("5") {
+		t.Fatal("/optout did not opt member 5 out")
+	}
+	postMessage(t, db, s, defaultGuildSettings(), "https://x.com/a/status/1")
+	if strings.Contains(strings.Join(fake.calls(), " "), "/channels/20/messages") {
+		t.Errorf("an opted-out member's link was fixed: %v", fake.calls())
+	}
+
+	// the opt-out outlives a restart
+	forgetOptedOutUsers()
+	if err := loadOptedOutUsers(db); err != nil || !isOptedOut("5") {
+		t.Errorf("after reloading: opted out %t, %v", isOptedOut("5"), err)
+	}
+
+	// /optin works from DMs too
+	dm := slashCommand("optin")
+	dm.GuildID, dm.Member, dm.User = "", nil, &discordgo.User{ID: "5"}
+	handleOptOutCommand(db, s, dm, false)
+	forgetOptedOutUsers()
+	if err := loadOptedOutUsers(db); err != nil || isOptedOut("5") {
+		t.Errorf("after /optin: opted out %t, %v", isOptedOut("5"), err)
+	}
+}
+
+func TestPingMe(t *testing.T) {
+	db := newTestDB(t)
+	s, _ := newTestSession(t, nil)
+	forget := func() {
+		mentionPreferences.Lock()
+		mentionPreferences.m = make(map[int64]bool)
+		mentionPreferences.Unlock()
+	}
+	t.Cleanup(forget)
+	quiet := defaultGuildSettings()
+	quiet.MentionUsers = false
+
+	for _, tt := range []struct {
+		mode        string
+		loud, quiet bool
+	}{
+		{"never", false, false},
+		{"always", true, true},
+		{"default", true, false},
+	} {
+		handlePingMeCommand(db, s, slashCommand("pingme", option("mode", tt.mode)))
+		// preferences survive a restart
+		forget()
+		if err := loadUserPreferences(db); err != nil {
+			t.Fatal(err)
+		}
+		if got := shouldMention("5", defaultGuildSettings()); got != tt.loud {
+			t.Errorf("%s in a pinging server: mention %t, want %t", tt.mode, got, tt.loud)
+		}
+		if got := shouldMention("5", quiet); got != tt.quiet {
+			t.Errorf("%s in a quiet server: mention %t, want %t", tt.mode, got, tt.quiet)
+		}
+	}
+}
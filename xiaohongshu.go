@@ -0,0 +1,122 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	xiaohongshuNoteRe   = regexp.MustCompile(`xiaohongshu\.com/(?:explore|discovery/item)/([0-9a-f]+)`)
+	xiaohongshuAuthorRe = regexp.MustCompile(`"nickname":"([^"]+)"`)
+)
+
+// canonicalXiaohongshuLink turns any note link into xiaohongshu.com/explore/<id>, keeping the
+// xsec_token that the site requires to show notes to logged-out visitors.
+func canonicalXiaohongshuLink(link string) (string, bool) {
+	mm := xiaohongshuNoteRe.FindStringSubmatch(link)
+	if len(mm) < 2 {
+		return "", false
+	}
+	canonical := "xiaohongshu.com/explore/" + mm[1]
+	if u, err := url.Parse("https://" + link); err == nil {
+		if token := u.Query().Get("xsec_token"); token != "" {
+			canonical += "?xsec_token=" + url.QueryEscape(token)
+		}
+	}
+	return canonical, true
+}
+
+// The note page is given this long to name the author before the repost goes out without it
+var xiaohongshuAuthorTimeout = 2 * time.Second
+
+// Authors are remembered per note for this long, up to XIAOHONGSHU_AUTHOR_CACHE_SIZE notes;
+// failed lookups too, so a slow site isn't asked again for every repost of the same note
+const XIAOHONGSHU_AUTHOR_CACHE_TTL = 1 * time.Hour
+const XIAOHONGSHU_AUTHOR_CACHE_SIZE = 512
+
+// what a repost shows when the author couldn't be found
+const XIAOHONGSHU_UNKNOWN_AUTHOR = "RedNote"
+
+type xiaohongshuAuthorEntry struct {
+	note    string
+	author  string
+	fetched time.Time
+}
+
+// LRU cache of note ID -> author nickname
+var xiaohongshuAuthors = struct {
+	sync.Mutex
+	order *list.List // front = most recently used
+	m     map[string]*list.Element
+}{order: list.New(), m: make(map[string]*list.Element)}
+
+func cachedXiaohongshuAuthor(note string) (string, bool) {
+	xiaohongshuAuthors.Lock()
+	defer xiaohongshuAuthors.Unlock()
+	el, ok := xiaohongshuAuthors.m[note]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*xiaohongshuAuthorEntry)
+	if time.Since(entry.fetched) > XIAOHONGSHU_AUTHOR_CACHE_TTL {
+		xiaohongshuAuthors.order.Remove(el)
+		delete(xiaohongshuAuthors.m, note)
+		return "", false
+	}
+	xiaohongshuAuthors.order.MoveToFront(el)
+	return entry.author, true
+}
+
+func storeXiaohongshuAuthor(note, author string) {
+	xiaohongshuAuthors.Lock()
+	defer xiaohongshuAuthors.Unlock()
+	if el, ok := xiaohongshuAuthors.m[note]; ok {
+		el.Value = &xiaohongshuAuthorEntry{note: note, author: author, fetched: time.Now()}
+		xiaohongshuAuthors.order.MoveToFront(el)
+		return
+	}
+	xiaohongshuAuthors.m[note] = xiaohongshuAuthors.order.PushFront(&xiaohongshuAuthorEntry{note: note, author: author, fetched: time.Now()})
+	if xiaohongshuAuthors.order.Len() > XIAOHONGSHU_AUTHOR_CACHE_SIZE {
+		oldest := xiaohongshuAuthors.order.Back()
+		xiaohongshuAuthors.order.Remove(oldest)
+		delete(xiaohongshuAuthors.m, oldest.Value.(*xiaohongshuAuthorEntry).note)
+	}
+}
+
+// fetchXiaohongshuAuthor looks up the note author's nickname from the note page.
+// Notes don't carry the author in their URL, so this is best effort.
+func fetchXiaohongshuAuthor(link string) string {
+	note := link
+	if mm := xiaohongshuNoteRe.FindStringSubmatch(link); len(mm) > 1 {
+		note = mm[1]
+	}
+	if author, ok := cachedXiaohongshuAuthor(note); ok {
+		return author
+	}
+	author := XIAOHONGSHU_UNKNOWN_AUTHOR
+	defer func() { storeXiaohongshuAuthor(note, author) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), xiaohongshuAuthorTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www."+link, nil)
+	if err != nil {
+		return author
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; FixEmbed/"+VERSION+")")
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		return author
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if mm := xiaohongshuAuthorRe.FindSubmatch(body); len(mm) > 1 {
+		author = string(mm[1])
+	}
+	return author
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCanonicalXiaohongshuLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+		ok   bool
+	}{
+		{"xiaohongshu.com/explore/64ab01", "xiaohongshu.com/explore/64ab01", true},
+		{"xiaohongshu.com/discovery/item/64ab01?xsec_token=AB%2Bc&xsec_source=pc_share", "xiaohongshu.com/explore/64ab01?xsec_token=AB%2Bc", true},
+		{"xiaohongshu.com/user/profile/64ab01", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := canonicalXiaohongshuLink(tt.link); got != tt.want || ok != tt.ok {
+			t.Errorf("canonicalXiaohongshuLink(%q) = %q, %t; want %q, %t", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFetchXiaohongshuAuthor(t *testing.T) {
+	transport := redirectClient.Transport
+	timeout := xiaohongshuAuthorTimeout
+	t.Cleanup(func() {
+		redirectClient.Transport = transport
+		xiaohongshuAuthorTimeout = timeout
+	})
+	xiaohongshuAuthorTimeout = 50 * time.Millisecond
+	var requests atomic.Int32
+	redirectClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		if strings.Contains(r.URL.Path, "/slow") {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		body := `{"user":{"nickname":"someone"}}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+
+	// the author is looked up once per note, whatever token the link carries
+	for _, link := range []string{"xiaohongshu.com/explore/64ab02?xsec_token=a", "xiaohongshu.com/explore/64ab02?xsec_token=b"} {
+		if got := fetchXiaohongshuAuthor(link); got != "someone" {
+			t.Errorf("fetchXiaohongshuAuthor(%s) = %q, want someone", link, got)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("fetched the note page %d times, want once", n)
+	}
+
+	// a page that doesn't answer in time leaves the repost with the service's name
+	start := time.Now()
+	if got := fetchXiaohongshuAuthor("xiaohongshu.com/explore/slow"); got != XIAOHONGSHU_UNKNOWN_AUTHOR {
+		t.Errorf("slow note author = %q, want %q", got, XIAOHONGSHU_UNKNOWN_AUTHOR)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v for a slow note page", elapsed)
+	}
+}